@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var apiKeyNameFlag string
+var apiKeyIDFlag uint
+
+var APIKeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "Gère les clés d'API utilisées pour appeler les routes /api/v1.",
+	Long: `Ce groupe de commandes permet d'émettre, de lister et de révoquer les clés d'API.
+Chaque lien créé via l'API appartient à la clé qui l'a créé : seule cette clé
+peut ensuite consulter ses statistiques, le modifier ou le supprimer.`,
+}
+
+var APIKeyCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Émet une nouvelle clé d'API.",
+	Long: `Cette commande génère une nouvelle clé d'API et l'affiche une seule fois.
+Seule son empreinte est conservée en base : notez-la immédiatement.
+
+Exemple:
+  url-shortener apikey create --name="équipe marketing"`,
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		if apiKeyNameFlag == "" {
+			fmt.Println("Erreur: Le flag --name est requis")
+			os.Exit(1)
+		}
+
+		apiKeyService, closeDB := openAPIKeyService()
+		defer closeDB()
+
+		plaintext, key, err := apiKeyService.IssueKey(apiKeyNameFlag)
+		if err != nil {
+			log.Printf("Erreur lors de la création de la clé d'API: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Clé d'API créée avec succès:\n")
+		fmt.Printf("ID: %d\n", key.ID)
+		fmt.Printf("Nom: %s\n", key.Name)
+		fmt.Printf("Clé: %s\n", plaintext)
+		fmt.Println("Conservez cette clé en lieu sûr, elle ne sera plus affichée.")
+	},
+}
+
+var APIKeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les clés d'API.",
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		apiKeyService, closeDB := openAPIKeyService()
+		defer closeDB()
+
+		keys, err := apiKeyService.ListKeys()
+		if err != nil {
+			log.Printf("Erreur lors de la récupération des clés d'API: %v", err)
+			os.Exit(1)
+		}
+
+		if len(keys) == 0 {
+			fmt.Println("Aucune clé d'API.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNOM\tPRÉFIXE\tCRÉÉE LE\tDERNIÈRE UTILISATION\tSTATUT")
+		for _, key := range keys {
+			status := "active"
+			if key.IsRevoked() {
+				status = "révoquée le " + key.RevokedAt.Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s…\t%s\t%s\t%s\n",
+				key.ID, key.Name, key.Prefix, key.CreatedAt.Format("2006-01-02 15:04"),
+				formatOptionalTime(key.LastUsedAt), status)
+		}
+		w.Flush()
+	},
+}
+
+var APIKeyRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Révoque une clé d'API.",
+	Long: `Cette commande révoque une clé d'API : elle ne permet plus de s'authentifier.
+Les liens qu'elle a créés sont conservés et continuent de rediriger.
+
+Exemple:
+  url-shortener apikey revoke --id=3`,
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		if apiKeyIDFlag == 0 {
+			fmt.Println("Erreur: Le flag --id est requis")
+			os.Exit(1)
+		}
+
+		apiKeyService, closeDB := openAPIKeyService()
+		defer closeDB()
+
+		key, err := apiKeyService.RevokeKey(apiKeyIDFlag)
+		if err != nil {
+			if errors.Is(err, models.ErrAPIKeyNotFound) {
+				fmt.Printf("Erreur: Aucune clé d'API avec l'ID %d\n", apiKeyIDFlag)
+				os.Exit(1)
+			}
+			log.Printf("Erreur lors de la révocation de la clé d'API: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Clé d'API %d (%s) révoquée.\n", key.ID, key.Name)
+	},
+}
+
+// openAPIKeyService construit le APIKeyService utilisé par les commandes 'apikey'.
+// La fonction retournée ferme la connexion à la base de données.
+func openAPIKeyService() (*services.APIKeyService, func()) {
+	db, closeDB := openDatabase()
+	return services.NewAPIKeyService(repository.NewAPIKeyRepository(db)), closeDB
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+func init() {
+	APIKeyCreateCmd.Flags().StringVar(&apiKeyNameFlag, "name", "", "Nom permettant d'identifier le détenteur de la clé")
+	APIKeyCreateCmd.MarkFlagRequired("name")
+	APIKeyRevokeCmd.Flags().UintVar(&apiKeyIDFlag, "id", 0, "ID de la clé d'API à révoquer")
+	APIKeyRevokeCmd.MarkFlagRequired("id")
+
+	APIKeyCmd.AddCommand(APIKeyCreateCmd, APIKeyListCmd, APIKeyRevokeCmd)
+	cmd.RootCmd.AddCommand(APIKeyCmd)
+}
@@ -1,28 +1,38 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
 	"os"
+	"time"
 
 	"github.com/axellelanca/urlshortener/cmd"
-	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 var longURLFlag string
+var customCodeFlag string
+var expiresAtFlag string
+var expiresInFlag time.Duration
+var maxClicksFlag int
 
 var CreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Crée une URL courte à partir d'une URL longue.",
 	Long: `Cette commande raccourcit une URL longue fournie et affiche le code court généré.
 
-Exemple:
-  url-shortener create --url="https://www.google.com/search?q=go+lang"`,
+Un alias personnalisé peut être fourni avec --code à la place du code aléatoire.
+La durée de vie du lien peut être limitée par une date (--expires-at ou --expires-in)
+et/ou par un nombre maximal de clics (--max-clicks).
+
+Exemples:
+  url-shortener create --url="https://www.google.com/search?q=go+lang"
+  url-shortener create --url="https://example.com/promo" --code="spring-sale"
+  url-shortener create --url="https://example.com/promo" --expires-in=72h --max-clicks=1000`,
 	Run: func(cobraCmd *cobra.Command, args []string) {
 		if longURLFlag == "" {
 			fmt.Println("Erreur: Le flag --url est requis")
@@ -35,42 +45,70 @@ Exemple:
 			os.Exit(1)
 		}
 
-		cfg := cmd.Cfg
-		if cfg == nil {
-			log.Fatalf("FATAL: Configuration non chargée")
+		opts := services.CreateLinkOptions{
+			CustomCode: customCodeFlag,
 		}
 
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
-		if err != nil {
-			log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
+		if expiresAtFlag != "" {
+			expiresAt, err := time.Parse(time.RFC3339, expiresAtFlag)
+			if err != nil {
+				fmt.Printf("Erreur: Date d'expiration invalide (format RFC3339 attendu): %v\n", err)
+				os.Exit(1)
+			}
+			opts.ExpiresAt = &expiresAt
+		} else if expiresInFlag != 0 {
+			expiresAt := time.Now().Add(expiresInFlag)
+			opts.ExpiresAt = &expiresAt
 		}
 
-		sqlDB, err := db.DB()
-		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+		if cobraCmd.Flags().Changed("max-clicks") {
+			opts.MaxClicks = &maxClicksFlag
 		}
 
-		defer sqlDB.Close()
+		linkService, closeDB := openLinkService()
+		defer closeDB()
 
-		linkRepo := repository.NewLinkRepository(db)
-		clickRepo := repository.NewClickRepository(db)
-		linkService := services.NewLinkService(linkRepo, clickRepo)
+		if customCodeFlag != "" {
+			if err := linkService.ValidateCustomCode(customCodeFlag); err != nil {
+				fmt.Printf("Erreur: Code personnalisé '%s' refusé: %v\n", customCodeFlag, err)
+				os.Exit(1)
+			}
+		}
 
-		link, err := linkService.CreateLink(longURLFlag)
+		link, err := linkService.CreateLinkWithOptions(longURLFlag, opts)
 		if err != nil {
+			if errors.Is(err, models.ErrDuplicateShortCode) {
+				fmt.Printf("Erreur: Le code '%s' est déjà utilisé\n", customCodeFlag)
+				os.Exit(1)
+			}
+			if errors.Is(err, models.ErrInvalidExpiration) || errors.Is(err, models.ErrInvalidMaxClicks) {
+				fmt.Printf("Erreur: %v\n", err)
+				os.Exit(1)
+			}
 			log.Printf("Erreur lors de la création du lien: %v", err)
 			os.Exit(1)
 		}
 
-		fullShortURL := fmt.Sprintf("%s/%s", cfg.Server.BaseURL, link.ShortCode)
+		fullShortURL := fmt.Sprintf("%s/%s", cmd.Cfg.Server.BaseURL, link.ShortCode)
 		fmt.Printf("URL courte créée avec succès:\n")
 		fmt.Printf("Code: %s\n", link.ShortCode)
 		fmt.Printf("URL complète: %s\n", fullShortURL)
+		if link.ExpiresAt != nil {
+			fmt.Printf("Expire le: %s\n", link.ExpiresAt.Format(time.RFC3339))
+		}
+		if link.MaxClicks != nil {
+			fmt.Printf("Nombre maximal de clics: %d\n", *link.MaxClicks)
+		}
 	},
 }
 
 func init() {
 	CreateCmd.Flags().StringVar(&longURLFlag, "url", "", "URL longue à raccourcir")
+	CreateCmd.Flags().StringVar(&customCodeFlag, "code", "", "Alias personnalisé à utiliser comme code court (optionnel)")
+	CreateCmd.Flags().StringVar(&expiresAtFlag, "expires-at", "", "Date d'expiration du lien au format RFC3339 (optionnel)")
+	CreateCmd.Flags().DurationVar(&expiresInFlag, "expires-in", 0, "Durée de vie du lien, ex: 24h (optionnel)")
+	CreateCmd.Flags().IntVar(&maxClicksFlag, "max-clicks", 0, "Nombre maximal de clics avant épuisement du lien (optionnel)")
+	CreateCmd.MarkFlagsMutuallyExclusive("expires-at", "expires-in")
 
 	CreateCmd.MarkFlagRequired("url")
 	cmd.RootCmd.AddCommand(CreateCmd)
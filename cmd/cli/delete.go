@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/spf13/cobra"
+)
+
+var deleteCodeFlag string
+
+var DeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Supprime un lien court (suppression logique).",
+	Long: `Cette commande supprime un lien court. La suppression est logique :
+le code reste réservé et le lien peut être récupéré avec la commande 'restore'.
+
+Exemple:
+  url-shortener delete --code="xyz123"`,
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		if deleteCodeFlag == "" {
+			fmt.Println("Erreur: Le flag --code est requis")
+			os.Exit(1)
+		}
+
+		linkService, closeDB := openLinkService()
+		defer closeDB()
+
+		if err := linkService.DeleteLink(deleteCodeFlag); err != nil {
+			exitOnLinkError(deleteCodeFlag, "la suppression du lien", err)
+		}
+
+		fmt.Printf("Lien '%s' supprimé. Utilisez 'restore --code=%s' pour le restaurer.\n", deleteCodeFlag, deleteCodeFlag)
+	},
+}
+
+func init() {
+	DeleteCmd.Flags().StringVar(&deleteCodeFlag, "code", "", "Code court du lien à supprimer")
+	DeleteCmd.MarkFlagRequired("code")
+	cmd.RootCmd.AddCommand(DeleteCmd)
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/spf13/cobra"
+)
+
+var disableCodeFlag string
+var enableFlag bool
+
+var DisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Désactive (ou réactive) un lien court.",
+	Long: `Cette commande désactive un lien court : il ne redirige plus (réponse 410)
+mais reste consultable et ses statistiques sont conservées.
+Utilisez --enable pour réactiver un lien désactivé.
+
+Exemples:
+  url-shortener disable --code="xyz123"
+  url-shortener disable --code="xyz123" --enable`,
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		if disableCodeFlag == "" {
+			fmt.Println("Erreur: Le flag --code est requis")
+			os.Exit(1)
+		}
+
+		linkService, closeDB := openLinkService()
+		defer closeDB()
+
+		link, err := linkService.SetLinkDisabled(disableCodeFlag, !enableFlag)
+		if err != nil {
+			exitOnLinkError(disableCodeFlag, "la modification du lien", err)
+		}
+
+		if link.Disabled {
+			fmt.Printf("Lien '%s' désactivé.\n", link.ShortCode)
+		} else {
+			fmt.Printf("Lien '%s' réactivé.\n", link.ShortCode)
+		}
+	},
+}
+
+func init() {
+	DisableCmd.Flags().StringVar(&disableCodeFlag, "code", "", "Code court du lien à désactiver")
+	DisableCmd.Flags().BoolVar(&enableFlag, "enable", false, "Réactive le lien au lieu de le désactiver")
+	DisableCmd.MarkFlagRequired("code")
+	cmd.RootCmd.AddCommand(DisableCmd)
+}
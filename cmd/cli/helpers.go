@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/shortcode"
+	"gorm.io/gorm"
+)
+
+// openDatabase se connecte à la base de données configurée. La fonction retournée ferme la connexion.
+func openDatabase() (*gorm.DB, func()) {
+	cfg := cmd.Cfg
+	if cfg == nil {
+		log.Fatalf("FATAL: Configuration non chargée")
+	}
+
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+	}
+
+	return db, func() { sqlDB.Close() }
+}
+
+// openLinkService construit le LinkService utilisé par les commandes de gestion des liens.
+// La fonction retournée ferme la connexion à la base de données.
+func openLinkService() (*services.LinkService, func()) {
+	db, closeDB := openDatabase()
+
+	linkRepo := repository.NewLinkRepository(db)
+	clickRepo := repository.NewClickRepository(db)
+	linkService := services.NewLinkService(linkRepo, clickRepo)
+	// Les alias refusés par le serveur le sont aussi par la CLI.
+	linkService.ReserveRoute(cmd.Cfg.Metrics.Path)
+
+	// Les codes générés par la CLI suivent la même stratégie que ceux du serveur.
+	codeGenerator, err := shortcode.New(cmd.Cfg.ShortCode, linkRepo)
+	if err != nil {
+		log.Fatalf("FATAL: Configuration shortcode invalide: %v", err)
+	}
+	linkService.SetCodeGenerator(codeGenerator)
+
+	return linkService, closeDB
+}
+
+// exitOnLinkError affiche un message adapté à l'erreur retournée par le LinkService et termine le programme.
+func exitOnLinkError(shortCode string, action string, err error) {
+	if errors.Is(err, models.ErrLinkNotFound) {
+		fmt.Printf("Erreur: Aucun lien trouvé avec le code '%s'\n", shortCode)
+		os.Exit(1)
+	}
+	log.Printf("Erreur lors de %s: %v", action, err)
+	os.Exit(1)
+}
+
+// parseDateFlag accepte une date au format AAAA-MM-JJ ou RFC3339. Une valeur vide retourne nil.
+func parseDateFlag(name string, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return &t, nil
+	}
+	return nil, fmt.Errorf("date invalide pour %s (format AAAA-MM-JJ ou RFC3339 attendu): %s", name, value)
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listLimitFlag  int
+	listCursorFlag string
+	listSortFlag   string
+	listOrderFlag  string
+	listDomainFlag string
+	listSearchFlag string
+	listFromFlag   string
+	listToFlag     string
+	listOutputFlag string
+)
+
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les liens courts avec filtres, tri et pagination.",
+	Long: `Cette commande affiche les liens courts existants, page par page.
+Les liens peuvent être filtrés par domaine de destination, par sous-chaîne
+et par période de création, puis triés par date de création ou par nombre de clics.
+Le curseur affiché en fin de page permet de récupérer la page suivante avec --cursor.
+
+Exemples:
+  url-shortener list
+  url-shortener list --domain="example.com" --sort=clicks --limit=50
+  url-shortener list --from=2025-01-01 --to=2025-02-01 --output=json`,
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		if listOutputFlag != "table" && listOutputFlag != "json" {
+			fmt.Println("Erreur: Le flag --output doit valoir 'table' ou 'json'")
+			os.Exit(1)
+		}
+
+		createdAfter, err := parseDateFlag("--from", listFromFlag)
+		if err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		}
+		createdBefore, err := parseDateFlag("--to", listToFlag)
+		if err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		}
+
+		linkService, closeDB := openLinkService()
+		defer closeDB()
+
+		page, err := linkService.ListLinks(services.ListLinksOptions{
+			Domain:        listDomainFlag,
+			Search:        listSearchFlag,
+			CreatedAfter:  createdAfter,
+			CreatedBefore: createdBefore,
+			SortBy:        listSortFlag,
+			Order:         listOrderFlag,
+			Cursor:        listCursorFlag,
+			Limit:         listLimitFlag,
+		})
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidCursor) || errors.Is(err, models.ErrInvalidListQuery) {
+				fmt.Printf("Erreur: %v\n", err)
+				os.Exit(1)
+			}
+			log.Printf("Erreur lors de la récupération des liens: %v", err)
+			os.Exit(1)
+		}
+
+		if listOutputFlag == "json" {
+			printLinkPageJSON(page)
+			return
+		}
+		printLinkPageTable(page)
+	},
+}
+
+type listedLink struct {
+	ShortCode   string     `json:"short_code"`
+	LongURL     string     `json:"long_url"`
+	CreatedAt   time.Time  `json:"created_at"`
+	TotalClicks int        `json:"total_clicks"`
+	Status      string     `json:"status"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+func printLinkPageJSON(page *services.LinkPage) {
+	now := time.Now()
+	output := struct {
+		Links      []listedLink `json:"links"`
+		NextCursor string       `json:"next_cursor,omitempty"`
+	}{
+		Links:      make([]listedLink, 0, len(page.Links)),
+		NextCursor: page.NextCursor,
+	}
+	for _, link := range page.Links {
+		output.Links = append(output.Links, listedLink{
+			ShortCode:   link.ShortCode,
+			LongURL:     link.LongURL,
+			CreatedAt:   link.CreatedAt,
+			TotalClicks: link.ClickCount,
+			Status:      link.Status(link.ClickCount, now),
+			ExpiresAt:   link.ExpiresAt,
+		})
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		log.Fatalf("Erreur lors de l'encodage JSON: %v", err)
+	}
+}
+
+func printLinkPageTable(page *services.LinkPage) {
+	if len(page.Links) == 0 {
+		fmt.Println("Aucun lien trouvé.")
+		return
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CODE\tCLICS\tSTATUT\tCRÉÉ LE\tURL LONGUE")
+	for _, link := range page.Links {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n",
+			link.ShortCode, link.ClickCount, link.Status(link.ClickCount, now),
+			link.CreatedAt.Format("2006-01-02 15:04"), link.LongURL)
+	}
+	w.Flush()
+
+	if page.NextCursor != "" {
+		fmt.Printf("\nPage suivante: --cursor=%s\n", page.NextCursor)
+	}
+}
+
+func init() {
+	ListCmd.Flags().IntVar(&listLimitFlag, "limit", 20, "Nombre maximal de liens par page (100 au maximum)")
+	ListCmd.Flags().StringVar(&listCursorFlag, "cursor", "", "Curseur de la page à afficher (fourni en fin de page précédente)")
+	ListCmd.Flags().StringVar(&listSortFlag, "sort", "created_at", "Champ de tri: created_at ou clicks")
+	ListCmd.Flags().StringVar(&listOrderFlag, "order", "desc", "Ordre de tri: asc ou desc")
+	ListCmd.Flags().StringVar(&listDomainFlag, "domain", "", "Filtre sur le domaine de l'URL longue (sous-domaines inclus)")
+	ListCmd.Flags().StringVar(&listSearchFlag, "search", "", "Filtre sur une sous-chaîne de l'URL longue ou du code")
+	ListCmd.Flags().StringVar(&listFromFlag, "from", "", "Liens créés à partir de cette date (AAAA-MM-JJ ou RFC3339)")
+	ListCmd.Flags().StringVar(&listToFlag, "to", "", "Liens créés avant cette date (AAAA-MM-JJ ou RFC3339)")
+	ListCmd.Flags().StringVarP(&listOutputFlag, "output", "o", "table", "Format de sortie: table ou json")
+	cmd.RootCmd.AddCommand(ListCmd)
+}
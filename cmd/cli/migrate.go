@@ -1,47 +1,236 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
 
 	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/migrations"
 	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+var migrateDirFlag string
+
 var MigrateCmd = &cobra.Command{
 	Use:   "migrate",
-	Short: "Exécute les migrations de la base de données pour créer ou mettre à jour les tables.",
-	Long: `Cette commande se connecte à la base de données configurée (SQLite)
-et exécute les migrations automatiques de GORM pour créer les tables 'links' et 'clicks'
-basées sur les modèles Go.`,
+	Short: "Applique les migrations versionnées de la base de données.",
+	Long: `Ce groupe de commandes gère le schéma de la base de données configurée (SQLite, PostgreSQL
+ou MySQL) à l'aide de migrations SQL versionnées, embarquées dans le binaire. Les migrations
+appliquées sont enregistrées dans la table 'schema_migrations'.
+
+Sans sous-commande, 'migrate' équivaut à 'migrate up'.`,
+	Args: cobra.NoArgs,
 	Run: func(cobraCmd *cobra.Command, args []string) {
-		cfg := cmd.Cfg
-		if cfg == nil {
-			log.Fatalf("FATAL: Configuration non chargée")
+		runMigrateUp()
+	},
+}
+
+var MigrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Applique toutes les migrations en attente.",
+	Long: `Cette commande applique, dans l'ordre, les migrations qui ne l'ont pas encore été.
+Une base créée avant les migrations versionnées est d'abord mise à niveau puis
+enregistrée comme étant à la version initiale.`,
+	Args: cobra.NoArgs,
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		runMigrateUp()
+	},
+}
+
+var MigrateDownCmd = &cobra.Command{
+	Use:   "down [N]",
+	Short: "Annule les N dernières migrations appliquées (1 par défaut).",
+	Long: `Cette commande annule les N dernières migrations appliquées, de la plus récente
+à la plus ancienne. Les données des tables supprimées sont perdues.
+
+Exemple:
+  url-shortener migrate down 2`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		n := 1
+		if len(args) == 1 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil || n <= 0 {
+				fmt.Printf("Erreur: Nombre de migrations invalide: %s\n", args[0])
+				os.Exit(1)
+			}
 		}
 
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		reverted, err := openMigrator(db).Down(n)
+		for _, migration := range reverted {
+			fmt.Printf("Migration %s annulée.\n", migration.ID())
+		}
 		if err != nil {
-			log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
+			log.Fatalf("FATAL: Échec de l'annulation des migrations: %v", err)
 		}
+		if len(reverted) == 0 {
+			fmt.Println("Aucune migration à annuler.")
+		}
+	},
+}
 
-		sqlDB, err := db.DB()
+var MigrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Affiche l'état de chaque migration.",
+	Args:  cobra.NoArgs,
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		migrator := openMigrator(db)
+		statuses, err := migrator.Status()
 		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+			log.Fatalf("FATAL: Échec de la lecture des migrations: %v", err)
 		}
-		defer sqlDB.Close()
 
-		if err := db.AutoMigrate(&models.Link{}, &models.Click{}); err != nil {
-			log.Fatalf("FATAL: Échec des migrations: %v", err)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNOM\tSTATUT\tAPPLIQUÉE LE")
+		for _, status := range statuses {
+			state := "en attente"
+			switch {
+			case status.Dirty:
+				state = "dirty"
+			case status.Unknown:
+				state = "appliquée (inconnue de ce binaire)"
+			case status.Applied:
+				state = "appliquée"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, formatOptionalTime(status.AppliedAt))
 		}
+		w.Flush()
 
-		fmt.Println("Migrations de la base de données exécutées avec succès.")
+		if migrator.NeedsBaseline() {
+			fmt.Println("\nBase créée avant les migrations versionnées : exécutez 'migrate up' pour l'enregistrer.")
+		} else if err := migrator.Check(context.Background()); err != nil {
+			fmt.Printf("\n%v\n", err)
+		} else if err := repository.CheckSchema(db); err != nil {
+			// Le schéma est à jour mais diffère des modèles : une migration manque ou est incomplète.
+			fmt.Printf("\nLe schéma ne correspond pas aux modèles: %v\n", err)
+		}
 	},
 }
 
+var MigrateCreateCmd = &cobra.Command{
+	Use:   "create NAME",
+	Short: "Crée les fichiers d'une nouvelle migration pour chaque driver.",
+	Long: `Cette commande crée, pour SQLite, PostgreSQL et MySQL, les fichiers vides NNNN_nom.up.sql
+et NNNN_nom.down.sql de la migration suivante. Ils sont embarqués dans le binaire à la
+prochaine compilation.
+
+Exemple:
+  url-shortener migrate create add_link_tags`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		paths, err := migrations.Create(migrateDirFlag, args[0])
+		if err != nil {
+			log.Fatalf("FATAL: Échec de la création de la migration: %v", err)
+		}
+		for _, path := range paths {
+			fmt.Printf("Créé: %s\n", path)
+		}
+	},
+}
+
+var MigrateForceCmd = &cobra.Command{
+	Use:   "force VERSION",
+	Short: "Enregistre le schéma à une version donnée sans exécuter de migration.",
+	Long: `Après l'échec d'une migration (schéma dirty), corrigez le schéma à la main puis
+enregistrez la version à laquelle il se trouve réellement. Les migrations suivantes
+seront de nouveau considérées en attente. La version 0 correspond à une base vide.
+
+Exemple:
+  url-shortener migrate force 3`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		version, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || version < 0 {
+			fmt.Printf("Erreur: Version invalide: %s\n", args[0])
+			os.Exit(1)
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		if err := openMigrator(db).Force(version); err != nil {
+			log.Fatalf("FATAL: Échec de l'enregistrement de la version: %v", err)
+		}
+		fmt.Printf("Schéma enregistré à la version %04d.\n", version)
+	},
+}
+
+func runMigrateUp() {
+	db, closeDB := openDatabase()
+	defer closeDB()
+
+	migrator := openMigrator(db)
+	if migrator.NeedsBaseline() {
+		baselineLegacySchema(db, migrator)
+	}
+
+	applied, err := migrator.Up()
+	for _, migration := range applied {
+		fmt.Printf("Migration %s appliquée.\n", migration.ID())
+	}
+	if err != nil {
+		log.Fatalf("FATAL: Échec des migrations: %v", err)
+	}
+	if len(applied) == 0 {
+		fmt.Println("Le schéma de la base de données est déjà à jour.")
+		return
+	}
+	fmt.Println("Migrations de la base de données exécutées avec succès.")
+}
+
+// baselineLegacySchema met à niveau une base créée par les migrations automatiques de GORM et
+// l'enregistre à la version initiale : les modèles de cette version correspondent à la migration
+// migrations.BaselineVersion.
+func baselineLegacySchema(db *gorm.DB, migrator *migrations.Migrator) {
+	// Les rollups d'une base existante sont initialisés à partir des clics déjà enregistrés.
+	initRollups := !db.Migrator().HasTable(&models.DailyClickRollup{})
+
+	if err := db.AutoMigrate(models.AllModels()...); err != nil {
+		log.Fatalf("FATAL: Échec de la mise à niveau du schéma existant: %v", err)
+	}
+
+	if initRollups {
+		result, err := repository.NewClickRepository(db).RebuildRollups(time.Time{}, time.Time{})
+		if err != nil {
+			log.Fatalf("FATAL: Échec de l'initialisation des rollups: %v", err)
+		}
+		if result.Clicks > 0 {
+			fmt.Printf("Rollups initialisés à partir de %d clic(s) existant(s).\n", result.Clicks)
+		}
+	}
+
+	if err := migrator.Force(migrations.BaselineVersion); err != nil {
+		log.Fatalf("FATAL: Échec de l'enregistrement de la version initiale: %v", err)
+	}
+	fmt.Printf("Base existante enregistrée à la version %04d.\n", migrations.BaselineVersion)
+}
+
+// openMigrator charge les migrations embarquées du driver de db.
+func openMigrator(db *gorm.DB) *migrations.Migrator {
+	migrator, err := migrations.New(db)
+	if err != nil {
+		log.Fatalf("FATAL: Échec du chargement des migrations: %v", err)
+	}
+	return migrator
+}
+
 func init() {
+	MigrateCreateCmd.Flags().StringVar(&migrateDirFlag, "dir", "internal/migrations/sql", "Répertoire des migrations, avec un sous-répertoire par driver")
+
+	MigrateCmd.AddCommand(MigrateUpCmd, MigrateDownCmd, MigrateStatusCmd, MigrateCreateCmd, MigrateForceCmd)
 	cmd.RootCmd.AddCommand(MigrateCmd)
 }
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var purgeOlderThanDaysFlag int
+var purgeModeFlag string
+var purgeDryRunFlag bool
+
+var PurgeClicksCmd = &cobra.Command{
+	Use:   "purge-clicks",
+	Short: "Applique la politique de rétention aux clics anciens.",
+	Long: `Cette commande supprime les clics plus anciens que le nombre de jours donné,
+ou, en mode aggregate, efface leurs données personnelles (IP, User-Agent brut,
+referrer complet) en conservant les statistiques agrégées.
+
+En mode delete, les compteurs et séries temporelles restent complets grâce aux
+rollups, et les clics supprimés sont ajoutés aux ventilations par référent,
+navigateur, système et appareil avant leur suppression. Les journées purgées ne
+peuvent en revanche plus être recalculées par rebuild-rollups.
+
+Par défaut, la durée et le mode sont ceux de la section 'retention' de la configuration.
+Avec --dry-run, la commande affiche le nombre de clics concernés sans rien modifier.
+
+Exemples:
+  url-shortener purge-clicks --older-than-days=90 --dry-run
+  url-shortener purge-clicks --older-than-days=365 --mode=aggregate`,
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		cfg := cmd.Cfg
+		if cfg == nil {
+			log.Fatalf("FATAL: Configuration non chargée")
+		}
+
+		days := cfg.Retention.ClickDays
+		if cobraCmd.Flags().Changed("older-than-days") {
+			days = purgeOlderThanDaysFlag
+		}
+		if days <= 0 {
+			fmt.Println("Erreur: Aucune durée de rétention configurée, utilisez --older-than-days avec une valeur positive")
+			os.Exit(1)
+		}
+
+		mode := cfg.Retention.Mode
+		if purgeModeFlag != "" {
+			mode = purgeModeFlag
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		clickService := services.NewClickService(repository.NewClickRepository(db))
+
+		before := time.Now().AddDate(0, 0, -days)
+		count, err := clickService.PurgeClicks(before, mode, purgeDryRunFlag)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidRetentionMode) {
+				fmt.Printf("Erreur: Mode '%s' invalide, valeurs possibles: delete, aggregate\n", mode)
+				os.Exit(1)
+			}
+			log.Printf("Erreur lors de la purge des clics (%d clic(s) déjà traité(s)): %v", count, err)
+			os.Exit(1)
+		}
+
+		action := "supprimé(s)"
+		if mode == services.RetentionModeAggregate {
+			action = "anonymisé(s)"
+		}
+		if purgeDryRunFlag {
+			fmt.Printf("Simulation: %d clic(s) antérieur(s) au %s seraient %s.\n", count, before.Format(time.DateOnly), action)
+			return
+		}
+		fmt.Printf("%d clic(s) antérieur(s) au %s %s.\n", count, before.Format(time.DateOnly), action)
+	},
+}
+
+func init() {
+	PurgeClicksCmd.Flags().IntVar(&purgeOlderThanDaysFlag, "older-than-days", 0, "Âge en jours au-delà duquel les clics sont purgés (défaut: retention.click_days)")
+	PurgeClicksCmd.Flags().StringVar(&purgeModeFlag, "mode", "", "Mode de purge: delete ou aggregate (défaut: retention.mode)")
+	PurgeClicksCmd.Flags().BoolVar(&purgeDryRunFlag, "dry-run", false, "Affiche le nombre de clics concernés sans les modifier")
+	cmd.RootCmd.AddCommand(PurgeClicksCmd)
+}
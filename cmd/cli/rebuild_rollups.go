@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var rebuildFromFlag string
+var rebuildToFlag string
+
+var RebuildRollupsCmd = &cobra.Command{
+	Use:   "rebuild-rollups",
+	Short: "Recalcule les rollups de clics à partir des clics bruts.",
+	Long: `Cette commande recalcule les rollups horaires et journaliers utilisés par les
+statistiques à partir de la table des clics, pour les journées (UTC) comprises
+entre --from (inclus) et --to (exclu).
+
+Les clics purgés en mode delete ne sont plus présents : la commande refuse une
+plage contenant des journées purgées, dont elle effacerait les compteurs. Si une
+rétention en mode delete est configurée, --from vaut par défaut le premier jour
+entièrement conservé.
+La commande est à lancer de préférence serveur arrêté.
+
+Exemples:
+  url-shortener rebuild-rollups
+  url-shortener rebuild-rollups --from=2025-01-01 --to=2025-02-01`,
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		cfg := cmd.Cfg
+		if cfg == nil {
+			log.Fatalf("FATAL: Configuration non chargée")
+		}
+
+		from, err := parseDateFlag("--from", rebuildFromFlag)
+		if err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		}
+		to, err := parseDateFlag("--to", rebuildToFlag)
+		if err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		}
+
+		if from == nil && cfg.Retention.ClickDays > 0 && cfg.Retention.Mode == services.RetentionModeDelete {
+			cutoff := time.Now().UTC().AddDate(0, 0, -cfg.Retention.ClickDays)
+			firstKeptDay := time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day()+1, 0, 0, 0, 0, time.UTC)
+			from = &firstKeptDay
+			fmt.Printf("Rétention de %d jours en mode delete: reconstruction à partir du %s.\n",
+				cfg.Retention.ClickDays, firstKeptDay.Format(time.DateOnly))
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		clickService := services.NewClickService(repository.NewClickRepository(db))
+		result, err := clickService.RebuildRollups(from, to)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidTimeRange) || errors.Is(err, models.ErrClicksPurged) {
+				fmt.Printf("Erreur: %v\n", err)
+				os.Exit(1)
+			}
+			log.Printf("Erreur lors de la reconstruction des rollups: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Rollups reconstruits à partir de %d clic(s): %d intervalle(s) horaire(s), %d journalier(s).\n",
+			result.Clicks, result.HourlyBuckets, result.DailyBuckets)
+	},
+}
+
+func init() {
+	RebuildRollupsCmd.Flags().StringVar(&rebuildFromFlag, "from", "", "Première journée à reconstruire (AAAA-MM-JJ ou RFC3339)")
+	RebuildRollupsCmd.Flags().StringVar(&rebuildToFlag, "to", "", "Journée de fin, exclue (AAAA-MM-JJ ou RFC3339)")
+	cmd.RootCmd.AddCommand(RebuildRollupsCmd)
+}
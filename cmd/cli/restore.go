@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var restoreCodeFlag string
+
+var RestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restaure un lien court précédemment supprimé.",
+	Long: `Cette commande restaure un lien supprimé avec la commande 'delete'.
+Le lien redirige de nouveau vers son URL longue.
+
+Exemple:
+  url-shortener restore --code="xyz123"`,
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		if restoreCodeFlag == "" {
+			fmt.Println("Erreur: Le flag --code est requis")
+			os.Exit(1)
+		}
+
+		linkService, closeDB := openLinkService()
+		defer closeDB()
+
+		link, err := linkService.RestoreLink(restoreCodeFlag)
+		if err != nil {
+			if errors.Is(err, models.ErrLinkNotDeleted) {
+				fmt.Printf("Erreur: Le lien '%s' n'est pas supprimé\n", restoreCodeFlag)
+				os.Exit(1)
+			}
+			exitOnLinkError(restoreCodeFlag, "la restauration du lien", err)
+		}
+
+		fmt.Printf("Lien '%s' restauré (URL longue: %s).\n", link.ShortCode, link.LongURL)
+	},
+}
+
+func init() {
+	RestoreCmd.Flags().StringVar(&restoreCodeFlag, "code", "", "Code court du lien à restaurer")
+	RestoreCmd.MarkFlagRequired("code")
+	cmd.RootCmd.AddCommand(RestoreCmd)
+}
@@ -0,0 +1,26 @@
+package cli
+
+import "strings"
+
+// sparklineLevels sont les caractères utilisés du plus bas au plus haut niveau.
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline représente une série de valeurs sous forme d'une ligne de caractères
+// dont la hauteur est proportionnelle à la valeur maximale de la série.
+func sparkline(values []int) string {
+	maxValue := 0
+	for _, v := range values {
+		maxValue = max(maxValue, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		if maxValue == 0 {
+			b.WriteRune(sparklineLevels[0])
+			continue
+		}
+		level := v * (len(sparklineLevels) - 1) / maxValue
+		b.WriteRune(sparklineLevels[level])
+	}
+	return b.String()
+}
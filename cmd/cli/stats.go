@@ -5,55 +5,71 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"text/tabwriter"
+	"time"
 
 	"github.com/axellelanca/urlshortener/cmd"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/spf13/cobra"
-
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 var shortCodeFlag string
+var seriesFlag string
+var seriesFromFlag string
+var seriesToFlag string
+var referrersFlag int
+var breakdownFlag bool
+var includeBotsFlag bool
 
 var StatsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "Affiche les statistiques (nombre de clics) pour un lien court.",
 	Long: `Cette commande permet de récupérer et d'afficher le nombre total de clics
-pour une URL courte spécifique en utilisant son code.
+pour une URL courte spécifique en utilisant son code. Les clics de robots
+(aperçus de liens, crawlers, requêtes HEAD) sont exclus du total et de la
+série temporelle sauf avec --include-bots.
+
+Avec --series, les clics sont également regroupés par heure, jour ou semaine
+et affichés sous forme de tableau accompagné d'une sparkline.
+
+Les principaux sites référents sont affichés à la suite du total (--referrers
+pour en modifier le nombre, 0 pour les masquer). Avec --breakdown, les clics
+sont aussi ventilés par navigateur, système et classe d'appareil.
 
-Exemple:
-  url-shortener stats --code="xyz123"`,
+Exemples:
+  url-shortener stats --code="xyz123"
+  url-shortener stats --code="xyz123" --referrers=20
+  url-shortener stats --code="xyz123" --breakdown
+  url-shortener stats --code="xyz123" --series=hour --from=2025-01-01 --to=2025-01-02`,
 	Run: func(cobraCmd *cobra.Command, args []string) {
 		if shortCodeFlag == "" {
 			fmt.Println("Erreur: Le flag --code est requis")
 			os.Exit(1)
 		}
 
-		cfg := cmd.Cfg
-		if cfg == nil {
-			log.Fatalf("FATAL: Configuration non chargée")
-		}
-
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		seriesFrom, err := parseDateFlag("--from", seriesFromFlag)
 		if err != nil {
-			log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
 		}
-
-		sqlDB, err := db.DB()
+		seriesTo, err := parseDateFlag("--to", seriesToFlag)
 		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
 		}
 
-		defer sqlDB.Close()
+		db, closeDB := openDatabase()
+		defer closeDB()
 
 		linkRepo := repository.NewLinkRepository(db)
 		clickRepo := repository.NewClickRepository(db)
 		linkService := services.NewLinkService(linkRepo, clickRepo)
+		clickService := services.NewClickService(clickRepo)
+		visitorService := services.NewVisitorService(repository.NewVisitorRepository(db))
 
-		link, totalClicks, err := linkService.GetLinkStats(shortCodeFlag)
+		link, clicks, err := linkService.GetLinkStats(shortCodeFlag)
 		if err != nil {
 			if errors.Is(err, models.ErrLinkNotFound) {
 				fmt.Printf("Erreur: Aucun lien trouvé avec le code '%s'\n", shortCodeFlag)
@@ -65,12 +81,175 @@ Exemple:
 
 		fmt.Printf("Statistiques pour le code court: %s\n", link.ShortCode)
 		fmt.Printf("URL longue: %s\n", link.LongURL)
-		fmt.Printf("Total de clics: %d\n", totalClicks)
+		if includeBotsFlag {
+			fmt.Printf("Total de clics: %d (dont %d de robots)\n", clicks.Total(), clicks.Bot)
+		} else {
+			fmt.Printf("Total de clics: %d (hors %d clics de robots)\n", clicks.Human, clicks.Bot)
+		}
+		visitors, err := visitorService.CountUniqueVisitors(link.ID, nil, nil)
+		if err != nil {
+			log.Printf("Erreur lors de l'estimation des visiteurs uniques: %v", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Visiteurs uniques (estimation): %d\n", visitors)
+		fmt.Printf("Statut: %s\n", link.Status(clicks.Human, time.Now()))
+		if link.ExpiresAt != nil {
+			fmt.Printf("Expire le: %s\n", link.ExpiresAt.Format(time.RFC3339))
+		}
+		if link.MaxClicks != nil {
+			fmt.Printf("Clics restants: %d/%d\n", max(*link.MaxClicks-clicks.Human, 0), *link.MaxClicks)
+		}
+
+		if referrersFlag > 0 {
+			referrers, err := linkService.GetTopReferrers(link.ID, referrersFlag)
+			if err != nil {
+				log.Printf("Erreur lors de la récupération des référents: %v", err)
+				os.Exit(1)
+			}
+			printTopReferrers(referrers)
+		}
+
+		if breakdownFlag {
+			for _, dimension := range services.ClickDimensions {
+				counts, err := clickService.GetClickBreakdown(link.ID, dimension, 5)
+				if err != nil {
+					log.Printf("Erreur lors de la ventilation des clics: %v", err)
+					os.Exit(1)
+				}
+				printClickBreakdown(dimension, counts)
+			}
+		}
+
+		if seriesFlag != "" {
+			series, err := linkService.GetClickTimeSeries(shortCodeFlag, seriesFrom, seriesTo, seriesFlag)
+			if err != nil {
+				if errors.Is(err, models.ErrInvalidTimeRange) {
+					fmt.Printf("Erreur: %v\n", err)
+					os.Exit(1)
+				}
+				log.Printf("Erreur lors de la récupération de la série temporelle: %v", err)
+				os.Exit(1)
+			}
+			var visitorBuckets []services.VisitorBucket
+			if series.Interval != repository.IntervalHour {
+				visitorBuckets, err = visitorService.UniqueVisitorsByInterval(link.ID, series.From, series.To, series.Interval)
+				if err != nil {
+					log.Printf("Erreur lors de l'estimation des visiteurs uniques: %v", err)
+					os.Exit(1)
+				}
+			}
+			printClickTimeSeries(series, visitorBuckets, includeBotsFlag)
+		}
 	},
 }
 
+func printTopReferrers(referrers []repository.ReferrerCount) {
+	fmt.Println("\nPrincipaux référents:")
+	if len(referrers) == 0 {
+		fmt.Println("  (aucun clic)")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, referrer := range referrers {
+		host := referrer.Host
+		if host == "" {
+			host = "(direct)"
+		}
+		fmt.Fprintf(w, "  %s\t%d\n", host, referrer.Count)
+	}
+	w.Flush()
+}
+
+func printClickBreakdown(dimension string, counts []repository.DimensionCount) {
+	fmt.Printf("\nClics par %s:\n", breakdownDimensionLabel(dimension))
+	if len(counts) == 0 {
+		fmt.Println("  (aucun clic)")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, count := range counts {
+		value := count.Value
+		if value == "" {
+			value = "(inconnu)"
+		}
+		fmt.Fprintf(w, "  %s\t%d\n", value, count.Count)
+	}
+	w.Flush()
+}
+
+func breakdownDimensionLabel(dimension string) string {
+	switch dimension {
+	case repository.DimensionBrowser:
+		return "navigateur"
+	case repository.DimensionOS:
+		return "système"
+	default:
+		return "appareil"
+	}
+}
+
+// printClickTimeSeries affiche la série sous forme de tableau. Les clics de robots ne sont comptés
+// qu'avec includeBots. La colonne des visiteurs uniques n'est affichée que pour les intervalles
+// journaliers et hebdomadaires.
+func printClickTimeSeries(series *services.ClickTimeSeries, visitorBuckets []services.VisitorBucket, includeBots bool) {
+	layout := "2006-01-02"
+	if series.Interval == repository.IntervalHour {
+		layout = "2006-01-02 15:04"
+	}
+
+	values := make([]int, 0, len(series.Buckets))
+	fmt.Printf("\nClics par %s (UTC), du %s au %s (exclu):\n", seriesIntervalLabel(series.Interval),
+		series.From.Format(layout), series.To.Format(layout))
+
+	visitors := make(map[int64]int, len(visitorBuckets))
+	for _, bucket := range visitorBuckets {
+		visitors[bucket.Start.Unix()] = bucket.Visitors
+	}
+	withVisitors := series.Interval != repository.IntervalHour
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	if withVisitors {
+		fmt.Fprintln(w, "\tclics\tvisiteurs\t")
+	}
+	for _, bucket := range series.Buckets {
+		clicks := bucket.Human
+		if includeBots {
+			clicks = bucket.Total()
+		}
+		if withVisitors {
+			fmt.Fprintf(w, "%s\t%d\t%d\t\n", bucket.Start.Format(layout), clicks, visitors[bucket.Start.Unix()])
+		} else {
+			fmt.Fprintf(w, "%s\t%d\t\n", bucket.Start.Format(layout), clicks)
+		}
+		values = append(values, clicks)
+	}
+	w.Flush()
+
+	fmt.Printf("\n%s\n", sparkline(values))
+}
+
+func seriesIntervalLabel(interval string) string {
+	switch interval {
+	case repository.IntervalHour:
+		return "heure"
+	case repository.IntervalWeek:
+		return "semaine"
+	default:
+		return "jour"
+	}
+}
+
 func init() {
 	StatsCmd.Flags().StringVar(&shortCodeFlag, "code", "", "Code court pour lequel afficher les statistiques")
+	StatsCmd.Flags().StringVar(&seriesFlag, "series", "", "Affiche les clics par intervalle: hour, day ou week (day si aucune valeur)")
+	StatsCmd.Flags().Lookup("series").NoOptDefVal = repository.IntervalDay
+	StatsCmd.Flags().StringVar(&seriesFromFlag, "from", "", "Début de la série (AAAA-MM-JJ ou RFC3339)")
+	StatsCmd.Flags().StringVar(&seriesToFlag, "to", "", "Fin de la série (AAAA-MM-JJ ou RFC3339)")
+	StatsCmd.Flags().IntVar(&referrersFlag, "referrers", 5, "Nombre de sites référents à afficher (0 pour les masquer)")
+	StatsCmd.Flags().BoolVar(&breakdownFlag, "breakdown", false, "Ventile les clics par navigateur, système et appareil")
+	StatsCmd.Flags().BoolVar(&includeBotsFlag, "include-bots", false, "Inclut les clics de robots dans le total et la série")
 	StatsCmd.MarkFlagRequired("code")
 	cmd.RootCmd.AddCommand(StatsCmd)
 }
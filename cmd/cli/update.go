@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var updateCodeFlag string
+var updateURLFlag string
+
+var UpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Modifie l'URL de destination d'un lien court existant.",
+	Long: `Cette commande met à jour l'URL longue vers laquelle un code court redirige,
+par exemple pour corriger une faute de frappe sans changer le code diffusé.
+
+Exemple:
+  url-shortener update --code="xyz123" --url="https://www.example.com/nouvelle-page"`,
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		if updateCodeFlag == "" || updateURLFlag == "" {
+			fmt.Println("Erreur: Les flags --code et --url sont requis")
+			os.Exit(1)
+		}
+
+		if _, err := url.ParseRequestURI(updateURLFlag); err != nil {
+			fmt.Printf("Erreur: URL invalide: %v\n", err)
+			os.Exit(1)
+		}
+
+		linkService, closeDB := openLinkService()
+		defer closeDB()
+
+		link, err := linkService.UpdateLink(updateCodeFlag, services.UpdateLinkOptions{LongURL: &updateURLFlag})
+		if err != nil {
+			exitOnLinkError(updateCodeFlag, "la mise à jour du lien", err)
+		}
+
+		fmt.Printf("Lien mis à jour avec succès:\n")
+		fmt.Printf("Code: %s\n", link.ShortCode)
+		fmt.Printf("Nouvelle URL longue: %s\n", link.LongURL)
+	},
+}
+
+func init() {
+	UpdateCmd.Flags().StringVar(&updateCodeFlag, "code", "", "Code court du lien à modifier")
+	UpdateCmd.Flags().StringVar(&updateURLFlag, "url", "", "Nouvelle URL longue")
+	UpdateCmd.MarkFlagRequired("code")
+	UpdateCmd.MarkFlagRequired("url")
+	cmd.RootCmd.AddCommand(UpdateCmd)
+}
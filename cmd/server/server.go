@@ -1,26 +1,38 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/analytics"
 	"github.com/axellelanca/urlshortener/internal/api"
+	"github.com/axellelanca/urlshortener/internal/bloom"
+	"github.com/axellelanca/urlshortener/internal/cache"
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/health"
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/migrations"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/monitor"
+	"github.com/axellelanca/urlshortener/internal/ratelimit"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/shortcode"
+	"github.com/axellelanca/urlshortener/internal/spool"
 	"github.com/axellelanca/urlshortener/internal/workers"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 
@@ -34,47 +46,234 @@ puis lance le serveur HTTP.`,
 
 		cfg := cmd.Cfg
 		if cfg == nil {
-			log.Fatalf("FATAL: Configuration non chargée")
+			fatal(slog.Default(), "Configuration non chargée")
 		}
 
+		logger, err := logging.New(cfg.Logging, os.Stderr)
+		if err != nil {
+			fatal(slog.Default(), "Configuration logging invalide", "error", err)
+		}
+		slog.SetDefault(logger)
+
 
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		db, err := database.Open(cfg.Database)
+		if err != nil {
+			fatal(logger, "Échec de la connexion à la base de données", "error", err)
+		}
+		sqlDB, err := db.DB()
 		if err != nil {
-			log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
+			fatal(logger, "Échec de l'obtention de la base de données SQL sous-jacente", "error", err)
+		}
+
+		migrator, err := migrations.New(db)
+		if err != nil {
+			fatal(logger, "Échec du chargement des migrations", "error", err)
+		}
+		if err := migrator.Check(context.Background()); err != nil {
+			if !cfg.Database.AllowPendingMigrations {
+				fatal(logger, "Schéma de la base de données non à jour", "error", err)
+			}
+			logger.Warn("Schéma de la base de données non à jour, démarrage autorisé par database.allow_pending_migrations", "error", err)
 		}
 
+		// ctx est annulé à la réception de SIGINT ou SIGTERM et arrête les processus de fond.
+		ctx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stopSignals()
+		var background sync.WaitGroup
+
 		linkRepo := repository.NewLinkRepository(db)
 		clickRepo := repository.NewClickRepository(db)
+		apiKeyRepo := repository.NewAPIKeyRepository(db)
+		visitorRepo := repository.NewVisitorRepository(db)
 
-		log.Println("Repositories initialisés.")
+		logger.Info("Repositories initialisés")
 
 		linkService := services.NewLinkService(linkRepo, clickRepo)
-		_ = services.NewClickService(clickRepo)
+		clickService := services.NewClickService(clickRepo)
+		visitorService := services.NewVisitorService(visitorRepo)
+		linkService.SetLogger(logger)
+		linkService.ReserveRoute(cfg.Metrics.Path)
+		codeGenerator, err := shortcode.New(cfg.ShortCode, linkRepo)
+		if err != nil {
+			fatal(logger, "Configuration shortcode invalide", "error", err)
+		}
+		linkService.SetCodeGenerator(codeGenerator)
+		logger.Info("Génération des codes courts configurée", "strategy", cfg.ShortCode.Strategy, "alphabet", cfg.ShortCode.Alphabet)
+
+		var apiKeyService *services.APIKeyService
+		if cfg.Auth.Enabled {
+			apiKeyService = services.NewAPIKeyService(apiKeyRepo)
+			apiKeyService.SetLogger(logger)
+		} else {
+			logger.Warn("Authentification par clé d'API désactivée, les routes /api/v1 sont publiques")
+		}
 
 	
-		log.Println("Services métiers initialisés.")
+		logger.Info("Services métiers initialisés")
+
 
+		salts := analytics.NewDailySalt(visitorRepo)
+		salts.SetLogger(logger)
+		ipAnonymizer, err := analytics.NewIPAnonymizer(cfg.Privacy.IPMode, salts)
+		if err != nil {
+			fatal(logger, "Configuration privacy.ip_mode invalide", "error", err)
+		}
+		enricher := analytics.NewClickEnricher(analytics.NewBotDetector(cfg.Analytics.BotSignatures), ipAnonymizer)
+
+		visitorCounter := analytics.NewVisitorCounter(visitorRepo, salts)
+		visitorCounter.SetLogger(logger)
+		visitorCounter.Start(time.Duration(cfg.Analytics.VisitorFlushSeconds) * time.Second)
 
 		clickEventsChannel := make(chan models.ClickEvent, cfg.Analytics.BufferSize)
-		workers.StartClickWorkers(cfg.Analytics.WorkerCount, clickEventsChannel, clickRepo)
 
-		log.Printf("Channel d'événements de clic initialisé avec un buffer de %d. %d worker(s) de clics démarré(s).",
-			cfg.Analytics.BufferSize, cfg.Analytics.WorkerCount)
+		var serviceMetrics *metrics.Metrics
+		if cfg.Metrics.Enabled {
+			serviceMetrics = metrics.New(
+				func() int { return len(clickEventsChannel) },
+				func() int { return cap(clickEventsChannel) },
+			)
+		}
+
+		if cfg.Cache.Enabled {
+			linkCache := cache.NewLinkCache(cfg.Cache.Size,
+				time.Duration(cfg.Cache.TTLSeconds)*time.Second,
+				time.Duration(cfg.Cache.NegativeTTLSeconds)*time.Second)
+			linkService.SetCache(linkCache)
+			serviceMetrics.RegisterLinkCache(linkCache)
+			logger.Info("Cache des redirections activé", "size", cfg.Cache.Size,
+				"ttl_seconds", cfg.Cache.TTLSeconds, "negative_ttl_seconds", cfg.Cache.NegativeTTLSeconds)
+		}
+
+		var codeFilter *bloom.CodeFilter
+		if cfg.Bloom.Enabled {
+			codeFilter = bloom.NewCodeFilter(linkRepo, cfg.Bloom.FalsePositiveRate,
+				time.Duration(cfg.Bloom.SyncIntervalSeconds)*time.Second, logger)
+			// Tant qu'il n'est pas construit, le filtre n'écarte aucun code : la synchronisation
+			// suivante réessaiera.
+			if _, err := codeFilter.Rebuild(); err != nil {
+				logger.Error("Échec de la construction du filtre des codes courts", "error", err)
+			}
+			linkService.SetCodeFilter(codeFilter)
+			serviceMetrics.RegisterCodeFilter(codeFilter)
+			startBackground(&background, func() { codeFilter.Start(ctx) })
+		}
+
+		var clickSpool *spool.Spool
+		if cfg.Analytics.SpoolDir != "" {
+			clickSpool, err = spool.Open(cfg.Analytics.SpoolDir,
+				int64(cfg.Analytics.SpoolSegmentKB)*1024, int64(cfg.Analytics.SpoolMaxMB)*1024*1024)
+			if err != nil {
+				fatal(logger, "Échec de l'ouverture du spool des clics", "error", err)
+			}
+			clickSpool.SetLogger(logger)
+			logger.Info("Spool des clics ouvert", "dir", cfg.Analytics.SpoolDir)
+		}
+
+		clickWorkers := workers.StartClickWorkers(cfg.Analytics.WorkerCount, clickEventsChannel, clickRepo, enricher,
+			workers.WorkerOptions{
+				Batch: workers.BatchConfig{
+					Size:       cfg.Analytics.BatchSize,
+					MaxLatency: time.Duration(cfg.Analytics.BatchMaxLatencyMs) * time.Millisecond,
+				},
+				Visitors: visitorCounter,
+				Spool:    clickSpool,
+				Metrics:  serviceMetrics,
+				Logger:   logger,
+			})
+
+		logger.Info("Channel d'événements de clic initialisé",
+			"buffer_size", cfg.Analytics.BufferSize, "workers", cfg.Analytics.WorkerCount)
+
+		var spoolReplayer *workers.SpoolReplayer
+		if clickSpool != nil {
+			spoolReplayer = workers.StartSpoolReplayer(clickSpool, clickEventsChannel,
+				time.Duration(cfg.Analytics.SpoolReplaySeconds)*time.Second, logger)
+		}
 
 		monitorInterval := time.Duration(cfg.Monitor.IntervalMinutes) * time.Minute
-		urlMonitor := monitor.NewUrlMonitor(linkRepo, monitorInterval)
-		go urlMonitor.Start()
-		log.Printf("Moniteur d'URLs démarré avec un intervalle de %v.", monitorInterval)
+		urlMonitor := monitor.NewUrlMonitor(linkRepo, monitorInterval, serviceMetrics, logger)
+		startBackground(&background, func() { urlMonitor.Start(ctx) })
+		logger.Info("Moniteur d'URLs démarré", "interval", monitorInterval)
+
+		sweepInterval := time.Duration(cfg.Monitor.SweepIntervalMinutes) * time.Minute
+		expirationSweeper := monitor.NewExpirationSweeper(linkRepo, sweepInterval, logger)
+		startBackground(&background, func() { expirationSweeper.Start(ctx) })
+		logger.Info("Sweeper d'expiration démarré", "interval", sweepInterval)
 
+		if cfg.Retention.ClickDays > 0 {
+			if err := services.ValidateRetentionMode(cfg.Retention.Mode); err != nil {
+				fatal(logger, "Configuration retention.mode invalide", "error", err)
+			}
+			retention := time.Duration(cfg.Retention.ClickDays) * 24 * time.Hour
+			purgeInterval := time.Duration(cfg.Retention.PurgeIntervalMinutes) * time.Minute
+			clickPurger := monitor.NewClickPurger(clickService, retention, cfg.Retention.Mode, purgeInterval, logger)
+			startBackground(&background, func() { clickPurger.Start(ctx) })
+			logger.Info("Purge des clics démarrée", "click_days", cfg.Retention.ClickDays, "mode", cfg.Retention.Mode)
+		}
 
-		router := gin.Default()
-		api.SetupRoutes(router, linkService, cfg.Analytics.BufferSize, cfg.Server.BaseURL)
+		// Le moniteur et le sweeper sont jugés figés après deux intervalles sans activité ;
+		// leur arrêt ne compromet pas les redirections, il ne rend donc pas le service indisponible.
+		readiness := health.NewChecker(time.Duration(cfg.Health.CheckTimeoutMs) * time.Millisecond)
+		readiness.Register("database", true, health.Database(sqlDB))
+		readiness.Register("migrations", true, health.Migrations(migrator))
+		readiness.Register("click_queue", true, health.QueueSaturation(
+			func() int { return len(clickEventsChannel) },
+			func() int { return cap(clickEventsChannel) },
+			float64(cfg.Health.QueueSaturationPercent)/100))
+		readiness.Register("url_monitor", false, health.Heartbeat(urlMonitor.LastHeartbeat, 2*monitorInterval))
+		readiness.Register("expiration_sweeper", false, health.Heartbeat(expirationSweeper.LastHeartbeat, 2*sweepInterval))
+
+
+		// Les logs de gin sont remplacés par ceux des middlewares ; le mode debug n'est conservé
+		// qu'avec le niveau de log debug.
+		if logger.Enabled(context.Background(), slog.LevelDebug) {
+			gin.SetMode(gin.DebugMode)
+		} else {
+			gin.SetMode(gin.ReleaseMode)
+		}
+		router := gin.New()
+		router.Use(api.RequestIDMiddleware(logger), api.AccessLogMiddleware(), api.RecoveryMiddleware())
+		if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+			fatal(logger, "Liste de proxys de confiance invalide", "error", err)
+		}
+
+		routeOptions := api.RouteOptions{
+			APIKeyService:  apiKeyService,
+			ClickService:   clickService,
+			VisitorService: visitorService,
+			ClickSpool:     clickSpool,
+			Readiness:      readiness,
+		}
+		if serviceMetrics != nil {
+			routeOptions.Metrics = serviceMetrics
+			routeOptions.MetricsPath = cfg.Metrics.Path
+			if cfg.Metrics.Username != "" {
+				routeOptions.MetricsAccounts = gin.Accounts{cfg.Metrics.Username: cfg.Metrics.Password}
+			}
+			logger.Info("Métriques Prometheus exposées", "path", cfg.Metrics.Path)
+		}
+		if cfg.Admin.Username != "" {
+			routeOptions.CodeFilter = codeFilter
+			routeOptions.AdminAccounts = gin.Accounts{cfg.Admin.Username: cfg.Admin.Password}
+			logger.Info("Routes d'administration activées", "path", "/admin")
+		}
+		if cfg.RateLimit.Enabled {
+			routeOptions.CreateRateLimiter = newRateLimiter(cfg.RateLimit.Create)
+			routeOptions.CreateIPRateLimiter = newRateLimiter(cfg.RateLimit.CreateIP)
+			routeOptions.RedirectRateLimiter = newRateLimiter(cfg.RateLimit.Redirect)
+			logger.Info("Rate limiting activé",
+				"create_per_minute", cfg.RateLimit.Create.RequestsPerMinute, "create_burst", cfg.RateLimit.Create.Burst,
+				"create_ip_per_minute", cfg.RateLimit.CreateIP.RequestsPerMinute, "create_ip_burst", cfg.RateLimit.CreateIP.Burst,
+				"redirect_per_minute", cfg.RateLimit.Redirect.RequestsPerMinute, "redirect_burst", cfg.RateLimit.Redirect.Burst)
+		}
+
+		api.SetupRoutes(router, linkService, cfg.Analytics.BufferSize, cfg.Server.BaseURL, routeOptions)
 
 
 		api.ClickEventsChannel = clickEventsChannel
 
 
-		log.Println("Routes API configurées.")
+		logger.Info("Routes API configurées")
 
 	
 		serverAddr := fmt.Sprintf(":%d", cfg.Server.Port)
@@ -84,27 +283,122 @@ puis lance le serveur HTTP.`,
 		}
 
 		go func() {
-			log.Printf("Serveur HTTP démarré sur le port %d", cfg.Server.Port)
-			log.Printf("URL de base: %s", cfg.Server.BaseURL)
+			logger.Info("Serveur HTTP démarré", "port", cfg.Server.Port, "base_url", cfg.Server.BaseURL)
 			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-				log.Fatalf("FATAL: Échec du démarrage du serveur HTTP: %v", err)
+				fatal(logger, "Échec du démarrage du serveur HTTP", "error", err)
 			}
 		}()
 	
 
-		quit := make(chan os.Signal, 1)
-		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM) 
+		<-ctx.Done()
+		// Un second signal interrompt immédiatement le programme.
+		stopSignals()
+		shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeoutSeconds) * time.Second
+		logger.Info("Signal d'arrêt reçu, arrêt du serveur", "timeout", shutdownTimeout)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		// Plus aucune redirection n'est acceptée : les derniers clics sont déjà dans le channel.
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			// Les requêtes encore en cours sont abandonnées ; leurs clics, envoyés après la fermeture
+			// du channel, passent par le spool.
+			logger.Error("Arrêt du serveur HTTP incomplet", "error", err)
+			srv.Close()
+		}
+
+		// Le vidage du pipeline a son propre délai : celui de l'arrêt HTTP peut être déjà écoulé.
+		drainCtx, cancelDrain := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancelDrain()
+		statsBefore := clickWorkers.Stats()
+		if spoolReplayer != nil {
+			// Les événements déjà relus ne quittent le spool qu'une fois enregistrés par les workers.
+			spoolReplayer.Stop(drainCtx)
+		}
+		api.CloseClickEvents()
+		var leftover int
+		workersDone := true
+		if err := clickWorkers.Wait(drainCtx); err != nil {
+			workersDone = false
+			logger.Error("Les workers de clics n'ont pas terminé dans le délai imparti", "error", err)
+			if clickSpool != nil {
+				// Les workers lisent encore le channel : chaque événement restant est soit enregistré
+				// par eux, soit mis en spool ici.
+				leftover = workers.SpoolPendingEvents(clickEventsChannel, clickSpool, logger)
+			}
+		}
+		statsAfter := clickWorkers.Stats()
+		logger.Info("Pipeline de clics vidé",
+			"saved", statsAfter.Saved-statsBefore.Saved, "spooled", statsAfter.Spooled-statsBefore.Spooled+int64(leftover),
+			"dropped", statsAfter.Dropped-statsBefore.Dropped, "remaining", len(clickEventsChannel))
+
+		if err := visitorCounter.Stop(); err != nil {
+			logger.Error("Échec de l'enregistrement des visiteurs uniques", "error", err)
+		}
+
+		backgroundCtx, cancelBackground := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancelBackground()
+		if err := waitGroup(backgroundCtx, &background); err != nil {
+			logger.Error("Les processus de fond n'ont pas terminé dans le délai imparti", "error", err)
+		}
 
-		<-quit
-		log.Println("Signal d'arrêt reçu. Arrêt du serveur...")
-		
-		log.Println("Arrêt en cours... Donnez un peu de temps aux workers pour finir.")
-		time.Sleep(5 * time.Second)
+		if !workersDone {
+			// Des lots de clics sont encore en cours d'écriture : le spool et la base restent ouverts
+			// jusqu'à la fin du processus plutôt que d'être fermés sous leurs pieds.
+			logger.Warn("Spool des clics et base de données laissés ouverts, des workers sont encore actifs")
+			return
+		}
+		if clickSpool != nil {
+			if err := clickSpool.Close(); err != nil {
+				logger.Error("Échec de la fermeture du spool des clics", "error", err)
+			}
+		}
+		if err := sqlDB.Close(); err != nil {
+			logger.Error("Échec de la fermeture de la base de données", "error", err)
+		}
 
-		log.Println("Serveur arrêté proprement.")
+		logger.Info("Serveur arrêté proprement")
 	},
 }
 
+// fatal journalise une erreur empêchant le démarrage du serveur et termine le programme.
+func fatal(logger *slog.Logger, msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+// startBackground lance run dans une goroutine suivie par wg.
+func startBackground(wg *sync.WaitGroup, run func()) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		run()
+	}()
+}
+
+// waitGroup attend wg, au plus jusqu'à l'expiration de ctx.
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newRateLimiter construit un limiteur pour la règle donnée, ou retourne nil si la règle est désactivée.
+func newRateLimiter(rule config.RateLimitRule) *ratelimit.Limiter {
+	if rule.RequestsPerMinute <= 0 {
+		return nil
+	}
+	return ratelimit.NewLimiter(rule.RequestsPerMinute, rule.Burst)
+}
+
 func init() {
 	cmd.RootCmd.AddCommand(RunServerCmd)
 }
\ No newline at end of file
@@ -0,0 +1,68 @@
+package analytics
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// MonitorUserAgent est le User-Agent envoyé par le moniteur d'URLs, pour que ses
+// vérifications ne soient jamais comptées comme des clics humains.
+const MonitorUserAgent = "urlshortener-monitor/1.0"
+
+// DefaultBotSignatures complète les robots reconnus par ParseUserAgent avec les clients
+// d'aperçu de liens et vérificateurs qui ne s'annoncent pas comme "bot".
+var DefaultBotSignatures = []string{
+	"urlshortener-monitor",
+	"facebookcatalog",
+	"whatsapp",
+	"skypeuripreview",
+	"linkpreview",
+	"embedly",
+	"quora link preview",
+	"pinterest",
+	"vkshare",
+	"w3c_validator",
+	"linkcheck",
+	"headless",
+	"okhttp",
+	"axios/",
+	"node-fetch",
+	"java/",
+	"libwww-perl",
+	"httpclient",
+}
+
+// BotDetector décide si un clic provient d'un robot plutôt que d'un visiteur humain.
+type BotDetector struct {
+	signatures []string
+}
+
+// NewBotDetector crée un détecteur reconnaissant les signatures par défaut ainsi que
+// extraSignatures, comparées sans tenir compte de la casse au User-Agent.
+func NewBotDetector(extraSignatures []string) *BotDetector {
+	signatures := make([]string, 0, len(DefaultBotSignatures)+len(extraSignatures))
+	for _, signature := range slices.Concat(DefaultBotSignatures, extraSignatures) {
+		signature = strings.ToLower(strings.TrimSpace(signature))
+		if signature != "" {
+			signatures = append(signatures, signature)
+		}
+	}
+	return &BotDetector{signatures: signatures}
+}
+
+// IsBot indique si la requête vient d'un robot : requête HEAD (vérificateurs de liens),
+// robot reconnu par ParseUserAgent ou User-Agent contenant une signature connue.
+func (d *BotDetector) IsBot(method, userAgent string, parsed UserAgent) bool {
+	if method == http.MethodHead || parsed.DeviceClass == DeviceBot {
+		return true
+	}
+
+	ua := strings.ToLower(userAgent)
+	for _, signature := range d.signatures {
+		if strings.Contains(ua, signature) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+func TestBotDetector_IsBot(t *testing.T) {
+	detector := NewBotDetector([]string{"  InternalChecker "})
+
+	tests := []struct {
+		name      string
+		method    string
+		userAgent string
+		expected  bool
+	}{
+		{"browser", "GET", "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0", false},
+		{"empty user agent", "GET", "", false},
+		{"slack unfurler", "GET", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", true},
+		{"twitter card fetcher", "GET", "Twitterbot/1.0", true},
+		{"whatsapp preview", "GET", "WhatsApp/2.23.20.0 A", true},
+		{"url monitor", "GET", MonitorUserAgent, true},
+		{"configured signature", "GET", "internalchecker/2.0", true},
+		{"head request from browser", "HEAD", "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detector.IsBot(tt.method, tt.userAgent, ParseUserAgent(tt.userAgent)); got != tt.expected {
+				t.Errorf("Expected IsBot=%v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestClickEnricher_FlagsBots(t *testing.T) {
+	enricher := NewClickEnricher(NewBotDetector(nil), nil)
+
+	click, err := enricher.NewClick(models.ClickEvent{LinkID: 1, Method: "HEAD", UserAgent: "Mozilla/5.0 (Windows NT 10.0) Chrome/120.0.0.0 Safari/537.36"})
+	if err != nil {
+		t.Fatalf("Failed to build click: %v", err)
+	}
+	if !click.IsBot || click.DeviceClass != DeviceBot {
+		t.Errorf("Expected HEAD click to be flagged as bot, got IsBot=%v DeviceClass=%q", click.IsBot, click.DeviceClass)
+	}
+
+	click, err = enricher.NewClick(models.ClickEvent{LinkID: 1, Method: "GET", UserAgent: "Mozilla/5.0 (Windows NT 10.0) Chrome/120.0.0.0 Safari/537.36"})
+	if err != nil {
+		t.Fatalf("Failed to build click: %v", err)
+	}
+	if click.IsBot || click.DeviceClass != DeviceDesktop {
+		t.Errorf("Expected browser click to be human, got IsBot=%v DeviceClass=%q", click.IsBot, click.DeviceClass)
+	}
+}
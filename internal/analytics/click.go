@@ -0,0 +1,67 @@
+package analytics
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// ClickEnricher construit les clics à persister à partir des événements de redirection.
+type ClickEnricher struct {
+	bots *BotDetector
+	ips  *IPAnonymizer
+}
+
+// NewClickEnricher crée un enrichisseur classant les robots avec bots et transformant les IP
+// avec ips. Un ips nil conserve les adresses complètes.
+func NewClickEnricher(bots *BotDetector, ips *IPAnonymizer) *ClickEnricher {
+	if ips == nil {
+		ips = &IPAnonymizer{mode: IPModeFull}
+	}
+	return &ClickEnricher{bots: bots, ips: ips}
+}
+
+// NewClick construit le clic correspondant à event : le referrer est normalisé, le User-Agent
+// analysé avant d'être tronqué, le clic marqué comme provenant d'un robot si nécessaire et l'IP
+// anonymisée selon le mode configuré.
+func (e *ClickEnricher) NewClick(event models.ClickEvent) (*models.Click, error) {
+	ipAddress, err := e.ips.Anonymize(event.IPAddress, event.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	referrerHost, referrer := NormalizeReferrer(event.Referrer)
+	ua := ParseUserAgent(event.UserAgent)
+	isBot := e.bots.IsBot(event.Method, event.UserAgent, ua)
+	if isBot {
+		ua.DeviceClass = DeviceBot
+	}
+
+	return &models.Click{
+		LinkID:         event.LinkID,
+		Timestamp:      event.Timestamp,
+		UserAgent:      truncateUTF8(event.UserAgent, MaxUserAgentLength),
+		IPAddress:      ipAddress,
+		Referrer:       referrer,
+		ReferrerHost:   referrerHost,
+		BrowserFamily:  ua.BrowserFamily,
+		BrowserVersion: ua.BrowserVersion,
+		OSFamily:       ua.OSFamily,
+		DeviceClass:    ua.DeviceClass,
+		IsBot:          isBot,
+	}, nil
+}
+
+// truncateUTF8 tronque s à au plus max octets sans couper de caractère multi-octets. Les séquences
+// UTF-8 invalides sont retirées : PostgreSQL refuserait la ligne, et avec elle tout le lot.
+func truncateUTF8(s string, max int) string {
+	s = strings.ToValidUTF8(s, "")
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
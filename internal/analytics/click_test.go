@@ -0,0 +1,50 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		max      int
+		expected string
+	}{
+		{"short", "abc", 5, "abc"},
+		{"ascii", "abcdef", 4, "abcd"},
+		{"cut inside a 2-byte character", "abé", 3, "ab"},
+		{"cut inside a 4-byte character", "a😀b", 3, "a"},
+		{"cut after a multi-byte character", "aé😀", 3, "aé"},
+		{"invalid bytes removed", "ab\xffcd", 10, "abcd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateUTF8(tt.s, tt.max); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewClick_TruncatesUserAgentOnRuneBoundary(t *testing.T) {
+	enricher := NewClickEnricher(NewBotDetector(nil), nil)
+	// Le caractère de 3 octets chevauche la limite de MaxUserAgentLength octets.
+	userAgent := strings.Repeat("a", MaxUserAgentLength-1) + "€tail"
+
+	click, err := enricher.NewClick(models.ClickEvent{LinkID: 1, Method: "GET", UserAgent: userAgent})
+	if err != nil {
+		t.Fatalf("NewClick failed: %v", err)
+	}
+	if !utf8.ValidString(click.UserAgent) {
+		t.Errorf("Expected valid UTF-8, got %q", click.UserAgent)
+	}
+	if click.UserAgent != strings.Repeat("a", MaxUserAgentLength-1) {
+		t.Errorf("Expected the User-Agent to be cut before the multi-byte character, got %d bytes", len(click.UserAgent))
+	}
+}
@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// hllPrecision fixe le nombre de registres (2^12 = 4096 octets par sketch),
+// pour une erreur type d'environ 1,6 %.
+const hllPrecision = 12
+
+// HLLSize est la taille en octets d'un sketch sérialisé.
+const HLLSize = 1 << hllPrecision
+
+// HyperLogLog estime le nombre d'éléments distincts d'un ensemble en mémoire constante.
+// Deux sketches se fusionnent sans perte, ce qui permet de les persister par jour
+// et de les combiner sur une période.
+type HyperLogLog struct {
+	registers []byte
+}
+
+// NewHyperLogLog crée un sketch vide.
+func NewHyperLogLog() *HyperLogLog {
+	return &HyperLogLog{registers: make([]byte, HLLSize)}
+}
+
+// HyperLogLogFromBytes reconstruit un sketch à partir de sa forme sérialisée (voir Bytes).
+func HyperLogLogFromBytes(data []byte) (*HyperLogLog, error) {
+	if len(data) != HLLSize {
+		return nil, fmt.Errorf("invalid HyperLogLog size: got %d bytes, want %d", len(data), HLLSize)
+	}
+	registers := make([]byte, HLLSize)
+	copy(registers, data)
+	return &HyperLogLog{registers: registers}, nil
+}
+
+// Add enregistre un élément à partir de son empreinte 64 bits, qui doit être uniformément
+// distribuée (issue d'une fonction de hachage). Retourne true si le sketch a changé.
+func (h *HyperLogLog) Add(hash uint64) bool {
+	index := hash >> (64 - hllPrecision)
+	// Le bit sentinelle borne le rang si les bits restants sont tous nuls.
+	rest := hash<<hllPrecision | 1<<(hllPrecision-1)
+	rank := byte(bits.LeadingZeros64(rest) + 1)
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+		return true
+	}
+	return false
+}
+
+// Merge ajoute à h les éléments de other.
+func (h *HyperLogLog) Merge(other *HyperLogLog) {
+	for i, rank := range other.registers {
+		if rank > h.registers[i] {
+			h.registers[i] = rank
+		}
+	}
+}
+
+// Count retourne l'estimation du nombre d'éléments distincts.
+func (h *HyperLogLog) Count() int {
+	m := float64(HLLSize)
+	sum := 0.0
+	zeros := 0
+	for _, rank := range h.registers {
+		sum += 1 / float64(uint64(1)<<rank)
+		if rank == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	// Correction des petites cardinalités (linear counting).
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(estimate))
+}
+
+// Bytes retourne la forme sérialisée du sketch.
+func (h *HyperLogLog) Bytes() []byte {
+	data := make([]byte, HLLSize)
+	copy(data, h.registers)
+	return data
+}
@@ -0,0 +1,72 @@
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func testHash(value string) uint64 {
+	sum := sha256.Sum256([]byte(value))
+	return binary.BigEndian.Uint64(sum[:])
+}
+
+func TestHyperLogLog_Count(t *testing.T) {
+	tests := []struct {
+		name     string
+		distinct int
+		repeats  int
+	}{
+		{"empty", 0, 1},
+		{"single element repeated", 1, 50},
+		{"small cardinality", 100, 3},
+		{"large cardinality", 50000, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hll := NewHyperLogLog()
+			for r := 0; r < tt.repeats; r++ {
+				for i := 0; i < tt.distinct; i++ {
+					hll.Add(testHash(fmt.Sprintf("visitor-%d", i)))
+				}
+			}
+
+			got := hll.Count()
+			// Erreur type d'environ 1,6 % : on tolère 5 %, et au plus 1 pour les très petites valeurs.
+			tolerance := math.Max(1, 0.05*float64(tt.distinct))
+			if math.Abs(float64(got-tt.distinct)) > tolerance {
+				t.Errorf("Expected about %d distinct elements, got %d", tt.distinct, got)
+			}
+		})
+	}
+}
+
+func TestHyperLogLog_MergeAndSerialize(t *testing.T) {
+	a, b := NewHyperLogLog(), NewHyperLogLog()
+	for i := 0; i < 3000; i++ {
+		a.Add(testHash(fmt.Sprintf("visitor-%d", i)))
+	}
+	for i := 2000; i < 5000; i++ {
+		b.Add(testHash(fmt.Sprintf("visitor-%d", i)))
+	}
+
+	restored, err := HyperLogLogFromBytes(a.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to restore sketch: %v", err)
+	}
+	if restored.Count() != a.Count() {
+		t.Errorf("Expected restored count %d, got %d", a.Count(), restored.Count())
+	}
+
+	restored.Merge(b)
+	if got := restored.Count(); math.Abs(float64(got-5000)) > 250 {
+		t.Errorf("Expected about 5000 distinct elements after merge, got %d", got)
+	}
+
+	if _, err := HyperLogLogFromBytes([]byte{1, 2, 3}); err == nil {
+		t.Error("Expected an error for a truncated sketch")
+	}
+}
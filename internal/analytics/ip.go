@@ -0,0 +1,93 @@
+package analytics
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// Modes de conservation de l'adresse IP des clics.
+const (
+	// IPModeFull conserve l'adresse complète.
+	IPModeFull = "full"
+	// IPModeTruncate conserve le réseau /24 (IPv4) ou /48 (IPv6).
+	IPModeTruncate = "truncate"
+	// IPModeHash conserve une empreinte salée par un sel journalier : deux clics d'une même IP
+	// sont rapprochables dans la journée, mais ni l'IP ni le rapprochement entre jours ne sont possibles.
+	IPModeHash = "hash"
+	// IPModeDrop ne conserve aucune adresse.
+	IPModeDrop = "drop"
+)
+
+// IPAnonymizer transforme l'adresse IP d'un clic selon le mode de conservation configuré.
+type IPAnonymizer struct {
+	mode  string
+	salts *DailySalt
+}
+
+// NewIPAnonymizer crée un anonymiseur pour le mode donné. Le mode IPModeHash requiert salts.
+func NewIPAnonymizer(mode string, salts *DailySalt) (*IPAnonymizer, error) {
+	switch mode {
+	case IPModeFull, IPModeTruncate, IPModeDrop:
+	case IPModeHash:
+		if salts == nil {
+			return nil, fmt.Errorf("IP mode %q requires a salt source", mode)
+		}
+	default:
+		return nil, fmt.Errorf("unknown IP mode %q: must be full, truncate, hash or drop", mode)
+	}
+	return &IPAnonymizer{mode: mode, salts: salts}, nil
+}
+
+// Anonymize retourne la valeur à stocker pour l'adresse ip d'un clic survenu à ts.
+// Une adresse invalide n'est conservée qu'en mode IPModeFull.
+func (a *IPAnonymizer) Anonymize(ip string, ts time.Time) (string, error) {
+	switch a.mode {
+	case IPModeFull:
+		return ip, nil
+	case IPModeDrop:
+		return "", nil
+	case IPModeHash:
+		if ip == "" {
+			return "", nil
+		}
+		salt, err := a.salts.For(ts)
+		if errors.Is(err, ErrSaltExpired) {
+			// Le sel de la journée a pu être supprimé : l'empreinte est calculée avec un sel jetable,
+			// jamais enregistré, qui ne la rapproche d'aucune autre.
+			salt, err = newSalt()
+		}
+		if err != nil {
+			return "", err
+		}
+		mac := hmac.New(sha256.New, salt)
+		mac.Write([]byte("ip:" + ip))
+		return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+	default:
+		return TruncateIP(ip), nil
+	}
+}
+
+// TruncateIP retourne l'adresse du réseau /24 (IPv4) ou /48 (IPv6) contenant ip,
+// ou une chaîne vide si ip n'est pas une adresse valide.
+func TruncateIP(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.WithZone("").Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.Addr().String()
+}
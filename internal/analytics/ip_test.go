@@ -0,0 +1,93 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/services/mocks"
+)
+
+func TestTruncateIP(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected string
+	}{
+		{"203.0.113.42", "203.0.113.0"},
+		{"::ffff:203.0.113.42", "203.0.113.0"},
+		{"2001:db8:85a3:8d3:1319:8a2e:370:7348", "2001:db8:85a3::"},
+		{"fe80::1%eth0", "fe80::"},
+		{"not an ip", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := TruncateIP(tt.ip); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestIPAnonymizer(t *testing.T) {
+	repo := mocks.NewMockVisitorRepository()
+	salts := NewDailySalt(repo)
+	day1 := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	salts.now = func() time.Time { return day2 }
+
+	anonymize := func(mode, ip string, ts time.Time) string {
+		t.Helper()
+		anonymizer, err := NewIPAnonymizer(mode, salts)
+		if err != nil {
+			t.Fatalf("Failed to create anonymizer: %v", err)
+		}
+		value, err := anonymizer.Anonymize(ip, ts)
+		if err != nil {
+			t.Fatalf("Failed to anonymize IP: %v", err)
+		}
+		return value
+	}
+
+	if got := anonymize(IPModeFull, "203.0.113.42", day1); got != "203.0.113.42" {
+		t.Errorf("full: expected the full address, got %q", got)
+	}
+	if got := anonymize(IPModeTruncate, "203.0.113.42", day1); got != "203.0.113.0" {
+		t.Errorf("truncate: expected the /24 network, got %q", got)
+	}
+	if got := anonymize(IPModeDrop, "203.0.113.42", day1); got != "" {
+		t.Errorf("drop: expected an empty value, got %q", got)
+	}
+
+	hashed := anonymize(IPModeHash, "203.0.113.42", day1)
+	if len(hashed) != 32 || hashed == "203.0.113.42" {
+		t.Errorf("hash: expected a 32-character digest, got %q", hashed)
+	}
+	if again := anonymize(IPModeHash, "203.0.113.42", day1.Add(time.Hour)); again != hashed {
+		t.Errorf("hash: expected the same digest within a day, got %q and %q", hashed, again)
+	}
+	if other := anonymize(IPModeHash, "203.0.113.43", day1); other == hashed {
+		t.Error("hash: expected different addresses to have different digests")
+	}
+	if nextDay := anonymize(IPModeHash, "203.0.113.42", day2); nextDay == hashed {
+		t.Error("hash: expected the digest to change with the daily salt")
+	}
+
+	// Un clic antérieur à la veille est haché avec un sel jetable, sans recréer le sel de sa journée.
+	old := day1.AddDate(0, 0, -3)
+	if expired := anonymize(IPModeHash, "203.0.113.42", old); len(expired) != 32 || expired == anonymize(IPModeHash, "203.0.113.42", old) {
+		t.Errorf("hash: expected a digest with a throwaway salt, got %q", expired)
+	}
+	for _, day := range repo.SaltDays() {
+		if day < "2025-03-10" {
+			t.Errorf("hash: expected no salt to be created for %s", day)
+		}
+	}
+
+	if _, err := NewIPAnonymizer("mask", salts); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+	if _, err := NewIPAnonymizer(IPModeHash, nil); err == nil {
+		t.Error("Expected an error for hash mode without a salt source")
+	}
+}
@@ -0,0 +1,31 @@
+package analytics
+
+import (
+	"net/url"
+	"strings"
+)
+
+// MaxReferrerLength borne la taille de l'en-tête Referer conservé.
+const MaxReferrerLength = 2048
+
+// MaxReferrerHostLength borne la taille de l'hôte extrait de l'en-tête Referer (colonne de 255 caractères).
+const MaxReferrerHostLength = 255
+
+// NormalizeReferrer retourne l'hôte normalisé (en minuscules, sans "www.") et la valeur complète
+// d'un en-tête Referer, tous deux tronqués sans couper de caractère multi-octets. Un en-tête vide
+// ou sans hôte (trafic direct, referrer invalide) donne un hôte vide.
+func NormalizeReferrer(raw string) (host string, full string) {
+	full = truncateUTF8(strings.TrimSpace(raw), MaxReferrerLength)
+	if full == "" {
+		return "", ""
+	}
+
+	parsed, err := url.Parse(full)
+	if err != nil {
+		return "", full
+	}
+
+	host = strings.ToLower(parsed.Hostname())
+	host = strings.TrimPrefix(host, "www.")
+	return truncateUTF8(host, MaxReferrerHostLength), full
+}
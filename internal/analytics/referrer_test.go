@@ -0,0 +1,53 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNormalizeReferrer(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		expectedHost string
+	}{
+		{"empty", "", ""},
+		{"full URL", "https://news.ycombinator.com/item?id=1", "news.ycombinator.com"},
+		{"www prefix and uppercase", "https://WWW.Google.com/search?q=go", "google.com"},
+		{"with port", "http://localhost:3000/page", "localhost"},
+		{"android app referrer", "android-app://com.slack/", "com.slack"},
+		{"not a URL", "%%%", ""},
+		{"relative value", "/internal/page", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, full := NormalizeReferrer(tt.raw)
+			if host != tt.expectedHost {
+				t.Errorf("Expected host %q, got %q", tt.expectedHost, host)
+			}
+			if full != tt.raw {
+				t.Errorf("Expected full value %q, got %q", tt.raw, full)
+			}
+		})
+	}
+}
+
+func TestNormalizeReferrer_Truncation(t *testing.T) {
+	// Le caractère de 2 octets chevauche la limite de MaxReferrerLength octets.
+	raw := "https://example.com/" + strings.Repeat("a", MaxReferrerLength-21) + "é"
+	host, full := NormalizeReferrer(raw)
+	if host != "example.com" {
+		t.Errorf("Expected host example.com, got %q", host)
+	}
+	if !utf8.ValidString(full) || full != raw[:MaxReferrerLength-1] {
+		t.Errorf("Expected the referrer to be cut before the multi-byte character, got %d bytes", len(full))
+	}
+
+	longHost := strings.Repeat("a", 300) + ".example.com"
+	host, _ = NormalizeReferrer("https://" + longHost + "/page")
+	if host != longHost[:MaxReferrerHostLength] {
+		t.Errorf("Expected the host to be cut to %d bytes, got %d", MaxReferrerHostLength, len(host))
+	}
+}
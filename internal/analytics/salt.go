@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+const saltDayLayout = "2006-01-02"
+
+// ErrSaltExpired est retourné par DailySalt.For pour une journée antérieure à la veille : son sel
+// a pu être supprimé, et en recréer un compterait à nouveau les visiteurs déjà vus ce jour-là.
+var ErrSaltExpired = errors.New("daily salt has expired")
+
+// DailySalt fournit un sel aléatoire par journée (UTC), partagé entre les processus via la base.
+// Au premier usage d'une journée, les sels antérieurs à la veille sont supprimés (la veille reste
+// disponible pour les clics encore en attente au passage de minuit) : les empreintes calculées
+// avec un sel supprimé ne peuvent plus être recalculées, et aucun sel n'est plus créé pour ces journées.
+type DailySalt struct {
+	repo   repository.VisitorRepository
+	now    func() time.Time
+	logger *slog.Logger
+
+	mu   sync.Mutex
+	day  string
+	salt []byte
+}
+
+// NewDailySalt crée une source de sels journaliers enregistrés dans repo.
+func NewDailySalt(repo repository.VisitorRepository) *DailySalt {
+	return &DailySalt{repo: repo, now: time.Now, logger: slog.Default()}
+}
+
+// SetLogger remplace le logger des sels, par défaut slog.Default().
+func (s *DailySalt) SetLogger(logger *slog.Logger) {
+	s.logger = logger.With("component", "daily_salt")
+}
+
+// For retourne le sel de la journée contenant t, ou ErrSaltExpired si cette journée est antérieure
+// à la veille.
+func (s *DailySalt) For(t time.Time) ([]byte, error) {
+	dayKey := t.UTC().Format(saltDayLayout)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if dayKey == s.day {
+		return s.salt, nil
+	}
+
+	if dayKey < s.now().UTC().AddDate(0, 0, -1).Format(saltDayLayout) {
+		return nil, ErrSaltExpired
+	}
+
+	candidate, err := newSalt()
+	if err != nil {
+		return nil, err
+	}
+	salt, err := s.repo.GetOrCreateSalt(dayKey, candidate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Un clic tardif de la veille ne doit pas faire revenir le sel courant en arrière.
+	if dayKey > s.day {
+		s.day, s.salt = dayKey, salt
+		previousDay := t.UTC().AddDate(0, 0, -1).Format(saltDayLayout)
+		if err := s.repo.DeleteSaltsBefore(previousDay); err != nil {
+			s.logger.Warn("Échec de la suppression des anciens sels", "error", err)
+		}
+	}
+	return salt, nil
+}
+
+// newSalt génère un sel aléatoire de 32 octets.
+func newSalt() ([]byte, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return salt, nil
+}
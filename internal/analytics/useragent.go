@@ -0,0 +1,159 @@
+package analytics
+
+import "strings"
+
+// MaxUserAgentLength borne la taille de l'en-tête User-Agent conservé.
+const MaxUserAgentLength = 255
+
+// Classes d'appareil déduites du User-Agent.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceOther   = "other"
+)
+
+// UnknownFamily désigne un navigateur ou un système non reconnu.
+const UnknownFamily = "Other"
+
+// UserAgent est le résultat de l'analyse d'un en-tête User-Agent.
+type UserAgent struct {
+	BrowserFamily  string
+	BrowserVersion string
+	OSFamily       string
+	DeviceClass    string
+}
+
+// userAgentRule associe un marqueur recherché dans le User-Agent à une famille.
+// Si versionToken est non vide, la version est lue juste après ce marqueur.
+type userAgentRule struct {
+	marker       string
+	family       string
+	versionToken string
+}
+
+// botRules identifient les robots les plus courants. Les marqueurs génériques viennent en dernier.
+var botRules = []userAgentRule{
+	{"googlebot", "Googlebot", "googlebot/"},
+	{"bingbot", "Bingbot", "bingbot/"},
+	{"facebookexternalhit", "Facebook", "facebookexternalhit/"},
+	{"twitterbot", "Twitterbot", "twitterbot/"},
+	{"slackbot", "Slackbot", "slackbot "},
+	{"curl/", "curl", "curl/"},
+	{"wget/", "Wget", "wget/"},
+	{"python-requests/", "python-requests", "python-requests/"},
+	{"go-http-client/", "Go-http-client", "go-http-client/"},
+	{"headlesschrome", "HeadlessChrome", "headlesschrome/"},
+	{"bot", "Bot", ""},
+	{"crawler", "Bot", ""},
+	{"spider", "Bot", ""},
+	{"slurp", "Bot", ""},
+}
+
+// browserRules sont évaluées dans l'ordre : la plupart des navigateurs basés sur Chromium
+// annoncent aussi "Chrome/" et "Safari/", ils doivent donc être testés avant.
+var browserRules = []userAgentRule{
+	{"edg/", "Edge", "edg/"},
+	{"edga/", "Edge", "edga/"},
+	{"edgios/", "Edge", "edgios/"},
+	{"edge/", "Edge", "edge/"},
+	{"opr/", "Opera", "opr/"},
+	{"opera", "Opera", "version/"},
+	{"samsungbrowser/", "Samsung Internet", "samsungbrowser/"},
+	{"firefox/", "Firefox", "firefox/"},
+	{"fxios/", "Firefox", "fxios/"},
+	{"crios/", "Chrome", "crios/"},
+	{"chrome/", "Chrome", "chrome/"},
+	{"safari/", "Safari", "version/"},
+	{"msie ", "Internet Explorer", "msie "},
+	{"trident/", "Internet Explorer", "rv:"},
+}
+
+// osRules sont évaluées dans l'ordre : Android et Chrome OS annoncent aussi "Linux",
+// iOS annonce aussi "Mac OS X".
+var osRules = []userAgentRule{
+	{"windows phone", "Windows Phone", ""},
+	{"windows", "Windows", ""},
+	{"iphone", "iOS", ""},
+	{"ipad", "iOS", ""},
+	{"ipod", "iOS", ""},
+	{"android", "Android", ""},
+	{"cros ", "Chrome OS", ""},
+	{"mac os x", "macOS", ""},
+	{"macintosh", "macOS", ""},
+	{"linux", "Linux", ""},
+}
+
+// ParseUserAgent déduit le navigateur, le système et la classe d'appareil d'un en-tête User-Agent.
+// L'analyse repose sur des marqueurs connus et ne vise pas l'exhaustivité : un navigateur ou un
+// système non reconnu est classé dans UnknownFamily, un User-Agent vide dans DeviceOther.
+func ParseUserAgent(raw string) UserAgent {
+	ua := strings.ToLower(strings.TrimSpace(raw))
+	if ua == "" {
+		return UserAgent{BrowserFamily: UnknownFamily, OSFamily: UnknownFamily, DeviceClass: DeviceOther}
+	}
+
+	result := UserAgent{BrowserFamily: UnknownFamily, OSFamily: UnknownFamily}
+	if rule, ok := matchRule(ua, osRules); ok {
+		result.OSFamily = rule.family
+	}
+
+	if rule, ok := matchRule(ua, botRules); ok {
+		result.BrowserFamily = rule.family
+		result.BrowserVersion = extractVersion(ua, rule.versionToken)
+		result.DeviceClass = DeviceBot
+		return result
+	}
+
+	if rule, ok := matchRule(ua, browserRules); ok {
+		result.BrowserFamily = rule.family
+		result.BrowserVersion = extractVersion(ua, rule.versionToken)
+	}
+	result.DeviceClass = deviceClass(ua)
+	return result
+}
+
+func matchRule(ua string, rules []userAgentRule) (userAgentRule, bool) {
+	for _, rule := range rules {
+		if strings.Contains(ua, rule.marker) {
+			return rule, true
+		}
+	}
+	return userAgentRule{}, false
+}
+
+// extractVersion lit la suite de chiffres et de points qui suit token dans ua.
+func extractVersion(ua, token string) string {
+	if token == "" {
+		return ""
+	}
+	idx := strings.Index(ua, token)
+	if idx < 0 {
+		return ""
+	}
+	rest := ua[idx+len(token):]
+	end := 0
+	for end < len(rest) && (rest[end] == '.' || (rest[end] >= '0' && rest[end] <= '9')) {
+		end++
+	}
+	version := strings.TrimRight(rest[:end], ".")
+	if len(version) > 32 {
+		version = version[:32]
+	}
+	return version
+}
+
+func deviceClass(ua string) string {
+	switch {
+	case strings.Contains(ua, "ipad"), strings.Contains(ua, "tablet"):
+		return DeviceTablet
+	case strings.Contains(ua, "android") && !strings.Contains(ua, "mobile"):
+		return DeviceTablet
+	case strings.Contains(ua, "mobi"), strings.Contains(ua, "iphone"), strings.Contains(ua, "ipod"),
+		strings.Contains(ua, "windows phone"):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
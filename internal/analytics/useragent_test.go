@@ -0,0 +1,80 @@
+package analytics
+
+import "testing"
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected UserAgent
+	}{
+		{
+			name:     "empty",
+			raw:      "",
+			expected: UserAgent{BrowserFamily: UnknownFamily, OSFamily: UnknownFamily, DeviceClass: DeviceOther},
+		},
+		{
+			name:     "chrome on windows",
+			raw:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.109 Safari/537.36",
+			expected: UserAgent{BrowserFamily: "Chrome", BrowserVersion: "120.0.6099.109", OSFamily: "Windows", DeviceClass: DeviceDesktop},
+		},
+		{
+			name:     "edge on windows",
+			raw:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.61",
+			expected: UserAgent{BrowserFamily: "Edge", BrowserVersion: "120.0.2210.61", OSFamily: "Windows", DeviceClass: DeviceDesktop},
+		},
+		{
+			name:     "firefox on linux",
+			raw:      "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			expected: UserAgent{BrowserFamily: "Firefox", BrowserVersion: "121.0", OSFamily: "Linux", DeviceClass: DeviceDesktop},
+		},
+		{
+			name:     "safari on macos",
+			raw:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+			expected: UserAgent{BrowserFamily: "Safari", BrowserVersion: "17.2", OSFamily: "macOS", DeviceClass: DeviceDesktop},
+		},
+		{
+			name:     "safari on iphone",
+			raw:      "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			expected: UserAgent{BrowserFamily: "Safari", BrowserVersion: "17.2", OSFamily: "iOS", DeviceClass: DeviceMobile},
+		},
+		{
+			name:     "chrome on ipad",
+			raw:      "Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			expected: UserAgent{BrowserFamily: "Chrome", BrowserVersion: "120.0.6099.119", OSFamily: "iOS", DeviceClass: DeviceTablet},
+		},
+		{
+			name:     "samsung internet on android phone",
+			raw:      "Mozilla/5.0 (Linux; Android 13; SM-S901B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			expected: UserAgent{BrowserFamily: "Samsung Internet", BrowserVersion: "23.0", OSFamily: "Android", DeviceClass: DeviceMobile},
+		},
+		{
+			name:     "chrome on android tablet",
+			raw:      "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected: UserAgent{BrowserFamily: "Chrome", BrowserVersion: "120.0.0.0", OSFamily: "Android", DeviceClass: DeviceTablet},
+		},
+		{
+			name:     "googlebot",
+			raw:      "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			expected: UserAgent{BrowserFamily: "Googlebot", BrowserVersion: "2.1", OSFamily: UnknownFamily, DeviceClass: DeviceBot},
+		},
+		{
+			name:     "curl",
+			raw:      "curl/8.4.0",
+			expected: UserAgent{BrowserFamily: "curl", BrowserVersion: "8.4.0", OSFamily: UnknownFamily, DeviceClass: DeviceBot},
+		},
+		{
+			name:     "unknown client",
+			raw:      "SomeClient",
+			expected: UserAgent{BrowserFamily: UnknownFamily, OSFamily: UnknownFamily, DeviceClass: DeviceDesktop},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseUserAgent(tt.raw); got != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
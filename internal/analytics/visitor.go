@@ -0,0 +1,156 @@
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// visitorSketchKey identifie le sketch d'un lien pour une journée (UTC).
+type visitorSketchKey struct {
+	linkID uint
+	day    int64
+}
+
+// VisitorCounter estime les visiteurs uniques de chaque lien par journée.
+// Un visiteur est identifié par une empreinte de son IP et de son User-Agent, salée par un sel
+// aléatoire qui change chaque jour : ni l'IP ni l'empreinte ne sont conservées, et un même
+// visiteur n'est pas rapprochable d'un jour à l'autre.
+//
+// Les empreintes alimentent des sketches HyperLogLog en mémoire, fusionnés périodiquement
+// avec les sketches persistés (voir Flush et Start).
+type VisitorCounter struct {
+	repo   repository.VisitorRepository
+	salts  *DailySalt
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	pending  map[visitorSketchKey]*HyperLogLog
+	stopChan chan struct{}
+}
+
+// NewVisitorCounter crée un compteur de visiteurs uniques persistant ses sketches dans repo
+// et salant les empreintes avec salts.
+func NewVisitorCounter(repo repository.VisitorRepository, salts *DailySalt) *VisitorCounter {
+	return &VisitorCounter{
+		repo:    repo,
+		salts:   salts,
+		logger:  slog.Default(),
+		pending: make(map[visitorSketchKey]*HyperLogLog),
+	}
+}
+
+// SetLogger remplace le logger du compteur, par défaut slog.Default().
+func (c *VisitorCounter) SetLogger(logger *slog.Logger) {
+	c.logger = logger.With("component", "visitor_counter")
+}
+
+// Record comptabilise un visiteur du lien à l'instant ts. Un clic antérieur à la veille (relu
+// depuis le spool plusieurs jours après) est ignoré : le sel de sa journée a pu être supprimé.
+func (c *VisitorCounter) Record(linkID uint, ts time.Time, ipAddress, userAgent string) error {
+	ts = ts.UTC()
+	day := time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)
+
+	salt, err := c.salts.For(day)
+	if errors.Is(err, ErrSaltExpired) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := visitorSketchKey{linkID: linkID, day: day.Unix()}
+	sketch, ok := c.pending[key]
+	if !ok {
+		sketch = NewHyperLogLog()
+		c.pending[key] = sketch
+	}
+	sketch.Add(visitorFingerprint(salt, ipAddress, userAgent))
+	return nil
+}
+
+// visitorFingerprint dérive une empreinte 64 bits du sel, de l'IP et du User-Agent.
+func visitorFingerprint(salt []byte, ipAddress, userAgent string) uint64 {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(ipAddress))
+	h.Write([]byte{0})
+	h.Write([]byte(userAgent))
+	return binary.BigEndian.Uint64(h.Sum(nil))
+}
+
+// Flush fusionne les sketches en attente avec les sketches persistés.
+// Les sketches dont la fusion échoue sont conservés pour le prochain appel.
+func (c *VisitorCounter) Flush() error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[visitorSketchKey]*HyperLogLog)
+	c.mu.Unlock()
+
+	var firstErr error
+	for key, sketch := range pending {
+		err := c.repo.MergeSketch(key.linkID, time.Unix(key.day, 0).UTC(), sketch.Bytes(), mergeSketches)
+		if err == nil {
+			continue
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		c.mu.Lock()
+		if current, ok := c.pending[key]; ok {
+			current.Merge(sketch)
+		} else {
+			c.pending[key] = sketch
+		}
+		c.mu.Unlock()
+	}
+	return firstErr
+}
+
+func mergeSketches(stored, pending []byte) ([]byte, error) {
+	merged, err := HyperLogLogFromBytes(stored)
+	if err != nil {
+		return nil, err
+	}
+	other, err := HyperLogLogFromBytes(pending)
+	if err != nil {
+		return nil, err
+	}
+	merged.Merge(other)
+	return merged.Bytes(), nil
+}
+
+// Start lance la fusion périodique des sketches en attente dans une goroutine.
+func (c *VisitorCounter) Start(interval time.Duration) {
+	c.stopChan = make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.Flush(); err != nil {
+					c.logger.Error("Échec de la fusion des sketches de visiteurs", "error", err)
+				}
+			case <-c.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop arrête la fusion périodique puis fusionne les sketches encore en attente.
+func (c *VisitorCounter) Stop() error {
+	if c.stopChan != nil {
+		close(c.stopChan)
+	}
+	return c.Flush()
+}
@@ -0,0 +1,121 @@
+package analytics
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/services/mocks"
+)
+
+func TestVisitorCounter(t *testing.T) {
+	repo := mocks.NewMockVisitorRepository()
+	salts := NewDailySalt(repo)
+	counter := NewVisitorCounter(repo, salts)
+
+	day1 := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	day3 := day1.AddDate(0, 0, 2)
+	now := day1
+	salts.now = func() time.Time { return now }
+
+	// Un même visiteur qui rafraîchit la page ne compte qu'une fois dans la journée.
+	for i := 0; i < 10; i++ {
+		mustRecord(t, counter, 1, day1.Add(time.Duration(i)*time.Minute), "203.0.113.7", "Firefox")
+	}
+	mustRecord(t, counter, 1, day1, "203.0.113.8", "Firefox")
+	mustRecord(t, counter, 1, day1, "203.0.113.7", "Chrome")
+	mustRecord(t, counter, 2, day1, "203.0.113.7", "Firefox")
+	if err := counter.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	// Les fusions successives ne recomptent pas les visiteurs déjà vus.
+	now = day2
+	mustRecord(t, counter, 1, day1.Add(time.Hour), "203.0.113.8", "Firefox")
+	mustRecord(t, counter, 1, day2, "203.0.113.7", "Firefox")
+	if err := counter.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	sketches, err := repo.ListSketches(1, time.Time{}, day3)
+	if err != nil {
+		t.Fatalf("Failed to list sketches: %v", err)
+	}
+	if len(sketches) != 2 {
+		t.Fatalf("Expected 2 daily sketches, got %d", len(sketches))
+	}
+	for i, expected := range []int{3, 1} {
+		hll, err := HyperLogLogFromBytes(sketches[i].Registers)
+		if err != nil {
+			t.Fatalf("Failed to restore sketch: %v", err)
+		}
+		if got := hll.Count(); got != expected {
+			t.Errorf("Day %d: expected %d unique visitors, got %d", i, expected, got)
+		}
+	}
+
+	// Seuls les sels du jour et de la veille sont conservés.
+	now = day3
+	mustRecord(t, counter, 1, day3, "203.0.113.7", "Firefox")
+	if got, expected := repo.SaltDays(), []string{"2025-03-11", "2025-03-12"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected salts for %v, got %v", expected, got)
+	}
+}
+
+func TestVisitorCounter_ExpiredSalt(t *testing.T) {
+	repo := mocks.NewMockVisitorRepository()
+	salts := NewDailySalt(repo)
+	counter := NewVisitorCounter(repo, salts)
+
+	day1 := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	now := day1
+	salts.now = func() time.Time { return now }
+	mustRecord(t, counter, 1, day1, "203.0.113.7", "Firefox")
+	if err := counter.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	// Trois jours plus tard, le sel du 10 a été supprimé : un clic de ce jour relu depuis le spool
+	// n'est pas compté, et son sel n'est pas recréé.
+	now = day1.AddDate(0, 0, 3)
+	mustRecord(t, counter, 1, now, "203.0.113.7", "Firefox")
+	if _, err := salts.For(day1); !errors.Is(err, ErrSaltExpired) {
+		t.Errorf("Expected ErrSaltExpired, got %v", err)
+	}
+	mustRecord(t, counter, 1, day1.Add(time.Hour), "203.0.113.8", "Firefox")
+	if err := counter.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	if got, expected := repo.SaltDays(), []string{"2025-03-13"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected salts for %v, got %v", expected, got)
+	}
+	sketches, err := repo.ListSketches(1, day1.Truncate(24*time.Hour), day1.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Failed to list sketches: %v", err)
+	}
+	if len(sketches) != 1 {
+		t.Fatalf("Expected 1 sketch for the first day, got %d", len(sketches))
+	}
+	hll, err := HyperLogLogFromBytes(sketches[0].Registers)
+	if err != nil {
+		t.Fatalf("Failed to restore sketch: %v", err)
+	}
+	if got := hll.Count(); got != 1 {
+		t.Errorf("Expected the late click not to be counted, got %d unique visitors", got)
+	}
+
+	// La veille reste acceptée pour les clics en attente au passage de minuit.
+	if _, err := salts.For(now.AddDate(0, 0, -1)); err != nil {
+		t.Errorf("Expected the previous day to be accepted, got %v", err)
+	}
+}
+
+func mustRecord(t *testing.T, counter *VisitorCounter, linkID uint, ts time.Time, ip, ua string) {
+	t.Helper()
+	if err := counter.Record(linkID, ts, ip, ua); err != nil {
+		t.Fatalf("Failed to record visitor: %v", err)
+	}
+}
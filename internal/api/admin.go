@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/axellelanca/urlshortener/internal/bloom"
+	"github.com/gin-gonic/gin"
+)
+
+// CodeFilterStatsHandler retourne l'état du filtre de Bloom des codes courts.
+func CodeFilterStatsHandler(codeFilter *bloom.CodeFilter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, codeFilter.Stats())
+	}
+}
+
+// RebuildCodeFilterHandler reconstruit le filtre de Bloom des codes courts à partir de la base,
+// par exemple après un import massif de liens ou pour l'adapter au nombre de liens.
+func RebuildCodeFilterHandler(codeFilter *bloom.CodeFilter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats, err := codeFilter.Rebuild()
+		if err != nil {
+			RequestLogger(c).Error("Failed to rebuild short code filter", "error", err)
+			c.JSON(http.StatusInternalServerError, errorResponse(c, "Failed to rebuild short code filter"))
+			return
+		}
+		RequestLogger(c).Info("Short code filter rebuilt", "codes", stats.Codes, "capacity", stats.Capacity)
+		c.JSON(http.StatusOK, stats)
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/bloom"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/services/mocks"
+	"github.com/gin-gonic/gin"
+)
+
+func setupCodeFilterRouter(t *testing.T) (*gin.Engine, *mocks.MockLinkRepository, *bloom.CodeFilter) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	mockLinkRepo := mocks.NewMockLinkRepository()
+	linkService := services.NewLinkService(mockLinkRepo, mocks.NewMockClickRepository())
+	codeFilter := bloom.NewCodeFilter(mockLinkRepo, 0.01, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	linkService.SetCodeFilter(codeFilter)
+
+	router := gin.New()
+	SetupRoutes(router, linkService, 100, "http://localhost:8080", RouteOptions{
+		CodeFilter:    codeFilter,
+		AdminAccounts: gin.Accounts{"admin": "secret"},
+	})
+	return router, mockLinkRepo, codeFilter
+}
+
+func TestRedirectHandler_CodeFilter(t *testing.T) {
+	router, mockLinkRepo, codeFilter := setupCodeFilterRouter(t)
+	if err := mockLinkRepo.CreateLink(&models.Link{ShortCode: "existing", LongURL: "https://example.com"}); err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
+	if _, err := codeFilter.Rebuild(); err != nil {
+		t.Fatalf("Failed to build filter: %v", err)
+	}
+
+	redirect := func(code string) int {
+		req, _ := http.NewRequest("GET", "/"+code, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if status := redirect("existing"); status != http.StatusFound {
+		t.Errorf("Expected status %d for an existing code, got %d", http.StatusFound, status)
+	}
+
+	// Une base indisponible donnerait une erreur 500 : le 404 prouve que la base n'est pas interrogée.
+	mockLinkRepo.SetShouldFail(true)
+	if status := redirect("unknown"); status != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown code, got %d", http.StatusNotFound, status)
+	}
+	if status := redirect("existing"); status != http.StatusInternalServerError {
+		t.Errorf("Expected existing codes to reach the database, got %d", status)
+	}
+	if rejected := codeFilter.Stats().Rejected; rejected != 1 {
+		t.Errorf("Expected one rejected lookup, got %d", rejected)
+	}
+}
+
+func TestAdminCodeFilterHandlers(t *testing.T) {
+	router, mockLinkRepo, _ := setupCodeFilterRouter(t)
+	if err := mockLinkRepo.CreateLink(&models.Link{ShortCode: "existing", LongURL: "https://example.com"}); err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
+
+	request := func(method, path string, auth bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		if auth {
+			req.SetBasicAuth("admin", "secret")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := request("POST", "/admin/bloom/rebuild", false); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without credentials, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	w := request("POST", "/admin/bloom/rebuild", true)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d (%s)", http.StatusOK, w.Code, w.Body.String())
+	}
+	var stats bloom.CodeFilterStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if !stats.Built || stats.Codes != 1 {
+		t.Errorf("Unexpected stats after rebuild: %+v", stats)
+	}
+
+	if w := request("GET", "/admin/bloom", true); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	mockLinkRepo.SetShouldFail(true)
+	if w := request("POST", "/admin/bloom/rebuild", true); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d when the database fails, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyContextKey est la clé sous laquelle la clé d'API authentifiée est stockée dans le contexte Gin.
+const apiKeyContextKey = "apiKey"
+
+// APIKeyAuthMiddleware exige un en-tête "Authorization: Bearer <clé>" valide
+// et stocke la clé d'API authentifiée dans le contexte de la requête.
+func APIKeyAuthMiddleware(apiKeyService *services.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(c, "Missing or malformed Authorization header"))
+			return
+		}
+
+		key, err := apiKeyService.Authenticate(strings.TrimSpace(token))
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidAPIKey) {
+				c.Header("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(c, "Invalid API key"))
+				return
+			}
+			RequestLogger(c).Error("Failed to authenticate API key", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
+			return
+		}
+
+		c.Set(apiKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// LinkOwnerMiddleware limite les routes /links/:shortCode aux liens appartenant à la clé d'API authentifiée.
+// Il doit être placé après APIKeyAuthMiddleware.
+func LinkOwnerMiddleware(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := CurrentAPIKey(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(c, "Authentication required"))
+			return
+		}
+
+		shortCode := c.Param("shortCode")
+		if err := linkService.CheckLinkOwner(shortCode, key.ID); err != nil {
+			switch {
+			case errors.Is(err, models.ErrLinkNotFound):
+				c.AbortWithStatusJSON(http.StatusNotFound, errorResponse(c, "Link not found"))
+			case errors.Is(err, models.ErrLinkForbidden):
+				c.AbortWithStatusJSON(http.StatusForbidden, errorResponse(c, "Link belongs to another API key"))
+			default:
+				RequestLogger(c).Error("Failed to check link owner", "short_code", shortCode, "error", err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
+			}
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CurrentAPIKey retourne la clé d'API authentifiée pour la requête, si l'authentification est activée.
+func CurrentAPIKey(c *gin.Context) (*models.APIKey, bool) {
+	value, exists := c.Get(apiKeyContextKey)
+	if !exists {
+		return nil, false
+	}
+	key, ok := value.(*models.APIKey)
+	return key, ok
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/services/mocks"
+	"github.com/gin-gonic/gin"
+)
+
+func setupAuthTestRouter() (*gin.Engine, *services.APIKeyService) {
+	gin.SetMode(gin.TestMode)
+
+	linkService := services.NewLinkService(mocks.NewMockLinkRepository(), mocks.NewMockClickRepository())
+	apiKeyService := services.NewAPIKeyService(mocks.NewMockAPIKeyRepository())
+
+	router := gin.New()
+	SetupRoutes(router, linkService, 100, "http://localhost:8080", RouteOptions{APIKeyService: apiKeyService})
+
+	return router, apiKeyService
+}
+
+func doAuthRequest(router *gin.Engine, method, path, apiKey string, body map[string]interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeyAuthMiddleware(t *testing.T) {
+	router, apiKeyService := setupAuthTestRouter()
+
+	validKey, _, err := apiKeyService.IssueKey("valid")
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+	revokedKey, revoked, err := apiKeyService.IssueKey("revoked")
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+	if _, err := apiKeyService.RevokeKey(revoked.ID); err != nil {
+		t.Fatalf("Failed to revoke API key: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		apiKey         string
+		expectedStatus int
+	}{
+		{"missing key", "", http.StatusUnauthorized},
+		{"unknown key", "usk_0000000000000000", http.StatusUnauthorized},
+		{"revoked key", revokedKey, http.StatusUnauthorized},
+		{"valid key", validKey, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doAuthRequest(router, "POST", "/api/v1/links", tt.apiKey, map[string]interface{}{"long_url": "https://example.com"})
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	t.Run("redirect stays public", func(t *testing.T) {
+		w := doAuthRequest(router, "POST", "/api/v1/links", validKey, map[string]interface{}{"long_url": "https://example.com"})
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+
+		w = doAuthRequest(router, "GET", "/"+response["short_code"].(string), "", nil)
+		if w.Code != http.StatusFound {
+			t.Errorf("Expected status code %d, got %d", http.StatusFound, w.Code)
+		}
+	})
+}
+
+func TestLinkOwnerMiddleware(t *testing.T) {
+	router, apiKeyService := setupAuthTestRouter()
+
+	ownerKey, _, _ := apiKeyService.IssueKey("owner")
+	otherKey, _, _ := apiKeyService.IssueKey("other")
+
+	w := doAuthRequest(router, "POST", "/api/v1/links", ownerKey, map[string]interface{}{"long_url": "https://example.com"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create test link: %d", w.Code)
+	}
+	var created map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	shortCode := created["short_code"].(string)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		apiKey         string
+		expectedStatus int
+	}{
+		{"owner reads stats", "GET", "/api/v1/links/" + shortCode + "/stats", ownerKey, http.StatusOK},
+		{"other key reads stats", "GET", "/api/v1/links/" + shortCode + "/stats", otherKey, http.StatusForbidden},
+		{"other key deletes", "DELETE", "/api/v1/links/" + shortCode, otherKey, http.StatusForbidden},
+		{"owner deletes", "DELETE", "/api/v1/links/" + shortCode, ownerKey, http.StatusNoContent},
+		{"other key restores", "POST", "/api/v1/links/" + shortCode + "/restore", otherKey, http.StatusForbidden},
+		{"owner restores", "POST", "/api/v1/links/" + shortCode + "/restore", ownerKey, http.StatusOK},
+		{"unknown link", "GET", "/api/v1/links/nonexistent/stats", ownerKey, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doAuthRequest(router, tt.method, tt.path, tt.apiKey, nil)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	t.Run("list only returns owned links", func(t *testing.T) {
+		for key, expected := range map[string]int{ownerKey: 1, otherKey: 0} {
+			w := doAuthRequest(router, "GET", "/api/v1/links", key, nil)
+			var response map[string]interface{}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if links := response["links"].([]interface{}); len(links) != expected {
+				t.Errorf("Expected %d link(s), got %d", expected, len(links))
+			}
+		}
+	})
+}
@@ -2,75 +2,247 @@ package api
 
 import (
 	"errors"
-	"log"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/axellelanca/urlshortener/internal/bloom"
+	"github.com/axellelanca/urlshortener/internal/health"
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/ratelimit"
+	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/spool"
 	"github.com/gin-gonic/gin"
 )
 
 var ClickEventsChannel chan models.ClickEvent
 
-func SetupRoutes(router *gin.Engine, linkService *services.LinkService, bufferSize int, baseURL string) {
+// clickEventsGate empêche les envois sur ClickEventsChannel une fois qu'il a été fermé par CloseClickEvents.
+var clickEventsGate struct {
+	sync.RWMutex
+	closed bool
+}
+
+// CloseClickEvents ferme ClickEventsChannel pour que les workers terminent, après les envois en cours.
+// Les clics des redirections encore servies ensuite passent par le spool, ou sont perdus et comptés.
+func CloseClickEvents() {
+	clickEventsGate.Lock()
+	defer clickEventsGate.Unlock()
+	if !clickEventsGate.closed {
+		clickEventsGate.closed = true
+		close(ClickEventsChannel)
+	}
+}
+
+// sendClickEvent transmet event aux workers sans bloquer. sent est faux si le channel est plein
+// ou déjà fermé, ce qu'indique closed.
+func sendClickEvent(event models.ClickEvent) (sent, closed bool) {
+	clickEventsGate.RLock()
+	defer clickEventsGate.RUnlock()
+	if clickEventsGate.closed {
+		return false, true
+	}
+	select {
+	case ClickEventsChannel <- event:
+		return true, false
+	default:
+		return false, false
+	}
+}
+
+// RouteOptions regroupe les dépendances optionnelles des routes.
+// Une dépendance nil désactive la fonctionnalité correspondante.
+type RouteOptions struct {
+	// ClickService active la ventilation des clics par navigateur, système et appareil.
+	ClickService *services.ClickService
+	// VisitorService ajoute les estimations de visiteurs uniques aux statistiques et séries temporelles.
+	VisitorService *services.VisitorService
+	// APIKeyService active l'authentification par clé d'API sur les routes /api/v1.
+	APIKeyService *services.APIKeyService
+	// CreateRateLimiter limite le débit des créations de liens, par clé d'API (par IP sans authentification).
+	CreateRateLimiter *ratelimit.Limiter
+	// CreateIPRateLimiter limite par IP le débit des créations de liens avant l'authentification :
+	// un flot de clés invalides est freiné sans atteindre la recherche des clés.
+	CreateIPRateLimiter *ratelimit.Limiter
+	// RedirectRateLimiter limite le débit des redirections.
+	RedirectRateLimiter *ratelimit.Limiter
+	// ClickSpool conserve sur disque les clics qui ne tiennent plus dans ClickEventsChannel.
+	// Sans spool, ces clics sont perdus.
+	ClickSpool *spool.Spool
+	// Metrics instrumente les redirections et les créations de liens, et expose les métriques sur MetricsPath.
+	Metrics     *metrics.Metrics
+	MetricsPath string
+	// MetricsAccounts protège MetricsPath par authentification basique s'il est non vide.
+	MetricsAccounts gin.Accounts
+	// Readiness fournit les vérifications de /readyz. Nil, le service est toujours déclaré prêt.
+	Readiness *health.Checker
+	// CodeFilter est administré par /admin/bloom, exposé uniquement si AdminAccounts est non vide.
+	CodeFilter    *bloom.CodeFilter
+	AdminAccounts gin.Accounts
+}
+
+func SetupRoutes(router *gin.Engine, linkService *services.LinkService, bufferSize int, baseURL string, opts RouteOptions) {
 	if ClickEventsChannel == nil {
 		ClickEventsChannel = make(chan models.ClickEvent, bufferSize)
 	}
 
 	router.GET("/health", HealthCheckHandler)
+	router.GET("/livez", HealthCheckHandler)
+	router.GET("/readyz", ReadinessHandler(opts.Readiness))
+	if opts.Metrics != nil {
+		metricsHandlers := []gin.HandlerFunc{MetricsHandler(opts.Metrics)}
+		if len(opts.MetricsAccounts) > 0 {
+			metricsHandlers = append([]gin.HandlerFunc{gin.BasicAuth(opts.MetricsAccounts)}, metricsHandlers...)
+		}
+		router.GET(opts.MetricsPath, metricsHandlers...)
+	}
 
-	apiV1 := router.Group("/api/v1")
+	if len(opts.AdminAccounts) > 0 && opts.CodeFilter != nil {
+		admin := router.Group("/admin", gin.BasicAuth(opts.AdminAccounts))
+		admin.GET("/bloom", CodeFilterStatsHandler(opts.CodeFilter))
+		admin.POST("/bloom/rebuild", RebuildCodeFilterHandler(opts.CodeFilter))
+	}
+
+	var authenticate []gin.HandlerFunc
+	if opts.APIKeyService != nil {
+		authenticate = append(authenticate, APIKeyAuthMiddleware(opts.APIKeyService))
+	}
+	apiV1 := router.Group("/api/v1", authenticate...)
 	{
-		apiV1.POST("/links", CreateShortLinkHandler(linkService, baseURL))
-		apiV1.GET("/links/:shortCode/stats", GetLinkStatsHandler(linkService))
+		// Budget par IP, puis authentification, puis budget par clé d'API.
+		createLink := withRateLimit(opts.CreateIPRateLimiter, slices.Concat(authenticate,
+			withRateLimit(opts.CreateRateLimiter, CreateShortLinkHandler(linkService, baseURL)))...)
+		router.POST("/api/v1/links", withMetrics(opts.Metrics, LinkCreationMetricsMiddleware, createLink)...)
+		apiV1.GET("/links", ListLinksHandler(linkService, baseURL))
+	}
+
+	link := apiV1.Group("/links/:shortCode")
+	if opts.APIKeyService != nil {
+		link.Use(LinkOwnerMiddleware(linkService))
+	}
+	{
+		link.GET("/stats", GetLinkStatsHandler(linkService, opts.VisitorService))
+		link.GET("/clicks/timeseries", GetClickTimeSeriesHandler(linkService, opts.VisitorService))
+		if opts.ClickService != nil {
+			link.GET("/clicks/breakdown", GetClickBreakdownHandler(linkService, opts.ClickService))
+		}
+		link.PATCH("", UpdateLinkHandler(linkService, baseURL))
+		link.DELETE("", DeleteLinkHandler(linkService))
+		link.POST("/restore", RestoreLinkHandler(linkService, baseURL))
 	}
 
-	router.GET("/:shortCode", RedirectHandler(linkService))
+	redirect := withMetrics(opts.Metrics, RedirectMetricsMiddleware,
+		withRateLimit(opts.RedirectRateLimiter, RedirectHandler(linkService, opts.ClickSpool, opts.Metrics)))
+	router.GET("/:shortCode", redirect...)
+	// Les vérificateurs de liens utilisent HEAD : la redirection est servie, le clic compté comme robot.
+	router.HEAD("/:shortCode", redirect...)
 }
 
+// withRateLimit préfixe les handlers par le middleware de limitation de débit si un limiteur est fourni.
+func withRateLimit(limiter *ratelimit.Limiter, handlers ...gin.HandlerFunc) []gin.HandlerFunc {
+	if limiter == nil {
+		return handlers
+	}
+	return append([]gin.HandlerFunc{RateLimitMiddleware(limiter)}, handlers...)
+}
+
+// HealthCheckHandler sert la sonde de vivacité (/livez, et /health pour compatibilité) : il répond
+// dès que le serveur HTTP traite des requêtes, sans vérifier les dépendances.
 func HealthCheckHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+// ReadinessHandler sert la sonde de disponibilité : le résultat de chaque vérification, avec un
+// statut 503 si une vérification critique échoue.
+func ReadinessHandler(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checker.Run(c.Request.Context())
+		status := http.StatusOK
+		if !report.Ready() {
+			status = http.StatusServiceUnavailable
+			RequestLogger(c).Warn("Readiness check failed", "checks", report.Checks)
+		}
+		c.JSON(status, report)
+	}
+}
+
 type CreateLinkRequest struct {
-	LongURL string `json:"long_url" binding:"required,url"`
+	LongURL    string     `json:"long_url" binding:"required,url"`
+	CustomCode string     `json:"custom_code"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	MaxClicks  *int       `json:"max_clicks"`
 }
 
 func CreateShortLinkHandler(linkService *services.LinkService, baseURL string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req CreateLinkRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
 			return
 		}
 
-		link, err := linkService.CreateLink(req.LongURL)
+		opts := services.CreateLinkOptions{
+			CustomCode: req.CustomCode,
+			ExpiresAt:  req.ExpiresAt,
+			MaxClicks:  req.MaxClicks,
+		}
+		if key, ok := CurrentAPIKey(c); ok {
+			opts.OwnerID = &key.ID
+		}
+
+		link, err := linkService.CreateLinkWithOptions(req.LongURL, opts)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			switch {
+			case errors.Is(err, models.ErrInvalidShortCode), errors.Is(err, models.ErrReservedShortCode),
+				errors.Is(err, models.ErrInvalidExpiration), errors.Is(err, models.ErrInvalidMaxClicks):
+				c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+			case errors.Is(err, models.ErrDuplicateShortCode):
+				c.JSON(http.StatusConflict, errorResponse(c, err.Error()))
+			default:
+				c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+			}
 			return
 		}
 
-		c.JSON(http.StatusCreated, gin.H{
-			"short_code":     link.ShortCode,
-			"long_url":       link.LongURL,
-			"full_short_url": baseURL + "/" + link.ShortCode,
-		})
+		c.JSON(http.StatusCreated, linkResponse(link, baseURL))
 	}
 }
 
-func RedirectHandler(linkService *services.LinkService) gin.HandlerFunc {
+// RedirectHandler redirige vers l'URL longue et transmet le clic aux workers. Si le channel
+// est plein ou fermé, le clic est ajouté à clickSpool (s'il est non nil) pour être relu plus tard.
+func RedirectHandler(linkService *services.LinkService, clickSpool *spool.Spool, m *metrics.Metrics) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		shortCode := c.Param("shortCode")
 
-		link, err := linkService.GetLinkByShortCode(shortCode)
+		link, err := linkService.ResolveLink(shortCode)
 		if err != nil {
 			if errors.Is(err, models.ErrLinkNotFound) {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+				c.JSON(http.StatusNotFound, errorResponse(c, "Link not found"))
+				return
+			}
+			if errors.Is(err, models.ErrLinkDisabled) {
+				c.JSON(http.StatusGone, errorResponse(c, "Link is disabled"))
+				return
+			}
+			if errors.Is(err, models.ErrLinkExpired) {
+				body := errorResponse(c, "Link has expired")
+				body["expired_at"] = link.ExpiresAt
+				c.JSON(http.StatusGone, body)
+				return
+			}
+			if errors.Is(err, models.ErrLinkClickLimitReached) {
+				body := errorResponse(c, "Link has reached its maximum number of clicks")
+				body["max_clicks"] = link.MaxClicks
+				c.JSON(http.StatusGone, body)
 				return
 			}
-			log.Printf("Error retrieving link for %s: %v", shortCode, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			RequestLogger(c).Error("Failed to resolve link", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
 			return
 		}
 
@@ -79,37 +251,431 @@ func RedirectHandler(linkService *services.LinkService) gin.HandlerFunc {
 			Timestamp: time.Now(),
 			UserAgent: c.GetHeader("User-Agent"),
 			IPAddress: c.ClientIP(),
+			Referrer:  c.GetHeader("Referer"),
+			Method:    c.Request.Method,
 		}
 
-		select {
-		case ClickEventsChannel <- clickEvent:
-		default:
-			log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
+		if sent, closed := sendClickEvent(clickEvent); !sent {
+			reason, problem := metrics.DropQueueFull, "Click events channel is full"
+			if closed {
+				reason, problem = metrics.DropShutdown, "Click events channel is closed"
+			}
+			if clickSpool == nil {
+				RequestLogger(c).Warn(problem+", dropping click event", "short_code", shortCode)
+				m.ClickEventsDropped(reason, 1)
+			} else if err := clickSpool.Append(clickEvent); err != nil {
+				RequestLogger(c).Warn(problem+" and spooling failed, dropping click event", "short_code", shortCode, "error", err)
+				m.ClickEventsDropped(reason, 1)
+			} else {
+				m.ClickEventsSpooled(1)
+			}
 		}
 
 		c.Redirect(http.StatusFound, link.LongURL)
 	}
 }
 
-func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
+type LinkStatsRequest struct {
+	Referrers int `form:"referrers" binding:"min=1,max=100"`
+	// IncludeBots compte aussi les clics de robots dans total_clicks.
+	IncludeBots bool `form:"include_bots"`
+}
+
+// GetLinkStatsHandler retourne les statistiques d'un lien. Si visitorService est non nil,
+// la réponse inclut l'estimation des visiteurs uniques.
+func GetLinkStatsHandler(linkService *services.LinkService, visitorService *services.VisitorService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		req := LinkStatsRequest{Referrers: 10}
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+			return
+		}
+
+		link, clicks, err := linkService.GetLinkStats(shortCode)
+		if err != nil {
+			if errors.Is(err, models.ErrLinkNotFound) {
+				c.JSON(http.StatusNotFound, errorResponse(c, "Link not found"))
+				return
+			}
+			RequestLogger(c).Error("Failed to retrieve link stats", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
+			return
+		}
+
+		referrers, err := linkService.GetTopReferrers(link.ID, req.Referrers)
+		if err != nil {
+			RequestLogger(c).Error("Failed to retrieve referrers", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
+			return
+		}
+		topReferrers := make([]gin.H, 0, len(referrers))
+		for _, referrer := range referrers {
+			topReferrers = append(topReferrers, gin.H{"host": referrer.Host, "clicks": referrer.Count})
+		}
+
+		var remainingClicks *int
+		if link.MaxClicks != nil {
+			remaining := max(*link.MaxClicks-clicks.Human, 0)
+			remainingClicks = &remaining
+		}
+
+		totalClicks := clicks.Human
+		if req.IncludeBots {
+			totalClicks = clicks.Total()
+		}
+
+		response := gin.H{
+			"short_code":       link.ShortCode,
+			"long_url":         link.LongURL,
+			"total_clicks":     totalClicks,
+			"human_clicks":     clicks.Human,
+			"bot_clicks":       clicks.Bot,
+			"status":           link.Status(clicks.Human, time.Now()),
+			"expires_at":       link.ExpiresAt,
+			"max_clicks":       link.MaxClicks,
+			"remaining_clicks": remainingClicks,
+			"disabled":         link.Disabled,
+			"top_referrers":    topReferrers,
+		}
+
+		if visitorService != nil {
+			visitors, err := visitorService.CountUniqueVisitors(link.ID, nil, nil)
+			if err != nil {
+				RequestLogger(c).Error("Failed to estimate unique visitors", "short_code", shortCode, "error", err)
+				c.JSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
+				return
+			}
+			response["unique_visitors"] = visitors
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+type ClickTimeSeriesRequest struct {
+	From     string `form:"from"`
+	To       string `form:"to"`
+	Interval string `form:"interval" binding:"omitempty,oneof=hour day week"`
+	// IncludeBots compte aussi les clics de robots dans clicks et total_clicks, comme pour /stats.
+	IncludeBots bool `form:"include_bots"`
+}
+
+// GetClickTimeSeriesHandler retourne les clics d'un lien par intervalle, séparés entre humains et robots.
+// Si visitorService est non nil, chaque intervalle journalier ou hebdomadaire inclut l'estimation
+// des visiteurs uniques.
+func GetClickTimeSeriesHandler(linkService *services.LinkService, visitorService *services.VisitorService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		shortCode := c.Param("shortCode")
 
-		link, totalClicks, err := linkService.GetLinkStats(shortCode)
+		var req ClickTimeSeriesRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+			return
+		}
+
+		from, err := parseTimeParam("from", req.From)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+			return
+		}
+		to, err := parseTimeParam("to", req.To)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+			return
+		}
+
+		series, err := linkService.GetClickTimeSeries(shortCode, from, to, req.Interval)
 		if err != nil {
 			if errors.Is(err, models.ErrLinkNotFound) {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+				c.JSON(http.StatusNotFound, errorResponse(c, "Link not found"))
 				return
 			}
-			log.Printf("Error retrieving stats for %s: %v", shortCode, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			if errors.Is(err, models.ErrInvalidTimeRange) {
+				c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+				return
+			}
+			RequestLogger(c).Error("Failed to retrieve click time series", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
 			return
 		}
 
+		var visitors map[int64]int
+		if visitorService != nil && series.Interval != repository.IntervalHour {
+			visitorBuckets, err := visitorService.UniqueVisitorsByInterval(series.Link.ID, series.From, series.To, series.Interval)
+			if err != nil {
+				RequestLogger(c).Error("Failed to estimate unique visitors", "short_code", shortCode, "error", err)
+				c.JSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
+				return
+			}
+			visitors = make(map[int64]int, len(visitorBuckets))
+			for _, bucket := range visitorBuckets {
+				visitors[bucket.Start.Unix()] = bucket.Visitors
+			}
+		}
+
+		var total repository.ClickCounts
+		buckets := make([]gin.H, 0, len(series.Buckets))
+		for _, bucket := range series.Buckets {
+			total.Human += bucket.Human
+			total.Bot += bucket.Bot
+			clicks := bucket.Human
+			if req.IncludeBots {
+				clicks = bucket.Total()
+			}
+			entry := gin.H{"start": bucket.Start, "clicks": clicks, "human_clicks": bucket.Human, "bot_clicks": bucket.Bot}
+			if visitors != nil {
+				entry["unique_visitors"] = visitors[bucket.Start.Unix()]
+			}
+			buckets = append(buckets, entry)
+		}
+
+		totalClicks := total.Human
+		if req.IncludeBots {
+			totalClicks = total.Total()
+		}
+
 		c.JSON(http.StatusOK, gin.H{
-			"short_code":   link.ShortCode,
-			"long_url":     link.LongURL,
+			"short_code":   series.Link.ShortCode,
+			"from":         series.From,
+			"to":           series.To,
+			"interval":     series.Interval,
 			"total_clicks": totalClicks,
+			"human_clicks": total.Human,
+			"bot_clicks":   total.Bot,
+			"buckets":      buckets,
 		})
 	}
 }
+
+type ClickBreakdownRequest struct {
+	Dimension string `form:"dimension" binding:"omitempty,oneof=browser os device"`
+	Limit     int    `form:"limit" binding:"min=1,max=100"`
+}
+
+// GetClickBreakdownHandler ventile les clics d'un lien par navigateur, système et classe d'appareil.
+// Le paramètre dimension restreint la réponse à une seule dimension.
+func GetClickBreakdownHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		req := ClickBreakdownRequest{Limit: 10}
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+			return
+		}
+
+		link, err := linkService.GetLinkByShortCode(shortCode)
+		if err != nil {
+			if errors.Is(err, models.ErrLinkNotFound) {
+				c.JSON(http.StatusNotFound, errorResponse(c, "Link not found"))
+				return
+			}
+			RequestLogger(c).Error("Failed to retrieve link", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
+			return
+		}
+
+		dimensions := services.ClickDimensions
+		if req.Dimension != "" {
+			dimensions = []string{req.Dimension}
+		}
+
+		breakdown := make(gin.H, len(dimensions))
+		for _, dimension := range dimensions {
+			counts, err := clickService.GetClickBreakdown(link.ID, dimension, req.Limit)
+			if err != nil {
+				RequestLogger(c).Error("Failed to retrieve click breakdown", "short_code", shortCode, "dimension", dimension, "error", err)
+				c.JSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
+				return
+			}
+			values := make([]gin.H, 0, len(counts))
+			for _, count := range counts {
+				values = append(values, gin.H{"value": count.Value, "clicks": count.Count})
+			}
+			breakdown[dimension] = values
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"short_code": link.ShortCode,
+			"breakdown":  breakdown,
+		})
+	}
+}
+
+type UpdateLinkRequest struct {
+	LongURL  *string `json:"long_url" binding:"omitempty,url"`
+	Disabled *bool   `json:"disabled"`
+}
+
+func UpdateLinkHandler(linkService *services.LinkService, baseURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req UpdateLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+			return
+		}
+
+		link, err := linkService.UpdateLink(shortCode, services.UpdateLinkOptions{
+			LongURL:  req.LongURL,
+			Disabled: req.Disabled,
+		})
+		if err != nil {
+			if errors.Is(err, models.ErrLinkNotFound) {
+				c.JSON(http.StatusNotFound, errorResponse(c, "Link not found"))
+				return
+			}
+			RequestLogger(c).Error("Failed to update link", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
+			return
+		}
+
+		c.JSON(http.StatusOK, linkResponse(link, baseURL))
+	}
+}
+
+func DeleteLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		if err := linkService.DeleteLink(shortCode); err != nil {
+			if errors.Is(err, models.ErrLinkNotFound) {
+				c.JSON(http.StatusNotFound, errorResponse(c, "Link not found"))
+				return
+			}
+			RequestLogger(c).Error("Failed to delete link", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func RestoreLinkHandler(linkService *services.LinkService, baseURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		link, err := linkService.RestoreLink(shortCode)
+		if err != nil {
+			if errors.Is(err, models.ErrLinkNotFound) {
+				c.JSON(http.StatusNotFound, errorResponse(c, "Link not found"))
+				return
+			}
+			if errors.Is(err, models.ErrLinkNotDeleted) {
+				c.JSON(http.StatusConflict, errorResponse(c, "Link is not deleted"))
+				return
+			}
+			RequestLogger(c).Error("Failed to restore link", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
+			return
+		}
+
+		c.JSON(http.StatusOK, linkResponse(link, baseURL))
+	}
+}
+
+type ListLinksRequest struct {
+	Limit         int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor        string `form:"cursor"`
+	Sort          string `form:"sort" binding:"omitempty,oneof=created_at clicks"`
+	Order         string `form:"order" binding:"omitempty,oneof=asc desc"`
+	Domain        string `form:"domain"`
+	Search        string `form:"q"`
+	CreatedAfter  string `form:"created_after"`
+	CreatedBefore string `form:"created_before"`
+}
+
+func ListLinksHandler(linkService *services.LinkService, baseURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ListLinksRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+			return
+		}
+
+		createdAfter, err := parseTimeParam("created_after", req.CreatedAfter)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+			return
+		}
+		createdBefore, err := parseTimeParam("created_before", req.CreatedBefore)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+			return
+		}
+
+		opts := services.ListLinksOptions{
+			Domain:        req.Domain,
+			Search:        req.Search,
+			CreatedAfter:  createdAfter,
+			CreatedBefore: createdBefore,
+			SortBy:        req.Sort,
+			Order:         req.Order,
+			Cursor:        req.Cursor,
+			Limit:         req.Limit,
+		}
+		if key, ok := CurrentAPIKey(c); ok {
+			opts.OwnerID = &key.ID
+		}
+
+		page, err := linkService.ListLinks(opts)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidCursor) || errors.Is(err, models.ErrInvalidListQuery) {
+				c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+				return
+			}
+			RequestLogger(c).Error("Failed to list links", "error", err)
+			c.JSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
+			return
+		}
+
+		now := time.Now()
+		items := make([]gin.H, 0, len(page.Links))
+		for _, item := range page.Links {
+			response := linkResponse(&item.Link, baseURL)
+			response["created_at"] = item.CreatedAt
+			response["total_clicks"] = item.ClickCount
+			response["status"] = item.Status(item.ClickCount, now)
+			items = append(items, response)
+		}
+
+		var nextCursor *string
+		if page.NextCursor != "" {
+			nextCursor = &page.NextCursor
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"links":       items,
+			"next_cursor": nextCursor,
+		})
+	}
+}
+
+// parseTimeParam accepte une date au format RFC3339 ou AAAA-MM-JJ. Une valeur vide retourne nil.
+func parseTimeParam(name string, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return &t, nil
+	}
+	return nil, fmt.Errorf("invalid %s: expected RFC3339 or YYYY-MM-DD, got %s", name, strconv.Quote(value))
+}
+
+func linkResponse(link *models.Link, baseURL string) gin.H {
+	return gin.H{
+		"short_code":     link.ShortCode,
+		"long_url":       link.LongURL,
+		"full_short_url": baseURL + "/" + link.ShortCode,
+		"expires_at":     link.ExpiresAt,
+		"max_clicks":     link.MaxClicks,
+		"disabled":       link.Disabled,
+	}
+}
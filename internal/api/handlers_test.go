@@ -2,13 +2,25 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/axellelanca/urlshortener/internal/analytics"
+	"github.com/axellelanca/urlshortener/internal/cache"
+	"github.com/axellelanca/urlshortener/internal/health"
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/axellelanca/urlshortener/internal/services/mocks"
+	"github.com/axellelanca/urlshortener/internal/spool"
 	"github.com/gin-gonic/gin"
 )
 
@@ -20,7 +32,7 @@ func setupTestRouter() (*gin.Engine, *services.LinkService) {
 	linkService := services.NewLinkService(mockLinkRepo, mockClickRepo)
 	
 	router := gin.New()
-	SetupRoutes(router, linkService, 100, "http://localhost:8080")
+	SetupRoutes(router, linkService, 100, "http://localhost:8080", RouteOptions{})
 	
 	return router, linkService
 }
@@ -50,6 +62,61 @@ func TestHealthCheckHandler(t *testing.T) {
 	}
 }
 
+func TestReadinessHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var dbErr error
+	checker := health.NewChecker(time.Second)
+	checker.Register("database", true, func(context.Context) error { return dbErr })
+	checker.Register("url_monitor", false, func(context.Context) error { return errors.New("no heartbeat yet") })
+
+	linkService := services.NewLinkService(mocks.NewMockLinkRepository(), mocks.NewMockClickRepository())
+	router := gin.New()
+	SetupRoutes(router, linkService, 100, "http://localhost:8080", RouteOptions{Readiness: checker})
+
+	tests := []struct {
+		name           string
+		path           string
+		dbErr          error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Liveness ignores dependencies", "/livez", errors.New("database is locked"), http.StatusOK, health.StatusOK},
+		{"Ready with a non-critical failure", "/readyz", nil, http.StatusOK, health.StatusDegraded},
+		{"Not ready when the database fails", "/readyz", errors.New("database is locked"), http.StatusServiceUnavailable, health.StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbErr = tt.dbErr
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
+			}
+			var report health.Report
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if report.Status != tt.expectedBody {
+				t.Errorf("Expected status '%s', got '%s'", tt.expectedBody, report.Status)
+			}
+			if tt.path != "/readyz" {
+				return
+			}
+			database := report.Checks["database"]
+			if !database.Critical || (tt.dbErr != nil) != (database.Status == health.StatusFail) {
+				t.Errorf("Unexpected database check result: %+v", database)
+			}
+			if monitor := report.Checks["url_monitor"]; monitor.Critical || monitor.Error != "no heartbeat yet" {
+				t.Errorf("Unexpected monitor check result: %+v", monitor)
+			}
+		})
+	}
+}
+
 func TestCreateShortLinkHandler(t *testing.T) {
 	router, _ := setupTestRouter()
 
@@ -126,6 +193,131 @@ func TestCreateShortLinkHandler(t *testing.T) {
 	}
 }
 
+func TestCreateShortLinkHandler_CustomCode(t *testing.T) {
+	router, linkService := setupTestRouter()
+
+	if _, err := linkService.CreateLinkWithOptions("https://example.com", services.CreateLinkOptions{CustomCode: "taken"}); err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		customCode     string
+		expectedStatus int
+	}{
+		{
+			name:           "valid custom code",
+			customCode:     "spring-sale",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "duplicate custom code",
+			customCode:     "taken",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "reserved custom code",
+			customCode:     "health",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "reserved custom code case insensitive",
+			customCode:     "API",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid characters",
+			customCode:     "spring sale!",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "too short",
+			customCode:     "ab",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]interface{}{
+				"long_url":    "https://example.com/promo",
+				"custom_code": tt.customCode,
+			})
+
+			req, err := http.NewRequest("POST", "/api/v1/links", bytes.NewBuffer(body))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedStatus == http.StatusCreated {
+				var response map[string]interface{}
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				if response["short_code"] != tt.customCode {
+					t.Errorf("Expected short_code %s, got %v", tt.customCode, response["short_code"])
+				}
+			}
+		})
+	}
+}
+
+// scriptedGenerator propose des codes prédéfinis et enregistre les collisions signalées.
+type scriptedGenerator struct {
+	codes    []string
+	observed []bool
+}
+
+func (g *scriptedGenerator) Generate() (string, error) {
+	code := g.codes[0]
+	g.codes = g.codes[1:]
+	return code, nil
+}
+
+func (g *scriptedGenerator) Observe(taken bool) {
+	g.observed = append(g.observed, taken)
+}
+
+func TestCreateShortLinkHandler_CodeGenerator(t *testing.T) {
+	router, linkService := setupTestRouter()
+	if _, err := linkService.CreateLinkWithOptions("https://example.com", services.CreateLinkOptions{CustomCode: "taken"}); err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
+	generator := &scriptedGenerator{codes: []string{"API", "taken", "fresh"}}
+	linkService.SetCodeGenerator(generator)
+
+	payload, _ := json.Marshal(map[string]interface{}{"long_url": "https://example.org"})
+	req, _ := http.NewRequest("POST", "/api/v1/links", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	// Les codes réservés et déjà attribués sont écartés et signalés au générateur.
+	if w.Code != http.StatusCreated || response["short_code"] != "fresh" {
+		t.Fatalf("Expected the first free code, got %d (%s)", w.Code, w.Body.String())
+	}
+	if len(generator.observed) != 3 || !generator.observed[0] || !generator.observed[1] || generator.observed[2] {
+		t.Errorf("Expected collisions to be reported, got %v", generator.observed)
+	}
+
+	linkService.SetCodeGenerator(&scriptedGenerator{codes: slices.Repeat([]string{"taken"}, 10)})
+	if _, err := linkService.CreateLink("https://example.net"); !errors.Is(err, models.ErrShortCodeGenerationFailed) {
+		t.Errorf("Expected ErrShortCodeGenerationFailed, got %v", err)
+	}
+}
+
 func TestRedirectHandler(t *testing.T) {
 	router, linkService := setupTestRouter()
 	
@@ -178,13 +370,150 @@ func TestRedirectHandler(t *testing.T) {
 	}
 }
 
-func TestGetLinkStatsHandler(t *testing.T) {
-	router, linkService := setupTestRouter()
+func TestRedirectHandler_SpoolsOverflow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	linkService := services.NewLinkService(mocks.NewMockLinkRepository(), mocks.NewMockClickRepository())
+	link, err := linkService.CreateLink("https://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
 
+	clickSpool, err := spool.Open(t.TempDir(), 1<<20, 0)
+	if err != nil {
+		t.Fatalf("Failed to open spool: %v", err)
+	}
+
+	// Un channel plein oblige le handler à passer par le spool.
+	previous := ClickEventsChannel
+	ClickEventsChannel = make(chan models.ClickEvent, 1)
+	ClickEventsChannel <- models.ClickEvent{}
+	defer func() { ClickEventsChannel = previous }()
+
+	router := gin.New()
+	SetupRoutes(router, linkService, 1, "http://localhost:8080", RouteOptions{ClickSpool: clickSpool})
+
+	req, _ := http.NewRequest("GET", "/"+link.ShortCode, nil)
+	req.Header.Set("Referer", "https://t.co/abc")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusFound {
+		t.Fatalf("Expected status code %d, got %d", http.StatusFound, w.Code)
+	}
+
+	var spooled []models.ClickEvent
+	if _, err := clickSpool.Replay(context.Background(), func(event models.ClickEvent) bool {
+		spooled = append(spooled, event)
+		event.Done()
+		return true
+	}); err != nil {
+		t.Fatalf("Failed to replay spool: %v", err)
+	}
+	if len(spooled) != 1 || spooled[0].LinkID != link.ID || spooled[0].Referrer != "https://t.co/abc" {
+		t.Errorf("Expected the click to be spooled, got %+v", spooled)
+	}
+}
+
+func TestRedirectHandler_AfterCloseClickEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	linkService := services.NewLinkService(mocks.NewMockLinkRepository(), mocks.NewMockClickRepository())
 	link, err := linkService.CreateLink("https://example.com")
 	if err != nil {
 		t.Fatalf("Failed to create test link: %v", err)
 	}
+	clickSpool, err := spool.Open(t.TempDir(), 1<<20, 0)
+	if err != nil {
+		t.Fatalf("Failed to open spool: %v", err)
+	}
+	serviceMetrics := metrics.New(func() int { return 0 }, func() int { return 0 })
+
+	previous := ClickEventsChannel
+	ClickEventsChannel = make(chan models.ClickEvent, 1000)
+	defer func() {
+		ClickEventsChannel = previous
+		clickEventsGate.closed = false
+	}()
+
+	withSpool := gin.New()
+	SetupRoutes(withSpool, linkService, 1, "http://localhost:8080", RouteOptions{ClickSpool: clickSpool})
+	withoutSpool := gin.New()
+	SetupRoutes(withoutSpool, linkService, 1, "http://localhost:8080", RouteOptions{
+		Metrics:     serviceMetrics,
+		MetricsPath: "/metrics",
+	})
+	redirect := func(router *gin.Engine) int {
+		req, _ := http.NewRequest("GET", "/"+link.ShortCode, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Des redirections concurrentes de la fermeture du channel ne doivent jamais paniquer.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if code := redirect(withSpool); code != http.StatusFound {
+					t.Errorf("Expected status code %d, got %d", http.StatusFound, code)
+					return
+				}
+			}
+		}()
+	}
+	CloseClickEvents()
+	CloseClickEvents()
+	wg.Wait()
+
+	received := 0
+	for range ClickEventsChannel {
+		received++
+	}
+	spooled, err := clickSpool.Replay(context.Background(), func(event models.ClickEvent) bool {
+		event.Done()
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Failed to replay spool: %v", err)
+	}
+	if received+spooled != 200 {
+		t.Errorf("Expected every click to be sent or spooled, got %d sent and %d spooled", received, spooled)
+	}
+
+	// Sans spool, un clic arrivé après la fermeture est perdu et compté.
+	if code := redirect(withoutSpool); code != http.StatusFound {
+		t.Fatalf("Expected status code %d, got %d", http.StatusFound, code)
+	}
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	withoutSpool.ServeHTTP(w, req)
+	if expected := `urlshortener_click_events_dropped_total{reason="shutdown"} 1`; !strings.Contains(w.Body.String(), expected) {
+		t.Errorf("Expected metrics to contain %q", expected)
+	}
+}
+
+func TestRedirectHandler_ExpiredLinks(t *testing.T) {
+	router, linkService := setupTestRouter()
+
+	expiresAt := time.Now().Add(time.Hour)
+	expiredLink, err := linkService.CreateLinkWithOptions("https://example.com", services.CreateLinkOptions{ExpiresAt: &expiresAt})
+	if err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	expiredLink.ExpiresAt = &past
+
+	maxClicks := 10
+	exhaustedLink, err := linkService.CreateLinkWithOptions("https://example.com", services.CreateLinkOptions{MaxClicks: &maxClicks})
+	if err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
+	exhaustedLink.Expired = true
+
+	budgetLink, err := linkService.CreateLinkWithOptions("https://example.com", services.CreateLinkOptions{MaxClicks: &maxClicks})
+	if err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
 
 	tests := []struct {
 		name           string
@@ -192,20 +521,25 @@ func TestGetLinkStatsHandler(t *testing.T) {
 		expectedStatus int
 	}{
 		{
-			name:           "valid short code",
-			shortCode:      link.ShortCode,
-			expectedStatus: http.StatusOK,
+			name:           "expired by date",
+			shortCode:      expiredLink.ShortCode,
+			expectedStatus: http.StatusGone,
 		},
 		{
-			name:           "invalid short code",
-			shortCode:      "nonexistent",
-			expectedStatus: http.StatusNotFound,
+			name:           "click budget exhausted",
+			shortCode:      exhaustedLink.ShortCode,
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:           "click budget remaining",
+			shortCode:      budgetLink.ShortCode,
+			expectedStatus: http.StatusFound,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req, err := http.NewRequest("GET", "/api/v1/links/"+tt.shortCode+"/stats", nil)
+			req, err := http.NewRequest("GET", "/"+tt.shortCode, nil)
 			if err != nil {
 				t.Fatalf("Failed to create request: %v", err)
 			}
@@ -217,26 +551,758 @@ func TestGetLinkStatsHandler(t *testing.T) {
 				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
 			}
 
-			if tt.expectedStatus == http.StatusOK {
+			if tt.expectedStatus == http.StatusGone {
 				var response map[string]interface{}
 				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 					t.Fatalf("Failed to unmarshal response: %v", err)
 				}
-
-				if response["short_code"] != tt.shortCode {
-					t.Errorf("Expected short_code %s, got %v", tt.shortCode, response["short_code"])
-				}
-				if response["long_url"] == nil {
-					t.Errorf("Expected long_url in response")
-				}
-				if response["total_clicks"] == nil {
-					t.Errorf("Expected total_clicks in response")
+				if response["error"] == nil {
+					t.Errorf("Expected error in response")
 				}
 			}
 		})
 	}
 }
 
+func TestRedirectHandler_Cache(t *testing.T) {
+	router, linkService := setupTestRouter()
+	linkCache := cache.NewLinkCache(100, time.Minute, time.Minute)
+	linkService.SetCache(linkCache)
+
+	redirect := func(code string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/"+code, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Le code inexistant est mis en cache, puis invalidé par la création du lien.
+	if w := redirect("promo-2025"); w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status %d for an unknown code, got %d", http.StatusNotFound, w.Code)
+	}
+	if _, err := linkService.CreateLinkWithOptions("https://example.com", services.CreateLinkOptions{CustomCode: "promo-2025"}); err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
+	if w := redirect("promo-2025"); w.Code != http.StatusFound {
+		t.Fatalf("Expected the created link to redirect, got %d", w.Code)
+	}
+
+	steps := []struct {
+		name             string
+		method           string
+		body             map[string]interface{}
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{"update destination", "PATCH", map[string]interface{}{"long_url": "https://example.org"}, http.StatusFound, "https://example.org"},
+		{"disable", "PATCH", map[string]interface{}{"disabled": true}, http.StatusGone, ""},
+		{"enable", "PATCH", map[string]interface{}{"disabled": false}, http.StatusFound, "https://example.org"},
+		{"delete", "DELETE", nil, http.StatusNotFound, ""},
+	}
+	for _, step := range steps {
+		payload, _ := json.Marshal(step.body)
+		req, _ := http.NewRequest(step.method, "/api/v1/links/promo-2025", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code >= 300 {
+			t.Fatalf("%s: request failed with status %d (%s)", step.name, w.Code, w.Body.String())
+		}
+
+		// La première redirection recharge le lien, la seconde est servie par le cache.
+		for i := 0; i < 2; i++ {
+			w := redirect("promo-2025")
+			if w.Code != step.expectedStatus || w.Header().Get("Location") != step.expectedLocation {
+				t.Errorf("%s: expected status %d to %q, got %d to %q",
+					step.name, step.expectedStatus, step.expectedLocation, w.Code, w.Header().Get("Location"))
+			}
+		}
+	}
+
+	stats := linkCache.Stats()
+	if stats.Misses != 6 || stats.Hits != 4 || stats.NegativeHits != 1 {
+		t.Errorf("Unexpected cache stats: %+v", stats)
+	}
+}
+
+func TestCreateShortLinkHandler_Expiration(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	tests := []struct {
+		name           string
+		requestBody    map[string]interface{}
+		expectedStatus int
+	}{
+		{
+			name: "future expiration and click budget",
+			requestBody: map[string]interface{}{
+				"long_url":   "https://example.com",
+				"expires_at": time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+				"max_clicks": 100,
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "past expiration",
+			requestBody: map[string]interface{}{
+				"long_url":   "https://example.com",
+				"expires_at": time.Now().Add(-time.Hour).Format(time.RFC3339),
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "zero max clicks",
+			requestBody: map[string]interface{}{
+				"long_url":   "https://example.com",
+				"max_clicks": 0,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.requestBody)
+
+			req, err := http.NewRequest("POST", "/api/v1/links", bytes.NewBuffer(body))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestLinkLifecycleHandlers(t *testing.T) {
+	router, linkService := setupTestRouter()
+
+	link, err := linkService.CreateLink("https://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
+
+	steps := []struct {
+		name           string
+		method         string
+		path           string
+		body           map[string]interface{}
+		expectedStatus int
+	}{
+		{"update destination", "PATCH", "/api/v1/links/" + link.ShortCode, map[string]interface{}{"long_url": "https://example.org"}, http.StatusOK},
+		{"update invalid destination", "PATCH", "/api/v1/links/" + link.ShortCode, map[string]interface{}{"long_url": "not-a-url"}, http.StatusBadRequest},
+		{"redirect to new destination", "GET", "/" + link.ShortCode, nil, http.StatusFound},
+		{"disable", "PATCH", "/api/v1/links/" + link.ShortCode, map[string]interface{}{"disabled": true}, http.StatusOK},
+		{"redirect disabled", "GET", "/" + link.ShortCode, nil, http.StatusGone},
+		{"enable", "PATCH", "/api/v1/links/" + link.ShortCode, map[string]interface{}{"disabled": false}, http.StatusOK},
+		{"restore not deleted", "POST", "/api/v1/links/" + link.ShortCode + "/restore", nil, http.StatusConflict},
+		{"delete", "DELETE", "/api/v1/links/" + link.ShortCode, nil, http.StatusNoContent},
+		{"redirect deleted", "GET", "/" + link.ShortCode, nil, http.StatusNotFound},
+		{"stats deleted", "GET", "/api/v1/links/" + link.ShortCode + "/stats", nil, http.StatusNotFound},
+		{"delete twice", "DELETE", "/api/v1/links/" + link.ShortCode, nil, http.StatusNotFound},
+		{"restore", "POST", "/api/v1/links/" + link.ShortCode + "/restore", nil, http.StatusOK},
+		{"redirect restored", "GET", "/" + link.ShortCode, nil, http.StatusFound},
+		{"update nonexistent", "PATCH", "/api/v1/links/nonexistent", map[string]interface{}{"disabled": true}, http.StatusNotFound},
+		{"restore nonexistent", "POST", "/api/v1/links/nonexistent/restore", nil, http.StatusNotFound},
+	}
+
+	for _, step := range steps {
+		var body *bytes.Buffer
+		if step.body != nil {
+			payload, _ := json.Marshal(step.body)
+			body = bytes.NewBuffer(payload)
+		} else {
+			body = bytes.NewBuffer(nil)
+		}
+
+		req, err := http.NewRequest(step.method, step.path, body)
+		if err != nil {
+			t.Fatalf("%s: failed to create request: %v", step.name, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != step.expectedStatus {
+			t.Fatalf("%s: expected status code %d, got %d (%s)", step.name, step.expectedStatus, w.Code, w.Body.String())
+		}
+
+		if step.name == "redirect to new destination" && w.Header().Get("Location") != "https://example.org" {
+			t.Errorf("%s: expected location https://example.org, got %s", step.name, w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestListLinksHandler(t *testing.T) {
+	router, linkService := setupTestRouter()
+
+	for _, longURL := range []string{
+		"https://example.com/a",
+		"https://www.example.com/b",
+		"https://other.org/example.com",
+		"https://example.com/c",
+	} {
+		if _, err := linkService.CreateLink(longURL); err != nil {
+			t.Fatalf("Failed to create test link: %v", err)
+		}
+	}
+
+	listLinks := func(query string) (int, map[string]interface{}) {
+		req, err := http.NewRequest("GET", "/api/v1/links"+query, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+		}
+		return w.Code, response
+	}
+
+	t.Run("domain filter", func(t *testing.T) {
+		status, response := listLinks("?domain=example.com")
+		if status != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, status)
+		}
+		if links := response["links"].([]interface{}); len(links) != 3 {
+			t.Errorf("Expected 3 links for domain example.com, got %d", len(links))
+		}
+	})
+
+	t.Run("cursor pagination", func(t *testing.T) {
+		seen := make(map[string]bool)
+		query := "?limit=3&order=asc"
+		for pages := 0; ; pages++ {
+			if pages > 2 {
+				t.Fatalf("Pagination did not terminate")
+			}
+			status, response := listLinks(query)
+			if status != http.StatusOK {
+				t.Fatalf("Expected status code %d, got %d", http.StatusOK, status)
+			}
+			for _, item := range response["links"].([]interface{}) {
+				code := item.(map[string]interface{})["short_code"].(string)
+				if seen[code] {
+					t.Errorf("Link %s returned twice", code)
+				}
+				seen[code] = true
+			}
+			cursor, ok := response["next_cursor"].(string)
+			if !ok {
+				break
+			}
+			query = "?limit=3&order=asc&cursor=" + cursor
+		}
+		if len(seen) != 4 {
+			t.Errorf("Expected 4 links across pages, got %d", len(seen))
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"?cursor=garbage", "?sort=name", "?limit=1000", "?created_after=yesterday"} {
+			if status, _ := listLinks(query); status != http.StatusBadRequest {
+				t.Errorf("Expected status code %d for %s, got %d", http.StatusBadRequest, query, status)
+			}
+		}
+	})
+}
+
+func TestGetLinkStatsHandler(t *testing.T) {
+	router, linkService := setupTestRouter()
+
+	link, err := linkService.CreateLink("https://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		shortCode      string
+		expectedStatus int
+	}{
+		{
+			name:           "valid short code",
+			shortCode:      link.ShortCode,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid short code",
+			shortCode:      "nonexistent",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/links/"+tt.shortCode+"/stats", nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var response map[string]interface{}
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+
+				if response["short_code"] != tt.shortCode {
+					t.Errorf("Expected short_code %s, got %v", tt.shortCode, response["short_code"])
+				}
+				if response["long_url"] == nil {
+					t.Errorf("Expected long_url in response")
+				}
+				if response["total_clicks"] == nil {
+					t.Errorf("Expected total_clicks in response")
+				}
+			}
+		})
+	}
+}
+
+func TestGetLinkStatsHandler_BotClicks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockClickRepo := mocks.NewMockClickRepository()
+	linkService := services.NewLinkService(mocks.NewMockLinkRepository(), mockClickRepo)
+	router := gin.New()
+	SetupRoutes(router, linkService, 100, "http://localhost:8080", RouteOptions{})
+
+	maxClicks := 3
+	link, err := linkService.CreateLinkWithOptions("https://example.com", services.CreateLinkOptions{MaxClicks: &maxClicks})
+	if err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
+
+	for _, isBot := range []bool{false, true, true, false} {
+		mockClickRepo.CreateClick(&models.Click{LinkID: link.ID, Timestamp: time.Now(), IsBot: isBot})
+	}
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedTotal float64
+	}{
+		{name: "human clicks only by default", expectedTotal: 2},
+		{name: "include bots", query: "?include_bots=true", expectedTotal: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/links/"+link.ShortCode+"/stats"+tt.query, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, got %d (%s)", http.StatusOK, w.Code, w.Body.String())
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if response["total_clicks"] != tt.expectedTotal {
+				t.Errorf("Expected total_clicks %v, got %v", tt.expectedTotal, response["total_clicks"])
+			}
+			if response["human_clicks"] != float64(2) || response["bot_clicks"] != float64(2) {
+				t.Errorf("Expected 2 human and 2 bot clicks, got %v and %v", response["human_clicks"], response["bot_clicks"])
+			}
+			// Les clics de robots n'entament pas le budget de clics.
+			if response["remaining_clicks"] != float64(1) {
+				t.Errorf("Expected remaining_clicks 1, got %v", response["remaining_clicks"])
+			}
+		})
+	}
+
+	// Vide le channel global des événements laissés par les tests précédents.
+	for len(ClickEventsChannel) > 0 {
+		<-ClickEventsChannel
+	}
+
+	req, _ := http.NewRequest("HEAD", "/"+link.ShortCode, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusFound {
+		t.Fatalf("Expected HEAD redirect status %d, got %d", http.StatusFound, w.Code)
+	}
+	select {
+	case event := <-ClickEventsChannel:
+		if event.Method != http.MethodHead {
+			t.Errorf("Expected click event method HEAD, got %q", event.Method)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a click event for the HEAD request")
+	}
+}
+
+func TestGetLinkStatsHandler_TopReferrers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockClickRepo := mocks.NewMockClickRepository()
+	linkService := services.NewLinkService(mocks.NewMockLinkRepository(), mockClickRepo)
+	router := gin.New()
+	SetupRoutes(router, linkService, 100, "http://localhost:8080", RouteOptions{})
+
+	link, err := linkService.CreateLink("https://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
+
+	for _, host := range []string{"twitter.com", "news.ycombinator.com", "twitter.com", "", "twitter.com", "news.ycombinator.com"} {
+		mockClickRepo.CreateClick(&models.Click{LinkID: link.ID, Timestamp: time.Now(), ReferrerHost: host})
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedHosts  []string
+	}{
+		{
+			name:           "default limit",
+			expectedStatus: http.StatusOK,
+			expectedHosts:  []string{"twitter.com", "news.ycombinator.com", ""},
+		},
+		{
+			name:           "custom limit",
+			query:          "?referrers=1",
+			expectedStatus: http.StatusOK,
+			expectedHosts:  []string{"twitter.com"},
+		},
+		{
+			name:           "invalid limit",
+			query:          "?referrers=0",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/links/"+link.ShortCode+"/stats"+tt.query, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d (%s)", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			referrers := response["top_referrers"].([]interface{})
+			if len(referrers) != len(tt.expectedHosts) {
+				t.Fatalf("Expected %d referrers, got %d", len(tt.expectedHosts), len(referrers))
+			}
+			for i, referrer := range referrers {
+				if host := referrer.(map[string]interface{})["host"]; host != tt.expectedHosts[i] {
+					t.Errorf("Referrer %d: expected host %q, got %v", i, tt.expectedHosts[i], host)
+				}
+			}
+		})
+	}
+}
+
+func TestGetClickBreakdownHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockClickRepo := mocks.NewMockClickRepository()
+	linkService := services.NewLinkService(mocks.NewMockLinkRepository(), mockClickRepo)
+	clickService := services.NewClickService(mockClickRepo)
+	router := gin.New()
+	SetupRoutes(router, linkService, 100, "http://localhost:8080", RouteOptions{ClickService: clickService})
+
+	link, err := linkService.CreateLink("https://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
+
+	for _, ua := range []string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		"curl/8.4.0",
+	} {
+		if err := clickService.ProcessClickEvent(models.ClickEvent{LinkID: link.ID, Timestamp: time.Now(), UserAgent: ua}); err != nil {
+			t.Fatalf("Failed to process click event: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expected       map[string][]string
+	}{
+		{
+			name:           "all dimensions",
+			expectedStatus: http.StatusOK,
+			expected: map[string][]string{
+				"browser": {"Chrome", "Firefox", "curl"},
+				"os":      {"Linux", "Other", "Windows", "macOS"},
+				"device":  {"desktop", "bot"},
+			},
+		},
+		{
+			name:           "single dimension with limit",
+			query:          "?dimension=browser&limit=1",
+			expectedStatus: http.StatusOK,
+			expected:       map[string][]string{"browser": {"Chrome"}},
+		},
+		{
+			name:           "unknown dimension",
+			query:          "?dimension=country",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/links/"+link.ShortCode+"/clicks/breakdown"+tt.query, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d (%s)", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Breakdown map[string][]struct {
+					Value  string `json:"value"`
+					Clicks int    `json:"clicks"`
+				} `json:"breakdown"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(response.Breakdown) != len(tt.expected) {
+				t.Fatalf("Expected %d dimensions, got %d", len(tt.expected), len(response.Breakdown))
+			}
+			for dimension, values := range tt.expected {
+				got := response.Breakdown[dimension]
+				if len(got) != len(values) {
+					t.Fatalf("Dimension %s: expected %d values, got %d", dimension, len(values), len(got))
+				}
+				for i, value := range values {
+					if got[i].Value != value {
+						t.Errorf("Dimension %s, entry %d: expected %q, got %q", dimension, i, value, got[i].Value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestUniqueVisitors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockClickRepo := mocks.NewMockClickRepository()
+	visitorRepo := mocks.NewMockVisitorRepository()
+	linkService := services.NewLinkService(mocks.NewMockLinkRepository(), mockClickRepo)
+	router := gin.New()
+	SetupRoutes(router, linkService, 100, "http://localhost:8080", RouteOptions{
+		VisitorService: services.NewVisitorService(visitorRepo),
+	})
+
+	link, err := linkService.CreateLink("https://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
+
+	counter := analytics.NewVisitorCounter(visitorRepo, analytics.NewDailySalt(visitorRepo))
+	// Les visites datent de la veille et du jour : les sels des journées plus anciennes ne sont plus créés.
+	yesterday := time.Now().UTC().AddDate(0, 0, -1)
+	day := time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 10, 0, 0, 0, time.UTC)
+	visits := []struct {
+		at time.Time
+		ip string
+	}{
+		{day, "203.0.113.1"},
+		{day.Add(time.Minute), "203.0.113.1"},
+		{day.Add(time.Hour), "203.0.113.2"},
+		{day.AddDate(0, 0, 1), "203.0.113.1"},
+	}
+	for _, visit := range visits {
+		mockClickRepo.CreateClick(&models.Click{LinkID: link.ID, Timestamp: visit.at})
+		if err := counter.Record(link.ID, visit.at, visit.ip, "Firefox"); err != nil {
+			t.Fatalf("Failed to record visitor: %v", err)
+		}
+	}
+	if err := counter.Flush(); err != nil {
+		t.Fatalf("Failed to flush visitors: %v", err)
+	}
+
+	var stats map[string]interface{}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/links/"+link.ShortCode+"/stats", nil))
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if stats["unique_visitors"] != float64(3) {
+		t.Errorf("Expected 3 unique visitors (one per visitor and per day), got %v", stats["unique_visitors"])
+	}
+
+	var series struct {
+		Buckets []struct {
+			Clicks         int `json:"clicks"`
+			UniqueVisitors int `json:"unique_visitors"`
+		} `json:"buckets"`
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/links/"+link.ShortCode+"/clicks/timeseries?from="+
+		day.AddDate(0, 0, -1).Format("2006-01-02")+"&to="+day.AddDate(0, 0, 1).Format("2006-01-02")+"&interval=day", nil))
+	if err := json.Unmarshal(w.Body.Bytes(), &series); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	expected := []struct{ clicks, visitors int }{{0, 0}, {3, 2}, {1, 1}}
+	if len(series.Buckets) != len(expected) {
+		t.Fatalf("Expected %d buckets, got %d", len(expected), len(series.Buckets))
+	}
+	for i, bucket := range series.Buckets {
+		if bucket.Clicks != expected[i].clicks || bucket.UniqueVisitors != expected[i].visitors {
+			t.Errorf("Bucket %d: expected %d clicks and %d visitors, got %d and %d",
+				i, expected[i].clicks, expected[i].visitors, bucket.Clicks, bucket.UniqueVisitors)
+		}
+	}
+}
+
+func TestGetClickTimeSeriesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockClickRepo := mocks.NewMockClickRepository()
+	linkService := services.NewLinkService(mocks.NewMockLinkRepository(), mockClickRepo)
+	router := gin.New()
+	SetupRoutes(router, linkService, 100, "http://localhost:8080", RouteOptions{})
+
+	link, err := linkService.CreateLink("https://example.com")
+	if err != nil {
+		t.Fatalf("Failed to create test link: %v", err)
+	}
+
+	day := time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC)
+	for _, offset := range []time.Duration{1 * time.Hour, 2 * time.Hour, 26 * time.Hour} {
+		mockClickRepo.CreateClick(&models.Click{LinkID: link.ID, Timestamp: day.Add(offset), IPAddress: "127.0.0.1"})
+	}
+	// Comme dans /stats, les clics de robots ne sont comptés qu'avec include_bots.
+	mockClickRepo.CreateClick(&models.Click{LinkID: link.ID, Timestamp: day.Add(3 * time.Hour), IPAddress: "127.0.0.1", IsBot: true})
+
+	tests := []struct {
+		name            string
+		query           string
+		expectedStatus  int
+		expectedBuckets []float64
+	}{
+		{
+			name:            "daily buckets with gap filling",
+			query:           "?from=2025-03-11&to=2025-03-13T12:00:00Z&interval=day",
+			expectedStatus:  http.StatusOK,
+			expectedBuckets: []float64{0, 2, 1},
+		},
+		{
+			name:            "weekly bucket",
+			query:           "?from=2025-03-12&to=2025-03-13&interval=week",
+			expectedStatus:  http.StatusOK,
+			expectedBuckets: []float64{3},
+		},
+		{
+			name:            "daily buckets with bots",
+			query:           "?from=2025-03-11&to=2025-03-13T12:00:00Z&interval=day&include_bots=true",
+			expectedStatus:  http.StatusOK,
+			expectedBuckets: []float64{0, 3, 1},
+		},
+		{
+			name:           "unknown interval",
+			query:          "?interval=minute",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "from after to",
+			query:          "?from=2025-03-14&to=2025-03-12",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "too many buckets",
+			query:          "?from=2000-01-01&to=2025-01-01&interval=hour",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/links/"+link.ShortCode+"/clicks/timeseries"+tt.query, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d (%s)", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			buckets := response["buckets"].([]interface{})
+			if len(buckets) != len(tt.expectedBuckets) {
+				t.Fatalf("Expected %d buckets, got %d", len(tt.expectedBuckets), len(buckets))
+			}
+			total := 0.0
+			for i, bucket := range buckets {
+				if clicks := bucket.(map[string]interface{})["clicks"].(float64); clicks != tt.expectedBuckets[i] {
+					t.Errorf("Bucket %d: expected %v clicks, got %v", i, tt.expectedBuckets[i], clicks)
+				}
+				total += tt.expectedBuckets[i]
+			}
+			if response["total_clicks"] != total || response["human_clicks"] != float64(3) || response["bot_clicks"] != float64(1) {
+				t.Errorf("Expected %v total clicks with 3 human and 1 bot clicks, got %v, %v and %v",
+					total, response["total_clicks"], response["human_clicks"], response["bot_clicks"])
+			}
+		})
+	}
+}
+
 func TestCreateLinkRequest_Validation(t *testing.T) {
 	tests := []struct {
 		name    string
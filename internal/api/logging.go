@@ -0,0 +1,109 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader transporte l'identifiant de la requête, reçu du client ou généré.
+const RequestIDHeader = "X-Request-ID"
+
+const (
+	requestIDContextKey = "requestID"
+	loggerContextKey    = "logger"
+	// maxRequestIDLength borne la taille d'un identifiant fourni par le client.
+	maxRequestIDLength = 128
+)
+
+// RequestIDMiddleware reprend l'en-tête X-Request-ID de la requête s'il est valide, ou en génère un,
+// le renvoie dans la réponse et rend disponible un logger qui l'ajoute à chaque ligne (voir RequestLogger).
+func RequestIDMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Set(requestIDContextKey, requestID)
+		c.Set(loggerContextKey, logger.With("request_id", requestID))
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// AccessLogMiddleware journalise chaque requête une fois traitée.
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		RequestLogger(c).LogAttrs(c.Request.Context(), level, "HTTP request",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("size", c.Writer.Size()),
+		)
+	}
+}
+
+// RecoveryMiddleware transforme une panique d'un handler en réponse 500 journalisée.
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		RequestLogger(c).Error("Recovered from panic", "panic", recovered, "stack", string(debug.Stack()))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(c, "Internal server error"))
+	})
+}
+
+// RequestLogger retourne le logger de la requête, ou le logger par défaut hors RequestIDMiddleware.
+func RequestLogger(c *gin.Context) *slog.Logger {
+	if logger, ok := c.Get(loggerContextKey); ok {
+		return logger.(*slog.Logger)
+	}
+	return slog.Default()
+}
+
+// RequestID retourne l'identifiant de la requête, vide hors RequestIDMiddleware.
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDContextKey)
+}
+
+// errorResponse construit le corps d'une réponse d'erreur, qui rappelle l'identifiant de la requête.
+func errorResponse(c *gin.Context, message string) gin.H {
+	body := gin.H{"error": message}
+	if requestID := RequestID(c); requestID != "" {
+		body["request_id"] = requestID
+	}
+	return body
+}
+
+// validRequestID n'accepte que des identifiants courts et sans caractère susceptible
+// de corrompre les logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/services/mocks"
+	"github.com/gin-gonic/gin"
+)
+
+// setupLoggingRouter retourne un routeur équipé des middlewares de logs, qui écrivent en JSON dans le buffer retourné.
+func setupLoggingRouter() (*gin.Engine, *bytes.Buffer) {
+	gin.SetMode(gin.TestMode)
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	linkService := services.NewLinkService(mocks.NewMockLinkRepository(), mocks.NewMockClickRepository())
+	router := gin.New()
+	router.Use(RequestIDMiddleware(logger), AccessLogMiddleware(), RecoveryMiddleware())
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+	SetupRoutes(router, linkService, 100, "http://localhost:8080", RouteOptions{})
+	return router, &logs
+}
+
+// logLines décode les lignes de log JSON du buffer.
+func logLines(t *testing.T, logs *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to parse log line %q: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	router, _ := setupLoggingRouter()
+
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"Generated", "", ""},
+		{"Accepted", "req-42.abc_DEF", "req-42.abc_DEF"},
+		{"Invalid replaced", "bad id\nwith newline", ""},
+		{"Too long replaced", strings.Repeat("a", maxRequestIDLength+1), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/health", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			if tt.expected != "" {
+				if got != tt.expected {
+					t.Errorf("Expected request ID %q, got %q", tt.expected, got)
+				}
+				return
+			}
+			if len(got) != 32 || got == tt.header {
+				t.Errorf("Expected a generated 32-character request ID, got %q", got)
+			}
+		})
+	}
+}
+
+func TestRequestIDInErrorResponsesAndLogs(t *testing.T) {
+	router, logs := setupLoggingRouter()
+
+	req, _ := http.NewRequest("GET", "/missing", nil)
+	req.Header.Set(RequestIDHeader, "trace-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if body["request_id"] != "trace-123" {
+		t.Errorf("Expected request_id 'trace-123' in error response, got %v", body["request_id"])
+	}
+
+	lines := logLines(t, logs)
+	if len(lines) == 0 {
+		t.Fatal("Expected at least one log line")
+	}
+	var accessLogged bool
+	for _, line := range lines {
+		if line["request_id"] != "trace-123" {
+			t.Errorf("Expected every log line to carry request_id, got %v", line)
+		}
+		if line["msg"] == "HTTP request" {
+			accessLogged = true
+			if line["status"] != float64(http.StatusNotFound) || line["path"] != "/missing" {
+				t.Errorf("Unexpected access log line: %v", line)
+			}
+		}
+	}
+	if !accessLogged {
+		t.Error("Expected an access log line")
+	}
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	router, logs := setupLoggingRouter()
+
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	requestID := w.Header().Get(RequestIDHeader)
+	if body["request_id"] != requestID {
+		t.Errorf("Expected request_id %q in error response, got %v", requestID, body["request_id"])
+	}
+
+	var panicLogged bool
+	for _, line := range logLines(t, logs) {
+		if line["msg"] == "Recovered from panic" {
+			panicLogged = line["panic"] == "boom" && line["request_id"] == requestID
+		}
+	}
+	if !panicLogged {
+		t.Errorf("Expected the panic to be logged with its request ID, got %s", logs.String())
+	}
+}
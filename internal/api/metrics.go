@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// RedirectMetricsMiddleware mesure la durée et le statut des redirections, y compris celles
+// refusées par les middlewares suivants (rate limiting).
+func RedirectMetricsMiddleware(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		m.ObserveRedirect(c.Writer.Status(), time.Since(start))
+	}
+}
+
+// LinkCreationMetricsMiddleware compte les liens créés avec succès.
+func LinkCreationMetricsMiddleware(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if c.Writer.Status() == http.StatusCreated {
+			m.LinkCreated()
+		}
+	}
+}
+
+// MetricsHandler expose les métriques au format Prometheus.
+func MetricsHandler(m *metrics.Metrics) gin.HandlerFunc {
+	return gin.WrapH(m.Handler())
+}
+
+// withMetrics préfixe les handlers par le middleware de métriques si des métriques sont fournies.
+func withMetrics(m *metrics.Metrics, middleware func(*metrics.Metrics) gin.HandlerFunc, handlers []gin.HandlerFunc) []gin.HandlerFunc {
+	if m == nil {
+		return handlers
+	}
+	return append([]gin.HandlerFunc{middleware(m)}, handlers...)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/cache"
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/services/mocks"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	linkService := services.NewLinkService(mocks.NewMockLinkRepository(), mocks.NewMockClickRepository())
+	linkCache := cache.NewLinkCache(100, time.Minute, time.Minute)
+	linkService.SetCache(linkCache)
+	serviceMetrics := metrics.New(func() int { return 3 }, func() int { return 100 })
+	serviceMetrics.RegisterLinkCache(linkCache)
+	router := gin.New()
+	SetupRoutes(router, linkService, 100, "http://localhost:8080", RouteOptions{
+		Metrics:         serviceMetrics,
+		MetricsPath:     "/metrics",
+		MetricsAccounts: gin.Accounts{"prometheus": "secret"},
+	})
+
+	w := doAuthRequest(router, "POST", "/api/v1/links", "", map[string]interface{}{"long_url": "https://example.com"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected link creation to succeed, got %d", w.Code)
+	}
+	var created map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	code := created["short_code"].(string)
+	for _, path := range []string{"/" + code, "/" + code, "/missing", "/missing"} {
+		doAuthRequest(router, "GET", path, "", nil)
+	}
+
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status %d without credentials, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	req.SetBasicAuth("prometheus", "secret")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	body := w.Body.String()
+	for _, expected := range []string{
+		`urlshortener_redirect_duration_seconds_count{status="302"} 2`,
+		`urlshortener_redirect_duration_seconds_count{status="404"} 2`,
+		`urlshortener_links_created_total 1`,
+		`urlshortener_click_events_queue_depth 3`,
+		`urlshortener_click_events_queue_capacity 100`,
+		`urlshortener_link_cache_hits_total 2`,
+		`urlshortener_link_cache_negative_hits_total 1`,
+		`urlshortener_link_cache_misses_total 2`,
+		`urlshortener_link_cache_entries 2`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected metrics to contain %q", expected)
+		}
+	}
+}
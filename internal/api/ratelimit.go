@@ -0,0 +1,48 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitMiddleware limite le débit des requêtes avec le limiteur fourni. Les requêtes
+// authentifiées sont comptées par clé d'API, les autres (et toutes celles qui ne sont pas encore
+// authentifiées, avant APIKeyAuthMiddleware) par adresse IP du client.
+// Les en-têtes X-RateLimit-* sont ajoutés à chaque réponse, et Retry-After aux réponses 429.
+func RateLimitMiddleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result := limiter.Allow(rateLimitKey(c))
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			body := errorResponse(c, "Rate limit exceeded")
+			body["retry_after"] = retryAfter
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, body)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func rateLimitKey(c *gin.Context) string {
+	if key, ok := CurrentAPIKey(c); ok {
+		return fmt.Sprintf("key:%d", key.ID)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/ratelimit"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/services/mocks"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	linkService := services.NewLinkService(mocks.NewMockLinkRepository(), mocks.NewMockClickRepository())
+	router := gin.New()
+	SetupRoutes(router, linkService, 100, "http://localhost:8080", RouteOptions{
+		CreateRateLimiter:   ratelimit.NewLimiter(1, 2),
+		RedirectRateLimiter: ratelimit.NewLimiter(1, 5),
+	})
+
+	body := map[string]interface{}{"long_url": "https://example.com"}
+	for i := 0; i < 2; i++ {
+		w := doAuthRequest(router, "POST", "/api/v1/links", "", body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected request %d to succeed, got %d", i+1, w.Code)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "2" {
+			t.Errorf("Expected X-RateLimit-Limit 2, got %s", w.Header().Get("X-RateLimit-Limit"))
+		}
+	}
+
+	w := doAuthRequest(router, "POST", "/api/v1/links", "", body)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status code %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected Retry-After header on 429 response")
+	}
+	if w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("Expected X-RateLimit-Remaining 0, got %s", w.Header().Get("X-RateLimit-Remaining"))
+	}
+
+	// Les redirections disposent de leur propre budget.
+	w = doAuthRequest(router, "GET", "/nonexistent", "", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected redirect budget to be independent, got status %d", w.Code)
+	}
+}
+
+func TestRateLimitMiddleware_CreateByIPBeforeAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	linkService := services.NewLinkService(mocks.NewMockLinkRepository(), mocks.NewMockClickRepository())
+	apiKeyRepo := mocks.NewMockAPIKeyRepository()
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo)
+	router := gin.New()
+	SetupRoutes(router, linkService, 100, "http://localhost:8080", RouteOptions{
+		APIKeyService:       apiKeyService,
+		CreateRateLimiter:   ratelimit.NewLimiter(1, 1),
+		CreateIPRateLimiter: ratelimit.NewLimiter(1, 3),
+	})
+
+	validKey, _, err := apiKeyService.IssueKey("valid")
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+
+	body := map[string]interface{}{"long_url": "https://example.com"}
+	// Le budget par clé s'applique après l'authentification.
+	if w := doAuthRequest(router, "POST", "/api/v1/links", validKey, body); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d", http.StatusCreated, w.Code)
+	}
+	if w := doAuthRequest(router, "POST", "/api/v1/links", validKey, body); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the per-key budget to be exhausted, got %d", w.Code)
+	}
+
+	// Les clés invalides consomment le budget de l'IP.
+	if w := doAuthRequest(router, "POST", "/api/v1/links", "usk_0000000000000000", body); w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status code %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	// Budget de l'IP épuisé : la recherche de clé n'est plus atteinte (elle échouerait en 500).
+	apiKeyRepo.SetShouldFail(true)
+	for _, key := range []string{"usk_0000000000000000", "", validKey} {
+		w := doAuthRequest(router, "POST", "/api/v1/links", key, body)
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status code %d before authentication, got %d", http.StatusTooManyRequests, w.Code)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "3" {
+			t.Errorf("Expected the IP budget (X-RateLimit-Limit 3), got %s", w.Header().Get("X-RateLimit-Limit"))
+		}
+	}
+}
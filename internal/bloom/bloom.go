@@ -0,0 +1,96 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+// Filter est un filtre de Bloom de chaînes : MayContain ne retourne jamais false pour une chaîne
+// ajoutée, mais peut retourner true pour une chaîne qui ne l'a pas été (faux positif).
+// Il est sûr pour un usage concurrent, sans verrou.
+type Filter struct {
+	words    []atomic.Uint64
+	bits     uint64
+	hashes   uint64
+	capacity int
+	added    atomic.Int64
+}
+
+// New crée un filtre dimensionné pour capacity chaînes avec un taux de faux positifs
+// falsePositiveRate (entre 0 et 1, exclus). Au-delà de capacity chaînes, le taux réel augmente.
+func New(capacity int, falsePositiveRate float64) *Filter {
+	if capacity < 1 {
+		capacity = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+	// Dimensionnement optimal : m = -n ln(p) / (ln 2)², k = (m / n) ln 2.
+	size := uint64(math.Ceil(-float64(capacity) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	size = max((size+63)/64*64, 64)
+	hashes := uint64(max(1, math.Round(float64(size)/float64(capacity)*math.Ln2)))
+	return &Filter{
+		words:    make([]atomic.Uint64, size/64),
+		bits:     size,
+		hashes:   hashes,
+		capacity: capacity,
+	}
+}
+
+// Add ajoute s au filtre.
+func (f *Filter) Add(s string) {
+	h1, h2 := hash(s)
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % f.bits
+		f.words[bit/64].Or(1 << (bit % 64))
+	}
+	f.added.Add(1)
+}
+
+// MayContain indique si s a pu être ajouté au filtre. false garantit qu'il ne l'a pas été.
+func (f *Filter) MayContain(s string) bool {
+	h1, h2 := hash(s)
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % f.bits
+		if f.words[bit/64].Load()&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Capacity retourne le nombre de chaînes pour lequel le filtre a été dimensionné.
+func (f *Filter) Capacity() int {
+	return f.capacity
+}
+
+// Added retourne le nombre d'ajouts, doublons compris.
+func (f *Filter) Added() int64 {
+	return f.added.Load()
+}
+
+// SizeBytes retourne la taille du tableau de bits.
+func (f *Filter) SizeBytes() int {
+	return int(f.bits / 8)
+}
+
+// EstimatedFalsePositiveRate estime le taux de faux positifs à partir de la proportion de bits à 1.
+func (f *Filter) EstimatedFalsePositiveRate() float64 {
+	var set int
+	for i := range f.words {
+		set += bits.OnesCount64(f.words[i].Load())
+	}
+	return math.Pow(float64(set)/float64(f.bits), float64(f.hashes))
+}
+
+// hash retourne les deux empreintes combinées par double hachage (Kirsch-Mitzenmacher) pour
+// obtenir les positions des bits. h2 est rendu non nul pour que les positions diffèrent.
+func hash(s string) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write([]byte(s))
+	sum := h.Sum(nil)
+	return binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:]) | 1
+}
@@ -0,0 +1,55 @@
+package bloom
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFilter_NoFalseNegatives(t *testing.T) {
+	f := New(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		f.Add(fmt.Sprintf("code%d", i))
+	}
+	for i := 0; i < 1000; i++ {
+		if !f.MayContain(fmt.Sprintf("code%d", i)) {
+			t.Fatalf("Expected code%d to be reported as present", i)
+		}
+	}
+	if f.Added() != 1000 || f.Capacity() != 1000 {
+		t.Errorf("Unexpected counters: added %d, capacity %d", f.Added(), f.Capacity())
+	}
+}
+
+func TestFilter_FalsePositiveRate(t *testing.T) {
+	for _, rate := range []float64{0.01, 0.001} {
+		t.Run(fmt.Sprint(rate), func(t *testing.T) {
+			f := New(10000, rate)
+			for i := 0; i < 10000; i++ {
+				f.Add(fmt.Sprintf("code%d", i))
+			}
+
+			falsePositives := 0
+			const lookups = 100000
+			for i := 0; i < lookups; i++ {
+				if f.MayContain(fmt.Sprintf("missing%d", i)) {
+					falsePositives++
+				}
+			}
+			// Marge pour la variance de l'échantillon.
+			if observed := float64(falsePositives) / lookups; observed > 2*rate {
+				t.Errorf("Expected a false positive rate close to %v, got %v", rate, observed)
+			}
+			if estimated := f.EstimatedFalsePositiveRate(); estimated > 2*rate {
+				t.Errorf("Expected an estimated false positive rate close to %v, got %v", rate, estimated)
+			}
+		})
+	}
+}
+
+func TestNew_InvalidParameters(t *testing.T) {
+	f := New(0, 2)
+	f.Add("abc")
+	if !f.MayContain("abc") || f.Capacity() != 1 {
+		t.Errorf("Expected invalid parameters to be replaced by defaults, got capacity %d", f.Capacity())
+	}
+}
@@ -0,0 +1,250 @@
+package bloom
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+const (
+	// minCodeFilterCapacity évite les reconstructions successives tant que la base contient peu de liens.
+	minCodeFilterCapacity = 100_000
+	codeFilterPageSize    = 1000
+	// codeFilterSyncOverlap : chaque synchronisation relit les derniers IDs déjà vus, car avec des
+	// transactions concurrentes (autre instance), un lien peut être validé après un lien d'ID supérieur.
+	codeFilterSyncOverlap = 100
+	// defaultCodeFilterInterval remplace un intervalle de synchronisation nul ou négatif.
+	defaultCodeFilterInterval = 5 * time.Second
+	// codeFilterMissSyncInterval borne la fréquence des synchronisations déclenchées par des codes
+	// absents du filtre, pour qu'un balayage de codes ne se traduise pas en requêtes sur la base.
+	codeFilterMissSyncInterval = time.Second
+)
+
+// CodeFilter est un filtre de Bloom des codes courts attribués, qui permet de répondre 404 aux
+// codes inexistants sans interroger la base de données. Il est construit à partir du
+// LinkRepository, complété à chaque création de lien par le service, et synchronisé périodiquement
+// avec les liens créés par d'autres processus (CLI, autres instances). Un code absent du filtre
+// déclenche aussi une synchronisation si la dernière date de plus d'une seconde : un lien créé
+// ailleurs est reconnu au plus une seconde après sa création.
+//
+// Tant qu'il n'a pas été construit, ou pour un récepteur nil, tous les codes sont considérés
+// comme existants.
+type CodeFilter struct {
+	linkRepo          repository.LinkRepository
+	falsePositiveRate float64
+	interval          time.Duration
+	logger            *slog.Logger
+
+	filter atomic.Pointer[Filter]
+	// building reçoit aussi les ajouts pendant une reconstruction : un lien créé pendant la lecture
+	// de la base ne manque pas au nouveau filtre.
+	building atomic.Pointer[Filter]
+	rejected atomic.Uint64
+
+	// mu sérialise les synchronisations et les reconstructions.
+	mu sync.Mutex
+	// lastID est le plus grand ID de lien lu dans la base.
+	lastID uint
+	// lastSync est la date (UnixNano) de la dernière lecture réussie de la base.
+	lastSync atomic.Int64
+}
+
+// CodeFilterStats décrit l'état du filtre.
+type CodeFilterStats struct {
+	Built bool `json:"built"`
+	// Codes est le nombre de codes ajoutés au filtre.
+	Codes                      int64   `json:"codes"`
+	Capacity                   int     `json:"capacity"`
+	SizeBytes                  int     `json:"size_bytes"`
+	TargetFalsePositiveRate    float64 `json:"target_false_positive_rate"`
+	EstimatedFalsePositiveRate float64 `json:"estimated_false_positive_rate"`
+	// Rejected compte les recherches de codes inexistants écartées sans interroger la base.
+	Rejected uint64 `json:"rejected"`
+}
+
+// NewCodeFilter crée un filtre vide, à construire avec Rebuild, pour un taux de faux positifs
+// falsePositiveRate. Start le synchronise avec la base à chaque intervalle (5s s'il n'est pas positif).
+func NewCodeFilter(linkRepo repository.LinkRepository, falsePositiveRate float64, interval time.Duration, logger *slog.Logger) *CodeFilter {
+	if interval <= 0 {
+		interval = defaultCodeFilterInterval
+	}
+	return &CodeFilter{
+		linkRepo:          linkRepo,
+		falsePositiveRate: falsePositiveRate,
+		interval:          interval,
+		logger:            logger.With("component", "code_filter"),
+	}
+}
+
+// Start synchronise le filtre à chaque intervalle jusqu'à l'annulation de ctx.
+func (f *CodeFilter) Start(ctx context.Context) {
+	f.logger.Info("Démarrage de la synchronisation du filtre des codes courts", "interval", f.interval)
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := f.Sync(); err != nil {
+				f.logger.Error("Échec de la synchronisation du filtre des codes courts", "error", err)
+			}
+		case <-ctx.Done():
+			f.logger.Info("Synchronisation du filtre des codes courts arrêtée")
+			return
+		}
+	}
+}
+
+// MayContain indique si le code a pu être attribué. false garantit qu'aucun lien n'avait ce code
+// lors de la dernière synchronisation.
+func (f *CodeFilter) MayContain(code string) bool {
+	if f == nil {
+		return true
+	}
+	filter := f.filter.Load()
+	if filter == nil || filter.MayContain(code) {
+		return true
+	}
+	// Le code a pu être créé par un autre processus depuis la dernière synchronisation.
+	if time.Since(time.Unix(0, f.lastSync.Load())) >= codeFilterMissSyncInterval {
+		if !f.mu.TryLock() {
+			// Une synchronisation ou une reconstruction est en cours : la base tranche.
+			return true
+		}
+		err := f.syncLocked()
+		f.mu.Unlock()
+		if err != nil {
+			f.logger.Error("Échec de la synchronisation du filtre des codes courts", "error", err)
+			return true
+		}
+		if f.filter.Load().MayContain(code) {
+			return true
+		}
+	}
+	f.rejected.Add(1)
+	return false
+}
+
+// Add ajoute le code d'un lien qui vient d'être créé.
+func (f *CodeFilter) Add(code string) {
+	if f == nil {
+		return
+	}
+	if filter := f.filter.Load(); filter != nil {
+		addOnce(filter, code)
+	}
+	if building := f.building.Load(); building != nil {
+		addOnce(building, code)
+	}
+}
+
+// Rebuild remplace le filtre par un filtre neuf, dimensionné pour le double du nombre de codes
+// actuel, construit à partir de tous les liens de la base (y compris supprimés logiquement,
+// qui peuvent être restaurés).
+func (f *CodeFilter) Rebuild() (CodeFilterStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := f.rebuildLocked()
+	return f.Stats(), err
+}
+
+// Sync ajoute au filtre les liens créés depuis la dernière lecture de la base. Le filtre est
+// reconstruit s'il n'existe pas encore ou s'il a dépassé sa capacité.
+func (f *CodeFilter) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.syncLocked()
+}
+
+// syncLocked implémente Sync ; f.mu doit être verrouillé.
+func (f *CodeFilter) syncLocked() error {
+	filter := f.filter.Load()
+	if filter == nil {
+		return f.rebuildLocked()
+	}
+
+	lastID, err := f.load(filter, f.lastID-min(f.lastID, codeFilterSyncOverlap))
+	if err != nil {
+		return err
+	}
+	f.lastID = max(f.lastID, lastID)
+	f.lastSync.Store(time.Now().UnixNano())
+
+	if filter.Added() > int64(filter.Capacity()) {
+		f.logger.Info("Capacité du filtre des codes courts dépassée, reconstruction",
+			"codes", filter.Added(), "capacity", filter.Capacity())
+		return f.rebuildLocked()
+	}
+	return nil
+}
+
+// Stats retourne l'état du filtre.
+func (f *CodeFilter) Stats() CodeFilterStats {
+	if f == nil {
+		return CodeFilterStats{}
+	}
+	stats := CodeFilterStats{TargetFalsePositiveRate: f.falsePositiveRate, Rejected: f.rejected.Load()}
+	if filter := f.filter.Load(); filter != nil {
+		stats.Built = true
+		stats.Codes = filter.Added()
+		stats.Capacity = filter.Capacity()
+		stats.SizeBytes = filter.SizeBytes()
+		stats.EstimatedFalsePositiveRate = filter.EstimatedFalsePositiveRate()
+	}
+	return stats
+}
+
+// rebuildLocked construit et installe un nouveau filtre ; f.mu doit être verrouillé.
+func (f *CodeFilter) rebuildLocked() error {
+	start := time.Now()
+	count, err := f.linkRepo.CountShortCodes()
+	if err != nil {
+		return err
+	}
+
+	next := New(max(2*int(count), minCodeFilterCapacity), f.falsePositiveRate)
+	f.building.Store(next)
+	defer f.building.Store(nil)
+
+	lastID, err := f.load(next, 0)
+	if err != nil {
+		return err
+	}
+	f.filter.Store(next)
+	f.lastID = lastID
+	f.lastSync.Store(time.Now().UnixNano())
+
+	f.logger.Info("Filtre des codes courts construit", "codes", next.Added(), "capacity", next.Capacity(),
+		"size_bytes", next.SizeBytes(), "duration", time.Since(start))
+	return nil
+}
+
+// load ajoute à filter les codes des liens d'ID supérieur à afterID et retourne le plus grand ID lu.
+func (f *CodeFilter) load(filter *Filter, afterID uint) (uint, error) {
+	for {
+		entries, err := f.linkRepo.ListShortCodes(afterID, codeFilterPageSize)
+		if err != nil {
+			return afterID, fmt.Errorf("failed to load short codes: %w", err)
+		}
+		for _, entry := range entries {
+			addOnce(filter, entry.ShortCode)
+			afterID = entry.ID
+		}
+		if len(entries) < codeFilterPageSize {
+			return afterID, nil
+		}
+	}
+}
+
+// addOnce n'ajoute que les codes absents du filtre, pour que les relectures ne faussent pas
+// le nombre de codes ajoutés.
+func addOnce(filter *Filter, code string) {
+	if !filter.MayContain(code) {
+		filter.Add(code)
+	}
+}
@@ -0,0 +1,178 @@
+package bloom
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services/mocks"
+)
+
+func newTestCodeFilter(t *testing.T, codes ...string) (*CodeFilter, *mocks.MockLinkRepository) {
+	t.Helper()
+	repo := mocks.NewMockLinkRepository()
+	for _, code := range codes {
+		createLink(t, repo, code)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewCodeFilter(repo, 0.01, time.Minute, logger), repo
+}
+
+func createLink(t *testing.T, repo *mocks.MockLinkRepository, code string) {
+	t.Helper()
+	if err := repo.CreateLink(&models.Link{ShortCode: code, LongURL: "https://example.com/" + code}); err != nil {
+		t.Fatalf("Failed to create link: %v", err)
+	}
+}
+
+func TestCodeFilter_Rebuild(t *testing.T) {
+	f, _ := newTestCodeFilter(t, "abc", "def")
+
+	// Tant qu'il n'est pas construit, le filtre n'écarte aucun code.
+	if !f.MayContain("missing") {
+		t.Error("Expected an unbuilt filter to accept every code")
+	}
+
+	stats, err := f.Rebuild()
+	if err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+	if !stats.Built || stats.Codes != 2 || stats.Capacity != minCodeFilterCapacity || stats.TargetFalsePositiveRate != 0.01 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if !f.MayContain("abc") || !f.MayContain("def") {
+		t.Error("Expected existing codes to be accepted")
+	}
+	if f.MayContain("missing") {
+		t.Error("Expected an unknown code to be rejected")
+	}
+	if stats := f.Stats(); stats.Rejected != 1 {
+		t.Errorf("Expected one rejected lookup, got %d", stats.Rejected)
+	}
+}
+
+func TestCodeFilter_AddAndSync(t *testing.T) {
+	f, repo := newTestCodeFilter(t, "abc")
+	if _, err := f.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lien créé par le service.
+	createLink(t, repo, "fromapi")
+	f.Add("fromapi")
+	if !f.MayContain("fromapi") {
+		t.Error("Expected an added code to be accepted")
+	}
+
+	// Lien créé par un autre processus, visible après synchronisation.
+	createLink(t, repo, "fromcli")
+	if err := f.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if !f.MayContain("fromcli") {
+		t.Error("Expected a code created elsewhere to be accepted after Sync")
+	}
+	if stats := f.Stats(); stats.Codes != 3 {
+		t.Errorf("Expected re-read codes not to be counted twice, got %d codes", stats.Codes)
+	}
+}
+
+func TestCodeFilter_SyncBuildsAndGrows(t *testing.T) {
+	f, repo := newTestCodeFilter(t, "abc")
+	if err := f.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if stats := f.Stats(); !stats.Built || stats.Codes != 1 {
+		t.Fatalf("Expected Sync to build the filter, got %+v", stats)
+	}
+
+	// Au-delà de sa capacité, le filtre est reconstruit plus grand.
+	for i := 0; i < minCodeFilterCapacity; i++ {
+		f.Add(fmt.Sprintf("code%d", i))
+	}
+	for i := 0; i < codeFilterPageSize+1; i++ {
+		createLink(t, repo, fmt.Sprintf("link%d", i))
+	}
+	if err := f.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	stats := f.Stats()
+	if stats.Codes != codeFilterPageSize+2 || stats.Capacity != minCodeFilterCapacity {
+		t.Errorf("Expected the filter to be rebuilt from the database, got %+v", stats)
+	}
+	if !f.MayContain(fmt.Sprintf("link%d", codeFilterPageSize)) {
+		t.Error("Expected every page of codes to be loaded")
+	}
+}
+
+func TestCodeFilter_RebuildFailureKeepsFilter(t *testing.T) {
+	f, repo := newTestCodeFilter(t, "abc")
+	if _, err := f.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+
+	repo.SetShouldFail(true)
+	if _, err := f.Rebuild(); err == nil {
+		t.Fatal("Expected the rebuild to fail")
+	}
+	if !f.MayContain("abc") || f.MayContain("missing") {
+		t.Error("Expected the previous filter to be kept")
+	}
+}
+
+func TestCodeFilter_MissSync(t *testing.T) {
+	f, repo := newTestCodeFilter(t, "abc")
+	if _, err := f.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Juste après une synchronisation, un code absent est écarté sans relire la base.
+	createLink(t, repo, "fromcli")
+	if f.MayContain("fromcli") {
+		t.Error("Expected a code created elsewhere to be rejected until the next sync")
+	}
+
+	// Passé codeFilterMissSyncInterval, un code absent déclenche une synchronisation.
+	f.lastSync.Add(-int64(codeFilterMissSyncInterval))
+	if !f.MayContain("fromcli") {
+		t.Error("Expected a miss to sync the filter with the database")
+	}
+	f.lastSync.Add(-int64(codeFilterMissSyncInterval))
+	if f.MayContain("missing") {
+		t.Error("Expected an unknown code to be rejected after the sync")
+	}
+
+	// Si la base est inaccessible, elle tranche elle-même.
+	f.lastSync.Add(-int64(codeFilterMissSyncInterval))
+	repo.SetShouldFail(true)
+	if !f.MayContain("missing") {
+		t.Error("Expected a failed sync to accept the code")
+	}
+	if stats := f.Stats(); stats.Rejected != 2 {
+		t.Errorf("Expected two rejected lookups, got %d", stats.Rejected)
+	}
+}
+
+func TestCodeFilter_NonPositiveInterval(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	f := NewCodeFilter(mocks.NewMockLinkRepository(), 0.01, 0, logger)
+	if f.interval != defaultCodeFilterInterval {
+		t.Errorf("Expected the default interval, got %v", f.interval)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f.Start(ctx)
+}
+
+func TestCodeFilter_Nil(t *testing.T) {
+	var f *CodeFilter
+	f.Add("abc")
+	if !f.MayContain("missing") || f.Stats() != (CodeFilterStats{}) {
+		t.Error("Expected a nil filter to accept every code")
+	}
+}
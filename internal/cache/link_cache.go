@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// LinkCache est un cache LRU borné des liens, par code court, dont les entrées expirent au bout
+// d'une durée fixe. Les codes inexistants sont aussi mis en cache (cache négatif), pour que les
+// balayages de codes aléatoires n'atteignent pas la base de données.
+//
+// Il est sûr pour un usage concurrent. Toutes les méthodes acceptent un récepteur nil, qui ne
+// met rien en cache : le service peut ainsi être utilisé sans cache.
+type LinkCache struct {
+	capacity    int
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// order contient les entrées, de la plus récemment utilisée à la moins récemment utilisée.
+	order *list.List
+	// generation est incrémentée à chaque invalidation : un chargement commencé avant une
+	// invalidation n'est pas mis en cache, il pourrait être périmé.
+	generation uint64
+	stats      Stats
+	now        func() time.Time
+}
+
+type entry struct {
+	code string
+	// link vaut nil pour un code inexistant.
+	link      *models.Link
+	expiresAt time.Time
+}
+
+// Stats sont les compteurs d'utilisation du cache depuis sa création.
+type Stats struct {
+	// Hits compte les recherches servies par le cache, NegativeHits compris.
+	Hits uint64
+	// NegativeHits compte les recherches de codes inexistants servies par le cache.
+	NegativeHits uint64
+	Misses       uint64
+	// Evictions compte les entrées retirées pour respecter la capacité du cache.
+	Evictions uint64
+	Entries   int
+}
+
+// NewLinkCache crée un cache d'au plus capacity liens. Les liens trouvés sont conservés ttl,
+// les codes inexistants negativeTTL ; une durée nulle désactive la mise en cache correspondante.
+func NewLinkCache(capacity int, ttl, negativeTTL time.Duration) *LinkCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &LinkCache{
+		capacity:    capacity,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+		now:         time.Now,
+	}
+}
+
+// Get retourne le lien de code depuis le cache, ou le charge avec load et met le résultat en cache.
+// load retourne models.ErrLinkNotFound pour un code inexistant ; les autres erreurs ne sont pas mises
+// en cache. Le lien retourné est une copie, que l'appelant peut modifier.
+func (c *LinkCache) Get(code string, load func(code string) (*models.Link, error)) (*models.Link, error) {
+	if c == nil {
+		return load(code)
+	}
+
+	c.mu.Lock()
+	if elem, ok := c.entries[code]; ok {
+		e := elem.Value.(*entry)
+		if c.now().Before(e.expiresAt) {
+			c.order.MoveToFront(elem)
+			c.stats.Hits++
+			if e.link == nil {
+				c.stats.NegativeHits++
+				c.mu.Unlock()
+				return nil, models.ErrLinkNotFound
+			}
+			link := *e.link
+			c.mu.Unlock()
+			return &link, nil
+		}
+		c.removeLocked(elem)
+	}
+	c.stats.Misses++
+	generation := c.generation
+	c.mu.Unlock()
+
+	link, err := load(code)
+	switch {
+	case err == nil:
+		cached := *link
+		c.store(code, &cached, c.ttl, generation)
+	case errors.Is(err, models.ErrLinkNotFound):
+		c.store(code, nil, c.negativeTTL, generation)
+	}
+	return link, err
+}
+
+// Invalidate retire le code du cache. Elle doit être appelée après chaque modification d'un lien,
+// y compris sa création, qui rend obsolète une éventuelle entrée négative.
+func (c *LinkCache) Invalidate(code string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	if elem, ok := c.entries[code]; ok {
+		c.removeLocked(elem)
+	}
+}
+
+// Stats retourne les compteurs d'utilisation du cache.
+func (c *LinkCache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	return stats
+}
+
+func (c *LinkCache) store(code string, link *models.Link, ttl time.Duration, generation uint64) {
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return
+	}
+
+	expiresAt := c.now().Add(ttl)
+	if elem, ok := c.entries[code]; ok {
+		e := elem.Value.(*entry)
+		e.link, e.expiresAt = link, expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[code] = c.order.PushFront(&entry{code: code, link: link, expiresAt: expiresAt})
+	for c.order.Len() > c.capacity {
+		c.removeLocked(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+// removeLocked retire une entrée ; c.mu doit être verrouillé.
+func (c *LinkCache) removeLocked(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*entry).code)
+}
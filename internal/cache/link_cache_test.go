@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// fakeStore simule la base de données et compte les chargements.
+type fakeStore struct {
+	links map[string]*models.Link
+	loads int
+}
+
+func (s *fakeStore) load(code string) (*models.Link, error) {
+	s.loads++
+	link, ok := s.links[code]
+	if !ok {
+		return nil, models.ErrLinkNotFound
+	}
+	copied := *link
+	return &copied, nil
+}
+
+func newTestCache(capacity int) (*LinkCache, *time.Time) {
+	c := NewLinkCache(capacity, time.Minute, 10*time.Second)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestLinkCache_HitsAndExpiry(t *testing.T) {
+	c, now := newTestCache(10)
+	store := &fakeStore{links: map[string]*models.Link{"abc": {ID: 1, ShortCode: "abc", LongURL: "https://example.com"}}}
+
+	for i := 0; i < 3; i++ {
+		link, err := c.Get("abc", store.load)
+		if err != nil || link.LongURL != "https://example.com" {
+			t.Fatalf("Expected the link, got %v, %v", link, err)
+		}
+	}
+	if store.loads != 1 {
+		t.Errorf("Expected a single load, got %d", store.loads)
+	}
+
+	*now = now.Add(time.Minute)
+	if _, err := c.Get("abc", store.load); err != nil {
+		t.Fatal(err)
+	}
+	if store.loads != 2 {
+		t.Errorf("Expected the expired entry to be reloaded, got %d loads", store.loads)
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.NegativeHits != 0 || stats.Entries != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestLinkCache_NegativeCaching(t *testing.T) {
+	c, now := newTestCache(10)
+	store := &fakeStore{links: map[string]*models.Link{}}
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get("missing", store.load); !errors.Is(err, models.ErrLinkNotFound) {
+			t.Fatalf("Expected ErrLinkNotFound, got %v", err)
+		}
+	}
+	if store.loads != 1 {
+		t.Errorf("Expected not-found codes to be cached, got %d loads", store.loads)
+	}
+	if stats := c.Stats(); stats.NegativeHits != 2 || stats.Hits != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// Les entrées négatives expirent plus tôt que les liens trouvés.
+	*now = now.Add(10 * time.Second)
+	store.links["missing"] = &models.Link{ID: 2, ShortCode: "missing"}
+	if _, err := c.Get("missing", store.load); err != nil {
+		t.Errorf("Expected the negative entry to expire, got %v", err)
+	}
+}
+
+func TestLinkCache_ErrorsAreNotCached(t *testing.T) {
+	c, _ := newTestCache(10)
+	loads := 0
+	failing := func(code string) (*models.Link, error) {
+		loads++
+		return nil, errors.New("database unavailable")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get("abc", failing); err == nil {
+			t.Fatal("Expected the load error")
+		}
+	}
+	if loads != 2 || c.Stats().Entries != 0 {
+		t.Errorf("Expected errors not to be cached, got %d loads and %+v", loads, c.Stats())
+	}
+}
+
+func TestLinkCache_Invalidate(t *testing.T) {
+	c, _ := newTestCache(10)
+	store := &fakeStore{links: map[string]*models.Link{"abc": {ID: 1, ShortCode: "abc", LongURL: "https://example.com"}}}
+
+	if _, err := c.Get("abc", store.load); err != nil {
+		t.Fatal(err)
+	}
+	store.links["abc"].LongURL = "https://example.org"
+	c.Invalidate("abc")
+
+	link, err := c.Get("abc", store.load)
+	if err != nil || link.LongURL != "https://example.org" {
+		t.Errorf("Expected the updated link after invalidation, got %v, %v", link, err)
+	}
+
+	// Un chargement concurrent d'une invalidation n'est pas mis en cache.
+	racing := func(code string) (*models.Link, error) {
+		link, err := store.load(code)
+		c.Invalidate(code)
+		return link, err
+	}
+	c.Invalidate("abc")
+	if _, err := c.Get("abc", racing); err != nil {
+		t.Fatal(err)
+	}
+	if c.Stats().Entries != 0 {
+		t.Error("Expected a load racing an invalidation not to be cached")
+	}
+}
+
+func TestLinkCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c, _ := newTestCache(2)
+	store := &fakeStore{links: map[string]*models.Link{
+		"a": {ID: 1, ShortCode: "a"},
+		"b": {ID: 2, ShortCode: "b"},
+		"c": {ID: 3, ShortCode: "c"},
+	}}
+
+	for _, code := range []string{"a", "b", "a", "c"} {
+		if _, err := c.Get(code, store.load); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := c.Stats()
+	if stats.Entries != 2 || stats.Evictions != 1 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+
+	loads := store.loads
+	if _, err := c.Get("a", store.load); err != nil || store.loads != loads {
+		t.Error("Expected the recently used entry to be kept")
+	}
+	if _, err := c.Get("b", store.load); err != nil || store.loads != loads+1 {
+		t.Error("Expected the least recently used entry to be evicted")
+	}
+}
+
+func TestLinkCache_ReturnsCopies(t *testing.T) {
+	c, _ := newTestCache(10)
+	store := &fakeStore{links: map[string]*models.Link{"abc": {ID: 1, ShortCode: "abc", LongURL: "https://example.com"}}}
+
+	link, _ := c.Get("abc", store.load)
+	link.LongURL = "https://modified.example"
+	cached, _ := c.Get("abc", store.load)
+	if cached.LongURL != "https://example.com" {
+		t.Errorf("Expected callers not to modify the cached link, got %s", cached.LongURL)
+	}
+}
+
+func TestLinkCache_Nil(t *testing.T) {
+	var c *LinkCache
+	store := &fakeStore{links: map[string]*models.Link{"abc": {ID: 1, ShortCode: "abc"}}}
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get("abc", store.load); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Invalidate("abc")
+	if store.loads != 2 || c.Stats() != (Stats{}) {
+		t.Errorf("Expected a nil cache to load every time, got %d loads", store.loads)
+	}
+}
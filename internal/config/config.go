@@ -12,24 +12,170 @@ type Config struct {
 	Database  DatabaseConfig  `mapstructure:"database"`
 	Analytics AnalyticsConfig `mapstructure:"analytics"`
 	Monitor   MonitorConfig   `mapstructure:"monitor"`
+	Auth      AuthConfig      `mapstructure:"auth"`
+	RateLimit RateLimitConfig `mapstructure:"ratelimit"`
+	Privacy   PrivacyConfig   `mapstructure:"privacy"`
+	Retention RetentionConfig `mapstructure:"retention"`
+	Metrics   MetricsConfig   `mapstructure:"metrics"`
+	Logging   LoggingConfig   `mapstructure:"logging"`
+	Health    HealthConfig    `mapstructure:"health"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	Bloom     BloomConfig     `mapstructure:"bloom"`
+	Admin     AdminConfig     `mapstructure:"admin"`
+	ShortCode ShortCodeConfig `mapstructure:"shortcode"`
 }
 
 type ServerConfig struct {
-	Port    int    `mapstructure:"port"`
-	BaseURL string `mapstructure:"base_url"`
+	Port           int      `mapstructure:"port"`
+	BaseURL        string   `mapstructure:"base_url"`
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// ShutdownTimeoutSeconds borne la durée de l'arrêt : fin des requêtes en cours et vidage des clics.
+	ShutdownTimeoutSeconds int `mapstructure:"shutdown_timeout_seconds"`
 }
 
+// DatabaseConfig décrit la connexion à la base de données. Driver vaut sqlite, postgres ou mysql ;
+// pour SQLite, DSN vide, Name est le chemin du fichier.
 type DatabaseConfig struct {
-	Name string `mapstructure:"name"`
+	Driver string `mapstructure:"driver"`
+	Name   string `mapstructure:"name"`
+	DSN    string `mapstructure:"dsn"`
+	// Réglages du pool de connexions ; une valeur nulle conserve la valeur par défaut de database/sql.
+	MaxOpenConns           int `mapstructure:"max_open_conns"`
+	MaxIdleConns           int `mapstructure:"max_idle_conns"`
+	ConnMaxLifetimeMinutes int `mapstructure:"conn_max_lifetime_minutes"`
+	ConnMaxIdleTimeMinutes int `mapstructure:"conn_max_idle_time_minutes"`
+	// AllowPendingMigrations laisse démarrer le serveur sur un schéma en retard ou dirty.
+	AllowPendingMigrations bool `mapstructure:"allow_pending_migrations"`
 }
 
 type AnalyticsConfig struct {
 	BufferSize  int `mapstructure:"buffer_size"`
 	WorkerCount int `mapstructure:"worker_count"`
+	// BotSignatures complète la liste des fragments de User-Agent identifiant un robot.
+	BotSignatures []string `mapstructure:"bot_signatures"`
+	// VisitorFlushSeconds est l'intervalle d'enregistrement des estimations de visiteurs uniques.
+	VisitorFlushSeconds int `mapstructure:"visitor_flush_seconds"`
+	// BatchSize est le nombre maximal de clics enregistrés ensemble par un worker.
+	BatchSize int `mapstructure:"batch_size"`
+	// BatchMaxLatencyMs est le délai maximal en millisecondes avant l'enregistrement d'un lot incomplet.
+	BatchMaxLatencyMs int `mapstructure:"batch_max_latency_ms"`
+	// SpoolDir est le répertoire du spool des clics en attente ; vide, le spool est désactivé.
+	SpoolDir           string `mapstructure:"spool_dir"`
+	SpoolSegmentKB     int    `mapstructure:"spool_segment_kb"`
+	SpoolMaxMB         int    `mapstructure:"spool_max_mb"`
+	SpoolReplaySeconds int    `mapstructure:"spool_replay_seconds"`
 }
 
 type MonitorConfig struct {
-	IntervalMinutes int `mapstructure:"interval_minutes"`
+	IntervalMinutes      int `mapstructure:"interval_minutes"`
+	SweepIntervalMinutes int `mapstructure:"sweep_interval_minutes"`
+}
+
+type AuthConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// PrivacyConfig règle les données personnelles conservées pour chaque clic.
+type PrivacyConfig struct {
+	// IPMode vaut full, truncate (/24 ou /48), hash (empreinte salée chaque jour) ou drop.
+	IPMode string `mapstructure:"ip_mode"`
+}
+
+// RetentionConfig définit la durée de conservation des clics. Un ClickDays nul ou négatif
+// conserve les clics indéfiniment.
+type RetentionConfig struct {
+	ClickDays int `mapstructure:"click_days"`
+	// Mode vaut delete (suppression) ou aggregate (effacement des données personnelles).
+	Mode                 string `mapstructure:"mode"`
+	PurgeIntervalMinutes int    `mapstructure:"purge_interval_minutes"`
+}
+
+// MetricsConfig configure l'exposition des métriques Prometheus. Si Username est renseigné,
+// l'accès à Path est protégé par authentification basique.
+type MetricsConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Path     string `mapstructure:"path"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// LoggingConfig règle les logs du serveur : niveau minimal (debug, info, warn, error)
+// et format (json ou text).
+type LoggingConfig struct {
+	Level  string `mapstructure:"level"`
+	Format string `mapstructure:"format"`
+}
+
+// CacheConfig règle le cache des liens consultés par les redirections.
+type CacheConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Size est le nombre maximal de codes en cache.
+	Size       int `mapstructure:"size"`
+	TTLSeconds int `mapstructure:"ttl_seconds"`
+	// NegativeTTLSeconds est la durée de mise en cache des codes inexistants (0 : pas de cache négatif).
+	NegativeTTLSeconds int `mapstructure:"negative_ttl_seconds"`
+}
+
+// BloomConfig règle le filtre de Bloom des codes courts, qui répond 404 aux codes inexistants
+// sans interroger la base de données.
+type BloomConfig struct {
+	Enabled           bool    `mapstructure:"enabled"`
+	FalsePositiveRate float64 `mapstructure:"false_positive_rate"`
+	// SyncIntervalSeconds est l'intervalle de synchronisation périodique avec la base (5 s'il n'est
+	// pas positif). Un code inconnu du filtre déclenche en plus une synchronisation si la dernière
+	// date de plus d'une seconde : un lien créé par un autre processus peut donc recevoir un 404
+	// pendant au plus une seconde.
+	SyncIntervalSeconds int `mapstructure:"sync_interval_seconds"`
+}
+
+// AdminConfig protège les routes /admin par authentification basique. Sans Username, elles sont désactivées.
+type AdminConfig struct {
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// ShortCodeConfig règle la génération des codes courts des liens sans alias personnalisé.
+type ShortCodeConfig struct {
+	// Strategy vaut random (caractères aléatoires), sequential (ID encodé dans l'alphabet),
+	// hashids (ID encodé et masqué par le sel) ou words (mots séparés par WordSeparator).
+	Strategy string `mapstructure:"strategy"`
+	// Alphabet vaut base62 ou unambiguous (base62 sans 0, O, 1, l et I).
+	Alphabet string `mapstructure:"alphabet"`
+	// Length est la longueur des codes random, et la longueur minimale des codes sequential et hashids.
+	Length int `mapstructure:"length"`
+	// MaxLength borne l'allongement automatique des codes random.
+	MaxLength int `mapstructure:"max_length"`
+	// GrowthCollisionRate est la part de codes déjà attribués, parmi les derniers générés, à partir
+	// de laquelle les codes random et words sont allongés. 0 désactive l'allongement.
+	GrowthCollisionRate float64 `mapstructure:"growth_collision_rate"`
+	// Salt personnalise les codes hashids : sans lui, la suite des IDs peut être retrouvée.
+	Salt          string `mapstructure:"salt"`
+	Words         int    `mapstructure:"words"`
+	MaxWords      int    `mapstructure:"max_words"`
+	WordSeparator string `mapstructure:"word_separator"`
+}
+
+// HealthConfig règle la sonde de disponibilité /readyz.
+type HealthConfig struct {
+	// CheckTimeoutMs borne la durée de chaque vérification, en millisecondes.
+	CheckTimeoutMs int `mapstructure:"check_timeout_ms"`
+	// QueueSaturationPercent est le remplissage du buffer des clics au-delà duquel le service n'est plus prêt.
+	QueueSaturationPercent int `mapstructure:"queue_saturation_percent"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Create  RateLimitRule `mapstructure:"create"`
+	// CreateIP limite par IP les créations de liens avant l'authentification, clés invalides comprises.
+	CreateIP RateLimitRule `mapstructure:"create_ip"`
+	Redirect RateLimitRule `mapstructure:"redirect"`
+}
+
+// RateLimitRule définit un budget de requêtes : RequestsPerMinute en régime permanent,
+// Burst en rafale. Un RequestsPerMinute nul ou négatif désactive la règle.
+type RateLimitRule struct {
+	RequestsPerMinute int `mapstructure:"requests_per_minute"`
+	Burst             int `mapstructure:"burst"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -39,10 +185,59 @@ func LoadConfig() (*Config, error) {
 
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.base_url", "http://localhost:8080")
+	viper.SetDefault("server.shutdown_timeout_seconds", 15)
+	viper.SetDefault("database.driver", "sqlite")
 	viper.SetDefault("database.name", "url_shortener.db")
+	viper.SetDefault("database.max_open_conns", 20)
+	viper.SetDefault("database.max_idle_conns", 5)
+	viper.SetDefault("database.conn_max_lifetime_minutes", 30)
+	viper.SetDefault("database.conn_max_idle_time_minutes", 5)
+	viper.SetDefault("database.allow_pending_migrations", false)
 	viper.SetDefault("analytics.buffer_size", 1000)
 	viper.SetDefault("analytics.worker_count", 5)
+	viper.SetDefault("analytics.visitor_flush_seconds", 10)
+	viper.SetDefault("analytics.batch_size", 100)
+	viper.SetDefault("analytics.batch_max_latency_ms", 500)
+	viper.SetDefault("analytics.spool_dir", "spool")
+	viper.SetDefault("analytics.spool_segment_kb", 1024)
+	viper.SetDefault("analytics.spool_max_mb", 256)
+	viper.SetDefault("analytics.spool_replay_seconds", 5)
 	viper.SetDefault("monitor.interval_minutes", 5)
+	viper.SetDefault("monitor.sweep_interval_minutes", 1)
+	viper.SetDefault("auth.enabled", true)
+	viper.SetDefault("ratelimit.enabled", true)
+	viper.SetDefault("ratelimit.create.requests_per_minute", 30)
+	viper.SetDefault("ratelimit.create.burst", 10)
+	viper.SetDefault("ratelimit.create_ip.requests_per_minute", 60)
+	viper.SetDefault("ratelimit.create_ip.burst", 20)
+	viper.SetDefault("ratelimit.redirect.requests_per_minute", 600)
+	viper.SetDefault("ratelimit.redirect.burst", 100)
+	viper.SetDefault("privacy.ip_mode", "truncate")
+	viper.SetDefault("retention.click_days", 0)
+	viper.SetDefault("retention.mode", "delete")
+	viper.SetDefault("retention.purge_interval_minutes", 60)
+	viper.SetDefault("metrics.enabled", true)
+	viper.SetDefault("metrics.path", "/metrics")
+	viper.SetDefault("logging.level", "info")
+	viper.SetDefault("logging.format", "json")
+	viper.SetDefault("health.check_timeout_ms", 1000)
+	viper.SetDefault("health.queue_saturation_percent", 90)
+	viper.SetDefault("cache.enabled", true)
+	viper.SetDefault("cache.size", 10000)
+	viper.SetDefault("cache.ttl_seconds", 60)
+	viper.SetDefault("cache.negative_ttl_seconds", 10)
+	viper.SetDefault("bloom.enabled", true)
+	viper.SetDefault("bloom.false_positive_rate", 0.01)
+	viper.SetDefault("bloom.sync_interval_seconds", 5)
+	viper.SetDefault("shortcode.strategy", "random")
+	viper.SetDefault("shortcode.alphabet", "base62")
+	viper.SetDefault("shortcode.length", 6)
+	viper.SetDefault("shortcode.max_length", 10)
+	viper.SetDefault("shortcode.growth_collision_rate", 0.2)
+	viper.SetDefault("shortcode.salt", "")
+	viper.SetDefault("shortcode.words", 3)
+	viper.SetDefault("shortcode.max_words", 4)
+	viper.SetDefault("shortcode.word_separator", "-")
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
@@ -57,8 +252,8 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("erreur lors du démappage de la configuration: %w", err)
 	}
 
-	log.Printf("Configuration loaded: Server Port=%d, DB Name=%s, Analytics Buffer=%d, Monitor Interval=%dmin",
-		cfg.Server.Port, cfg.Database.Name, cfg.Analytics.BufferSize, cfg.Monitor.IntervalMinutes)
+	log.Printf("Configuration loaded: Server Port=%d, DB Driver=%s, Analytics Buffer=%d, Monitor Interval=%dmin",
+		cfg.Server.Port, cfg.Database.Driver, cfg.Analytics.BufferSize, cfg.Monitor.IntervalMinutes)
 
 	return &cfg, nil
 }
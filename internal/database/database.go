@@ -0,0 +1,110 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Drivers de base de données supportés.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// Open se connecte à la base de données décrite par cfg et applique les réglages du pool de connexions.
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	dialector, err := Dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s database: %w", driverName(cfg), err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get underlying SQL database: %w", err)
+	}
+
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetimeMinutes > 0 {
+		sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetimeMinutes) * time.Minute)
+	}
+	if cfg.ConnMaxIdleTimeMinutes > 0 {
+		sqlDB.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTimeMinutes) * time.Minute)
+	}
+	return db, nil
+}
+
+// Dialector retourne le dialecte GORM du driver configuré.
+func Dialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch driverName(cfg) {
+	case DriverSQLite:
+		dsn := cfg.DSN
+		if dsn == "" {
+			dsn = cfg.Name
+		}
+		if dsn == "" {
+			return nil, errors.New("database.name or database.dsn is required for sqlite")
+		}
+		return sqlite.Open(dsn), nil
+	case DriverPostgres:
+		if cfg.DSN == "" {
+			return nil, errors.New("database.dsn is required for postgres")
+		}
+		return postgres.Open(cfg.DSN), nil
+	case DriverMySQL:
+		if cfg.DSN == "" {
+			return nil, errors.New("database.dsn is required for mysql")
+		}
+		dsn, err := mysqlDSN(cfg.DSN)
+		if err != nil {
+			return nil, err
+		}
+		return mysql.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q (want %s, %s or %s)",
+			cfg.Driver, DriverSQLite, DriverPostgres, DriverMySQL)
+	}
+}
+
+// mysqlDSN impose la conversion des DATETIME en time.Time, en UTC : les rollups et les
+// comparaisons de dates reposent sur des horodatages UTC.
+func mysqlDSN(dsn string) (string, error) {
+	parsed, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("invalid mysql DSN: %w", err)
+	}
+	parsed.ParseTime = true
+	parsed.Loc = time.UTC
+	return parsed.FormatDSN(), nil
+}
+
+// driverName normalise le driver configuré ; vide, SQLite est utilisé.
+func driverName(cfg config.DatabaseConfig) string {
+	driver := strings.ToLower(strings.TrimSpace(cfg.Driver))
+	switch driver {
+	case "", "sqlite3":
+		return DriverSQLite
+	case "postgresql", "pgx":
+		return DriverPostgres
+	default:
+		return driver
+	}
+}
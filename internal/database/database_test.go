@@ -0,0 +1,77 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+func TestDialector(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.DatabaseConfig
+		expected    string
+		expectError bool
+	}{
+		{"Default driver is SQLite", config.DatabaseConfig{Name: "test.db"}, "sqlite", false},
+		{"PostgreSQL", config.DatabaseConfig{Driver: "PostgreSQL", DSN: "host=localhost dbname=test"}, "postgres", false},
+		{"MySQL", config.DatabaseConfig{Driver: "mysql", DSN: "user:pass@tcp(localhost:3306)/test"}, "mysql", false},
+		{"PostgreSQL without DSN", config.DatabaseConfig{Driver: "postgres", Name: "test.db"}, "", true},
+		{"Invalid MySQL DSN", config.DatabaseConfig{Driver: "mysql", DSN: "not a dsn"}, "", true},
+		{"Unsupported driver", config.DatabaseConfig{Driver: "oracle", DSN: "x"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialector, err := Dialector(tt.cfg)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if dialector.Name() != tt.expected {
+				t.Errorf("Expected dialect %s, got %s", tt.expected, dialector.Name())
+			}
+		})
+	}
+}
+
+func TestMySQLDSN(t *testing.T) {
+	dsn, err := mysqlDSN("user:pass@tcp(localhost:3306)/test?parseTime=false&loc=Local")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(dsn, "parseTime=true") || strings.Contains(dsn, "loc=Local") {
+		t.Errorf("Expected parseTime and the UTC location to be enforced, got %s", dsn)
+	}
+}
+
+func TestOpen_SQLitePool(t *testing.T) {
+	db, err := Open(config.DatabaseConfig{
+		Driver:                 DriverSQLite,
+		Name:                   filepath.Join(t.TempDir(), "test.db"),
+		MaxOpenConns:           3,
+		ConnMaxLifetimeMinutes: 1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	if err := sqlDB.Ping(); err != nil {
+		t.Fatalf("Failed to ping database: %v", err)
+	}
+	if max := sqlDB.Stats().MaxOpenConnections; max != 3 {
+		t.Errorf("Expected 3 max open connections, got %d", max)
+	}
+}
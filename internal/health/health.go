@@ -0,0 +1,169 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/migrations"
+)
+
+// États d'une vérification et d'un rapport.
+const (
+	StatusOK = "ok"
+	// StatusDegraded : seules des vérifications non critiques échouent, le service reste prêt.
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+)
+
+// CheckFunc vérifie une dépendance ; une erreur signale un échec. ctx expire au bout du délai du Checker.
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	critical bool
+	run      CheckFunc
+}
+
+// Checker exécute les vérifications de disponibilité du service. Un Checker nil n'a aucune
+// vérification et rapporte toujours StatusOK.
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+}
+
+// NewChecker crée un Checker dont chaque vérification est interrompue au bout de timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register ajoute une vérification. L'échec d'une vérification critique rend le service indisponible ;
+// celui d'une vérification non critique le signale seulement comme dégradé.
+func (c *Checker) Register(name string, critical bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, critical: critical, run: fn})
+}
+
+// CheckResult est le résultat d'une vérification.
+type CheckResult struct {
+	Status     string  `json:"status"`
+	Critical   bool    `json:"critical"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// Report regroupe les résultats de toutes les vérifications, par nom.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Ready indique si aucune vérification critique n'a échoué.
+func (r Report) Ready() bool {
+	return r.Status != StatusFail
+}
+
+// Run exécute toutes les vérifications en parallèle.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult)}
+	if c == nil {
+		return report
+	}
+
+	results := make([]CheckResult, len(c.checks))
+	var wg sync.WaitGroup
+	for i, chk := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.runCheck(ctx, chk)
+		}()
+	}
+	wg.Wait()
+
+	for i, chk := range c.checks {
+		result := results[i]
+		report.Checks[chk.name] = result
+		if result.Status == StatusOK {
+			continue
+		}
+		if chk.critical {
+			report.Status = StatusFail
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (c *Checker) runCheck(ctx context.Context, chk check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() { errCh <- chk.run(ctx) }()
+
+	// Une vérification qui ignore ctx ne bloque pas le rapport au-delà du délai.
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Status:     StatusOK,
+		Critical:   chk.critical,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Database vérifie que la base de données répond.
+func Database(db *sql.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// Migrations vérifie que toutes les migrations ont été appliquées et qu'aucune n'a été interrompue.
+func Migrations(migrator *migrations.Migrator) CheckFunc {
+	return migrator.Check
+}
+
+// QueueSaturation échoue quand la file des événements de clic est remplie à plus de maxRatio
+// (entre 0 et 1) : les workers ne suivent plus ou sont arrêtés.
+func QueueSaturation(depth, capacity func() int, maxRatio float64) CheckFunc {
+	return func(ctx context.Context) error {
+		d, c := depth(), capacity()
+		if c == 0 {
+			return nil
+		}
+		if ratio := float64(d) / float64(c); ratio > maxRatio {
+			return fmt.Errorf("click queue is %.0f%% full (%d/%d)", ratio*100, d, c)
+		}
+		return nil
+	}
+}
+
+// Heartbeat échoue quand le dernier battement retourné par last date de plus de maxAge,
+// ou si aucun battement n'a encore été émis.
+func Heartbeat(last func() time.Time, maxAge time.Duration) CheckFunc {
+	return func(ctx context.Context) error {
+		beat := last()
+		if beat.IsZero() {
+			return errors.New("no heartbeat yet")
+		}
+		if age := time.Since(beat); age > maxAge {
+			return fmt.Errorf("last heartbeat %v ago exceeds %v", age.Truncate(time.Second), maxAge)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,129 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ok(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("unreachable") }
+
+func TestChecker_Run(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(c *Checker)
+		expected string
+	}{
+		{"All checks pass", func(c *Checker) {
+			c.Register("database", true, ok)
+			c.Register("monitor", false, ok)
+		}, StatusOK},
+		{"Non-critical failure", func(c *Checker) {
+			c.Register("database", true, ok)
+			c.Register("monitor", false, failing)
+		}, StatusDegraded},
+		{"Critical failure", func(c *Checker) {
+			c.Register("database", true, failing)
+			c.Register("monitor", false, failing)
+		}, StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(time.Second)
+			tt.register(checker)
+			report := checker.Run(context.Background())
+			if report.Status != tt.expected {
+				t.Errorf("Expected status %s, got %s", tt.expected, report.Status)
+			}
+			if report.Ready() != (tt.expected != StatusFail) {
+				t.Errorf("Unexpected readiness %v for status %s", report.Ready(), report.Status)
+			}
+			if len(report.Checks) != 2 {
+				t.Errorf("Expected 2 check results, got %d", len(report.Checks))
+			}
+		})
+	}
+}
+
+func TestChecker_RunReportsErrors(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Register("database", true, failing)
+	report := checker.Run(context.Background())
+
+	result := report.Checks["database"]
+	if result.Status != StatusFail || !result.Critical || result.Error != "unreachable" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestChecker_RunTimesOut(t *testing.T) {
+	checker := NewChecker(20 * time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+	// La vérification ignore son contexte : le rapport ne doit pas l'attendre.
+	checker.Register("stuck", true, func(context.Context) error {
+		<-release
+		return nil
+	})
+
+	start := time.Now()
+	report := checker.Run(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected the report to be returned after the timeout, took %v", elapsed)
+	}
+	result := report.Checks["stuck"]
+	if result.Status != StatusFail || !strings.Contains(result.Error, "deadline") {
+		t.Errorf("Expected a deadline failure, got %+v", result)
+	}
+}
+
+func TestChecker_Nil(t *testing.T) {
+	var checker *Checker
+	report := checker.Run(context.Background())
+	if report.Status != StatusOK || !report.Ready() {
+		t.Errorf("Expected a nil checker to report ready, got %+v", report)
+	}
+}
+
+func TestQueueSaturation(t *testing.T) {
+	depth := 0
+	check := QueueSaturation(func() int { return depth }, func() int { return 100 }, 0.9)
+
+	for _, d := range []int{0, 50, 90} {
+		depth = d
+		if err := check(context.Background()); err != nil {
+			t.Errorf("Expected depth %d to pass, got %v", d, err)
+		}
+	}
+	depth = 91
+	if err := check(context.Background()); err == nil {
+		t.Error("Expected a saturated queue to fail")
+	}
+
+	unbuffered := QueueSaturation(func() int { return 0 }, func() int { return 0 }, 0.9)
+	if err := unbuffered(context.Background()); err != nil {
+		t.Errorf("Expected an unbuffered queue to pass, got %v", err)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	var last time.Time
+	check := Heartbeat(func() time.Time { return last }, time.Minute)
+
+	if err := check(context.Background()); err == nil {
+		t.Error("Expected a missing heartbeat to fail")
+	}
+	last = time.Now().Add(-30 * time.Second)
+	if err := check(context.Background()); err != nil {
+		t.Errorf("Expected a fresh heartbeat to pass, got %v", err)
+	}
+	last = time.Now().Add(-2 * time.Minute)
+	if err := check(context.Background()); err == nil {
+		t.Error("Expected a stale heartbeat to fail")
+	}
+}
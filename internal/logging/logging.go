@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+// Formats de sortie des logs.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New construit le logger décrit par cfg, qui écrit sur w.
+func New(cfg config.LoggingConfig, w io.Writer) (*slog.Logger, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch strings.ToLower(cfg.Format) {
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("unsupported log format %q (want %s or %s)", cfg.Format, FormatJSON, FormatText)
+	}
+}
+
+// ParseLevel convertit un niveau (debug, info, warn ou error) en slog.Level.
+func ParseLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("unsupported log level %q (want debug, info, warn or error)", level)
+	}
+	return l, nil
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(config.LoggingConfig{Level: "warn", Format: "json"}, &buf)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.Info("ignored")
+	logger.Warn("kept", "short_code", "abc")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a single JSON log line, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "kept" || entry["level"] != "WARN" || entry["short_code"] != "abc" {
+		t.Errorf("Unexpected log entry: %v", entry)
+	}
+
+	buf.Reset()
+	logger, err = New(config.LoggingConfig{Level: "DEBUG", Format: "text"}, &buf)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.Debug("details", "attempt", 2)
+	if got := buf.String(); !strings.Contains(got, "level=DEBUG") || !strings.Contains(got, "attempt=2") {
+		t.Errorf("Unexpected text log line: %q", got)
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	for _, cfg := range []config.LoggingConfig{
+		{Level: "verbose", Format: "json"},
+		{Level: "info", Format: "xml"},
+	} {
+		if _, err := New(cfg, &bytes.Buffer{}); err == nil {
+			t.Errorf("Expected an error for %+v", cfg)
+		}
+	}
+}
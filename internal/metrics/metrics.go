@@ -0,0 +1,241 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/bloom"
+	"github.com/axellelanca/urlshortener/internal/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "urlshortener"
+
+// Raisons pour lesquelles un événement de clic est perdu.
+const (
+	DropQueueFull    = "queue_full"
+	DropInsertFailed = "insert_failed"
+	// DropShutdown compte les clics des redirections servies après la fermeture du pipeline.
+	DropShutdown = "shutdown"
+)
+
+// Metrics regroupe les métriques Prometheus du service, enregistrées dans un registre dédié.
+// Toutes les méthodes acceptent un récepteur nil, qui n'enregistre rien : les composants
+// peuvent ainsi être utilisés sans métriques.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	redirectDuration *prometheus.HistogramVec
+	linksCreated     prometheus.Counter
+	clickEventsDrop  *prometheus.CounterVec
+	clickEventsSpool prometheus.Counter
+	clicksSaved      prometheus.Counter
+	insertDuration   prometheus.Histogram
+	insertErrors     prometheus.Counter
+	urlCheckDuration *prometheus.HistogramVec
+	monitoredLinks   *prometheus.GaugeVec
+}
+
+// New crée les métriques du service. queueDepth et queueCapacity sont lus à chaque collecte
+// pour exposer le remplissage du channel des événements de clic.
+func New(queueDepth, queueCapacity func() int) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		redirectDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "redirect_duration_seconds",
+			Help:      "Durée de traitement des redirections, par code de statut HTTP.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"status"}),
+		linksCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "links_created_total",
+			Help:      "Nombre de liens courts créés via l'API.",
+		}),
+		clickEventsDrop: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "click_events_dropped_total",
+			Help:      "Nombre d'événements de clic perdus, par raison.",
+		}, []string{"reason"}),
+		clickEventsSpool: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "click_events_spooled_total",
+			Help:      "Nombre d'événements de clic conservés dans le spool sur disque.",
+		}),
+		clicksSaved: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "clicks_saved_total",
+			Help:      "Nombre de clics enregistrés en base par les workers.",
+		}),
+		insertDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "click_batch_insert_duration_seconds",
+			Help:      "Durée des tentatives d'enregistrement d'un lot de clics.",
+			Buckets:   prometheus.ExponentialBuckets(.001, 2, 14),
+		}),
+		insertErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "click_batch_insert_errors_total",
+			Help:      "Nombre de tentatives d'enregistrement d'un lot de clics en échec.",
+		}),
+		urlCheckDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "url_check_duration_seconds",
+			Help:      "Durée des vérifications d'accessibilité des URLs longues, par résultat.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"result"}),
+		monitoredLinks: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "monitored_links",
+			Help:      "Nombre de liens actifs par état lors de la dernière vérification du moniteur.",
+		}, []string{"state"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.redirectDuration, m.linksCreated, m.clickEventsDrop, m.clickEventsSpool, m.clicksSaved,
+		m.insertDuration, m.insertErrors, m.urlCheckDuration, m.monitoredLinks,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "click_events_queue_depth",
+			Help:      "Nombre d'événements de clic en attente dans le channel des workers.",
+		}, func() float64 { return float64(queueDepth()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "click_events_queue_capacity",
+			Help:      "Capacité du channel des événements de clic.",
+		}, func() float64 { return float64(queueCapacity()) }),
+	)
+	return m
+}
+
+// RegisterLinkCache expose les compteurs du cache des redirections.
+func (m *Metrics) RegisterLinkCache(linkCache *cache.LinkCache) {
+	if m == nil {
+		return
+	}
+	counter := func(name, help string, value func(cache.Stats) uint64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return float64(value(linkCache.Stats())) })
+	}
+	m.registry.MustRegister(
+		counter("link_cache_hits_total", "Nombre de recherches de liens servies par le cache, codes inexistants compris.",
+			func(s cache.Stats) uint64 { return s.Hits }),
+		counter("link_cache_negative_hits_total", "Nombre de recherches de codes inexistants servies par le cache.",
+			func(s cache.Stats) uint64 { return s.NegativeHits }),
+		counter("link_cache_misses_total", "Nombre de recherches de liens transmises à la base de données.",
+			func(s cache.Stats) uint64 { return s.Misses }),
+		counter("link_cache_evictions_total", "Nombre d'entrées retirées du cache pour respecter sa capacité.",
+			func(s cache.Stats) uint64 { return s.Evictions }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "link_cache_entries",
+			Help:      "Nombre d'entrées dans le cache des redirections.",
+		}, func() float64 { return float64(linkCache.Stats().Entries) }),
+	)
+}
+
+// RegisterCodeFilter expose l'état du filtre de Bloom des codes courts.
+func (m *Metrics) RegisterCodeFilter(codeFilter *bloom.CodeFilter) {
+	if m == nil {
+		return
+	}
+	m.registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "code_filter_rejected_total",
+			Help:      "Nombre de recherches de codes inexistants écartées par le filtre de Bloom sans interroger la base.",
+		}, func() float64 { return float64(codeFilter.Stats().Rejected) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "code_filter_codes",
+			Help:      "Nombre de codes courts dans le filtre de Bloom.",
+		}, func() float64 { return float64(codeFilter.Stats().Codes) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "code_filter_estimated_false_positive_rate",
+			Help:      "Taux de faux positifs estimé du filtre de Bloom.",
+		}, func() float64 { return codeFilter.Stats().EstimatedFalsePositiveRate }),
+	)
+}
+
+// Handler sert les métriques au format d'exposition Prometheus.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRedirect enregistre une redirection servie avec le statut donné.
+func (m *Metrics) ObserveRedirect(status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.redirectDuration.WithLabelValues(strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// LinkCreated compte une création de lien.
+func (m *Metrics) LinkCreated() {
+	if m == nil {
+		return
+	}
+	m.linksCreated.Inc()
+}
+
+// ClickEventsDropped compte n événements de clic perdus pour la raison donnée.
+func (m *Metrics) ClickEventsDropped(reason string, n int) {
+	if m == nil || n <= 0 {
+		return
+	}
+	m.clickEventsDrop.WithLabelValues(reason).Add(float64(n))
+}
+
+// ClickEventsSpooled compte n événements de clic conservés dans le spool.
+func (m *Metrics) ClickEventsSpooled(n int) {
+	if m == nil || n <= 0 {
+		return
+	}
+	m.clickEventsSpool.Add(float64(n))
+}
+
+// ObserveClickInsert enregistre une tentative d'enregistrement d'un lot de size clics.
+func (m *Metrics) ObserveClickInsert(size int, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.insertDuration.Observe(duration.Seconds())
+	if err != nil {
+		m.insertErrors.Inc()
+		return
+	}
+	m.clicksSaved.Add(float64(size))
+}
+
+// ObserveURLCheck enregistre une vérification d'accessibilité d'URL.
+func (m *Metrics) ObserveURLCheck(accessible bool, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.urlCheckDuration.WithLabelValues(accessibilityLabel(accessible)).Observe(duration.Seconds())
+}
+
+// SetMonitoredLinks publie le résultat d'un passage complet du moniteur d'URLs.
+func (m *Metrics) SetMonitoredLinks(accessible, inaccessible int) {
+	if m == nil {
+		return
+	}
+	m.monitoredLinks.WithLabelValues(accessibilityLabel(true)).Set(float64(accessible))
+	m.monitoredLinks.WithLabelValues(accessibilityLabel(false)).Set(float64(inaccessible))
+}
+
+func accessibilityLabel(accessible bool) string {
+	if accessible {
+		return "accessible"
+	}
+	return "inaccessible"
+}
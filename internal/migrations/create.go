@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create écrit les fichiers vides d'une nouvelle migration pour chaque driver, dans dir/<driver>,
+// avec la version suivant la plus haute version existante. Retourne les chemins créés.
+func Create(dir, name string) ([]string, error) {
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, errors.New("invalid migration name")
+	}
+
+	var latest int64
+	for _, driver := range Drivers {
+		migrations, err := Load(os.DirFS(filepath.Join(dir, driver)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for _, migration := range migrations {
+			latest = max(latest, migration.Version)
+		}
+	}
+	migration := Migration{Version: latest + 1, Name: name}
+
+	var paths []string
+	for _, driver := range Drivers {
+		driverDir := filepath.Join(dir, driver)
+		if err := os.MkdirAll(driverDir, 0o755); err != nil {
+			return paths, fmt.Errorf("failed to create migrations directory: %w", err)
+		}
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(driverDir, fmt.Sprintf("%s.%s.sql", migration.ID(), direction))
+			header := fmt.Sprintf("-- %s (%s, %s)\n", migration.ID(), driver, direction)
+			file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+			if err == nil {
+				_, err = file.WriteString(header)
+				if cerr := file.Close(); err == nil {
+					err = cerr
+				}
+			}
+			if err != nil {
+				return paths, fmt.Errorf("failed to create migration file: %w", err)
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,450 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// files contient les migrations de chaque driver, dans sql/<driver>/NNNN_nom.up.sql et NNNN_nom.down.sql.
+//
+//go:embed sql
+var files embed.FS
+
+// BaselineVersion est la migration qui crée le schéma initial, celui que produisaient les
+// migrations automatiques de GORM avant l'introduction des migrations versionnées.
+const BaselineVersion = 1
+
+// Drivers liste les dialectes pour lesquels chaque migration doit être écrite.
+var Drivers = []string{"sqlite", "postgres", "mysql"}
+
+// ErrPendingMigrations signale des migrations embarquées qui n'ont pas encore été appliquées.
+var ErrPendingMigrations = errors.New("database has pending migrations, run the migrate up command")
+
+// ErrDirtySchema signale une migration interrompue : le schéma est dans un état intermédiaire
+// qu'il faut corriger à la main avant d'utiliser migrate force.
+var ErrDirtySchema = errors.New("database schema is dirty after a failed migration, fix it then run the migrate force command")
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration est une évolution du schéma et son inverse.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// ID retourne l'identifiant de la migration, tel que dans le nom de ses fichiers.
+func (m Migration) ID() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// MigrationStatus décrit l'état d'une migration dans la base.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	Dirty     bool
+	AppliedAt *time.Time
+	// Unknown : la migration est enregistrée dans la base mais absente de ce binaire,
+	// qui est plus ancien que le schéma.
+	Unknown bool
+}
+
+// schemaMigration est l'enregistrement d'une migration appliquée.
+type schemaMigration struct {
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255;not null"`
+	Dirty     bool   `gorm:"not null;default:false"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator applique les migrations d'un driver à une base et tient à jour la table schema_migrations.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	// transactional : le driver annule les changements de schéma d'une transaction échouée.
+	// Sans cela (MySQL), une migration en cours est marquée dirty jusqu'à sa fin.
+	transactional bool
+}
+
+// New crée un Migrator pour les migrations embarquées du driver de db.
+func New(db *gorm.DB) (*Migrator, error) {
+	driver := db.Dialector.Name()
+	fsys, err := fs.Sub(files, "sql/"+driver)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for database driver %q: %w", driver, err)
+	}
+	return NewWithFS(db, fsys)
+}
+
+// NewWithFS crée un Migrator pour les migrations de la racine de fsys.
+func NewWithFS(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:            db,
+		migrations:    migrations,
+		transactional: db.Dialector.Name() != "mysql",
+	}, nil
+}
+
+// Load lit les migrations de la racine de fsys, triées par version. Chaque migration doit
+// avoir un fichier up et un fichier down.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			return nil, fmt.Errorf("migration %s needs both an up and a down file", migration.ID())
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrations retourne les migrations connues, triées par version.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Status retourne l'état de chaque migration connue, suivi des migrations enregistrées
+// dans la base mais inconnues de ce binaire.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	known := make(map[int64]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied, status.Dirty, status.AppliedAt = true, record.Dirty, &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	var unknown []MigrationStatus
+	for version, record := range applied {
+		if known[version] {
+			continue
+		}
+		appliedAt := record.AppliedAt
+		unknown = append(unknown, MigrationStatus{
+			Version: version, Name: record.Name, Applied: true, Dirty: record.Dirty, AppliedAt: &appliedAt, Unknown: true,
+		})
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Version < unknown[j].Version })
+	return append(statuses, unknown...), nil
+}
+
+// Check retourne ErrDirtySchema si une migration a été interrompue, ErrPendingMigrations s'il
+// reste des migrations à appliquer, et nil si le schéma est à jour. La base n'est pas modifiée.
+func (m *Migrator) Check(ctx context.Context) error {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	for _, record := range applied {
+		if record.Dirty {
+			return fmt.Errorf("%w: migration %04d_%s", ErrDirtySchema, record.Version, record.Name)
+		}
+	}
+
+	var pending []string
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration.ID())
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s", ErrPendingMigrations, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// NeedsBaseline indique si la base a été créée par les migrations automatiques de GORM,
+// avant les migrations versionnées : elle contient des tables mais pas schema_migrations.
+func (m *Migrator) NeedsBaseline() bool {
+	migrator := m.db.Migrator()
+	return !migrator.HasTable(&schemaMigration{}) && migrator.HasTable("links")
+}
+
+// Up applique les migrations en attente, dans l'ordre des versions, et retourne celles qui ont été appliquées.
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	applied, err := m.appliedClean()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.apply(migration, true); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down annule les n dernières migrations appliquées, de la plus récente à la plus ancienne,
+// et retourne celles qui ont été annulées.
+func (m *Migrator) Down(n int) ([]Migration, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid number of migrations to roll back: %d", n)
+	}
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	applied, err := m.appliedClean()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]int64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	var done []Migration
+	for _, version := range versions {
+		if len(done) == n {
+			break
+		}
+		migration, ok := m.find(version)
+		if !ok {
+			return done, fmt.Errorf("cannot roll back migration %04d_%s: unknown to this binary", version, applied[version].Name)
+		}
+		if err := m.apply(migration, false); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Force enregistre le schéma comme étant exactement à la version donnée, sans exécuter de SQL :
+// les migrations connues jusqu'à version sont marquées appliquées, les suivantes retirées, et
+// l'état dirty est effacé. version 0 correspond à une base vide.
+func (m *Migrator) Force(version int64) error {
+	if version != 0 {
+		if _, ok := m.find(version); !ok {
+			return fmt.Errorf("unknown migration version %d", version)
+		}
+	}
+	if err := m.ensureTable(); err != nil {
+		return err
+	}
+
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("version > ? OR dirty = ?", version, true).Delete(&schemaMigration{}).Error; err != nil {
+			return fmt.Errorf("failed to reset migration records: %w", err)
+		}
+		applied, err := m.applied(tx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := tx.Create(m.record(migration, false)).Error; err != nil {
+				return fmt.Errorf("failed to record migration %s: %w", migration.ID(), err)
+			}
+		}
+		return nil
+	})
+}
+
+// apply exécute une migration dans un sens et met à jour son enregistrement.
+func (m *Migrator) apply(migration Migration, up bool) error {
+	direction, script := "up", migration.Up
+	if !up {
+		direction, script = "down", migration.Down
+	}
+	run := func(tx *gorm.DB) error {
+		for _, statement := range splitStatements(script) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("migration %s (%s) failed: %w", migration.ID(), direction, err)
+			}
+		}
+		return nil
+	}
+
+	if m.transactional {
+		return m.db.Transaction(func(tx *gorm.DB) error {
+			if err := run(tx); err != nil {
+				return err
+			}
+			if up {
+				return tx.Create(m.record(migration, false)).Error
+			}
+			return tx.Delete(&schemaMigration{Version: migration.Version}).Error
+		})
+	}
+
+	// Les changements de schéma ne sont pas annulables : la migration reste dirty si elle échoue.
+	var err error
+	if up {
+		err = m.db.Create(m.record(migration, true)).Error
+	} else {
+		err = m.db.Model(&schemaMigration{Version: migration.Version}).Update("dirty", true).Error
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", migration.ID(), err)
+	}
+	if err := run(m.db); err != nil {
+		return err
+	}
+	if up {
+		err = m.db.Model(&schemaMigration{Version: migration.Version}).Update("dirty", false).Error
+	} else {
+		err = m.db.Delete(&schemaMigration{Version: migration.Version}).Error
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", migration.ID(), err)
+	}
+	return nil
+}
+
+func (m *Migrator) record(migration Migration, dirty bool) *schemaMigration {
+	return &schemaMigration{
+		Version:   migration.Version,
+		Name:      migration.Name,
+		Dirty:     dirty,
+		AppliedAt: time.Now().UTC(),
+	}
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+func (m *Migrator) ensureTable() error {
+	if err := m.db.AutoMigrate(&schemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// applied retourne les migrations enregistrées, par version ; aucune si la table n'existe pas encore.
+func (m *Migrator) applied(db *gorm.DB) (map[int64]schemaMigration, error) {
+	applied := make(map[int64]schemaMigration)
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return applied, nil
+	}
+	var records []schemaMigration
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// appliedClean retourne les migrations enregistrées, ou ErrDirtySchema si l'une d'elles a été interrompue.
+func (m *Migrator) appliedClean() (map[int64]schemaMigration, error) {
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range applied {
+		if record.Dirty {
+			return nil, fmt.Errorf("%w: migration %04d_%s", ErrDirtySchema, record.Version, record.Name)
+		}
+	}
+	return applied, nil
+}
+
+// splitStatements découpe un script en instructions : une instruction se termine par un
+// point-virgule en fin de ligne. Les lignes de commentaire sont ignorées.
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
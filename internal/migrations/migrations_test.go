@@ -0,0 +1,331 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Comme ceux des repositories, ces tests s'exécutent aussi sur PostgreSQL et MySQL quand
+// URLSHORTENER_TEST_POSTGRES_DSN ou URLSHORTENER_TEST_MYSQL_DSN désignent une base jetable.
+var testDatabaseDSNEnv = map[string]string{
+	database.DriverPostgres: "URLSHORTENER_TEST_POSTGRES_DSN",
+	database.DriverMySQL:    "URLSHORTENER_TEST_MYSQL_DSN",
+}
+
+// forEachDatabase exécute test dans un sous-test par base de données disponible, sur une base vide.
+func forEachDatabase(t *testing.T, test func(t *testing.T, db *gorm.DB)) {
+	t.Helper()
+	t.Run(database.DriverSQLite, func(t *testing.T) {
+		test(t, openTestDB(t, config.DatabaseConfig{
+			Driver: database.DriverSQLite,
+			Name:   filepath.Join(t.TempDir(), "test.db"),
+		}))
+	})
+	for _, driver := range []string{database.DriverPostgres, database.DriverMySQL} {
+		t.Run(driver, func(t *testing.T) {
+			dsn := os.Getenv(testDatabaseDSNEnv[driver])
+			if dsn == "" {
+				t.Skipf("%s not set", testDatabaseDSNEnv[driver])
+			}
+			db := openTestDB(t, config.DatabaseConfig{Driver: driver, DSN: dsn})
+			tables := append(models.AllModels(), &schemaMigration{})
+			for i := len(tables) - 1; i >= 0; i-- {
+				if err := db.Migrator().DropTable(tables[i]); err != nil {
+					t.Fatalf("Failed to reset database: %v", err)
+				}
+			}
+			test(t, db)
+		})
+	}
+}
+
+func openTestDB(t *testing.T, cfg config.DatabaseConfig) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db = db.Session(&gorm.Session{Logger: logger.Discard})
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func mustNew(t *testing.T, db *gorm.DB) *Migrator {
+	t.Helper()
+	migrator, err := New(db)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	return migrator
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	var reference []Migration
+	for _, driver := range Drivers {
+		fsys, err := fs.Sub(files, "sql/"+driver)
+		if err != nil {
+			t.Fatal(err)
+		}
+		migrations, err := Load(fsys)
+		if err != nil {
+			t.Fatalf("Failed to load %s migrations: %v", driver, err)
+		}
+		if len(migrations) == 0 || migrations[0].Version != BaselineVersion {
+			t.Fatalf("Expected %s migrations to start at the baseline, got %v", driver, migrations)
+		}
+		for i := range migrations {
+			migrations[i].Up, migrations[i].Down = "", ""
+		}
+		if reference == nil {
+			reference = migrations
+		} else if !reflect.DeepEqual(migrations, reference) {
+			t.Errorf("Expected the same migrations for every driver, got %v for %s and %v for %s",
+				migrations, driver, reference, Drivers[0])
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{
+		"0002_add_tags.up.sql":   {Data: []byte("CREATE TABLE tags (id integer);")},
+		"0002_add_tags.down.sql": {Data: []byte("DROP TABLE tags;")},
+		"0001_init.up.sql":       {Data: []byte("CREATE TABLE a (id integer);")},
+		"0001_init.down.sql":     {Data: []byte("DROP TABLE a;")},
+		"README.md":              {Data: []byte("ignored")},
+	})
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].ID() != "0001_init" || migrations[1].ID() != "0002_add_tags" {
+		t.Fatalf("Expected migrations sorted by version, got %v", migrations)
+	}
+
+	invalid := map[string]fstest.MapFS{
+		"missing down": {
+			"0001_init.up.sql": {Data: []byte("CREATE TABLE a (id integer);")},
+		},
+		"duplicate version": {
+			"0001_init.up.sql":    {Data: []byte("CREATE TABLE a (id integer);")},
+			"0001_init.down.sql":  {Data: []byte("DROP TABLE a;")},
+			"0001_other.up.sql":   {Data: []byte("CREATE TABLE b (id integer);")},
+			"0001_other.down.sql": {Data: []byte("DROP TABLE b;")},
+		},
+	}
+	for name, fsys := range invalid {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- commentaire
+CREATE TABLE a (
+    id integer
+);
+
+CREATE INDEX idx_a ON a(id);
+INSERT INTO a VALUES (1)`
+	expected := []string{
+		"CREATE TABLE a (\n    id integer\n);",
+		"CREATE INDEX idx_a ON a(id);",
+		"INSERT INTO a VALUES (1)",
+	}
+	if got := splitStatements(script); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *gorm.DB) {
+		migrator := mustNew(t, db)
+		ctx := context.Background()
+
+		if err := migrator.Check(ctx); !errors.Is(err, ErrPendingMigrations) {
+			t.Fatalf("Expected pending migrations on an empty database, got %v", err)
+		}
+		if migrator.NeedsBaseline() {
+			t.Error("Expected an empty database not to need a baseline")
+		}
+
+		applied, err := migrator.Up()
+		if err != nil {
+			t.Fatalf("Failed to apply migrations: %v", err)
+		}
+		if len(applied) != len(migrator.Migrations()) {
+			t.Errorf("Expected %d migrations applied, got %d", len(migrator.Migrations()), len(applied))
+		}
+		if err := migrator.Check(ctx); err != nil {
+			t.Errorf("Expected an up-to-date schema, got %v", err)
+		}
+		// Les migrations SQL doivent produire le schéma attendu par les modèles.
+		if err := repository.CheckSchema(db); err != nil {
+			t.Errorf("Expected migrations to match the models, got %v", err)
+		}
+		if applied, err := migrator.Up(); err != nil || len(applied) != 0 {
+			t.Errorf("Expected a second run to apply nothing, got %v, %v", applied, err)
+		}
+
+		statuses, err := migrator.Status()
+		if err != nil {
+			t.Fatalf("Failed to read status: %v", err)
+		}
+		for _, status := range statuses {
+			if !status.Applied || status.Dirty || status.AppliedAt == nil {
+				t.Errorf("Expected migration %d to be applied, got %+v", status.Version, status)
+			}
+		}
+
+		reverted, err := migrator.Down(len(statuses))
+		if err != nil {
+			t.Fatalf("Failed to roll back migrations: %v", err)
+		}
+		if len(reverted) != len(statuses) || reverted[len(reverted)-1].Version != BaselineVersion {
+			t.Errorf("Expected every migration rolled back, latest first, got %v", reverted)
+		}
+		if db.Migrator().HasTable(&models.Link{}) {
+			t.Error("Expected the links table to be dropped")
+		}
+		if err := migrator.Check(ctx); !errors.Is(err, ErrPendingMigrations) {
+			t.Errorf("Expected pending migrations after rolling back, got %v", err)
+		}
+	})
+}
+
+func TestMigrator_DirtyAndForce(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *gorm.DB) {
+		migrator := mustNew(t, db)
+		if _, err := migrator.Up(); err != nil {
+			t.Fatalf("Failed to apply migrations: %v", err)
+		}
+		latest := migrator.Migrations()[len(migrator.Migrations())-1].Version
+		if err := db.Model(&schemaMigration{Version: latest}).Update("dirty", true).Error; err != nil {
+			t.Fatalf("Failed to mark migration dirty: %v", err)
+		}
+
+		if err := migrator.Check(context.Background()); !errors.Is(err, ErrDirtySchema) {
+			t.Errorf("Expected a dirty schema, got %v", err)
+		}
+		if _, err := migrator.Up(); !errors.Is(err, ErrDirtySchema) {
+			t.Errorf("Expected Up to refuse a dirty schema, got %v", err)
+		}
+
+		if err := migrator.Force(latest); err != nil {
+			t.Fatalf("Failed to force version: %v", err)
+		}
+		if err := migrator.Check(context.Background()); err != nil {
+			t.Errorf("Expected a clean schema after force, got %v", err)
+		}
+		if err := migrator.Force(999); err == nil {
+			t.Error("Expected an error when forcing an unknown version")
+		}
+	})
+}
+
+func TestMigrator_Baseline(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *gorm.DB) {
+		// Base créée par les migrations automatiques de GORM, avant les migrations versionnées.
+		if err := db.AutoMigrate(models.AllModels()...); err != nil {
+			t.Fatalf("Failed to migrate models: %v", err)
+		}
+		migrator := mustNew(t, db)
+		if !migrator.NeedsBaseline() {
+			t.Fatal("Expected a legacy database to need a baseline")
+		}
+		if err := migrator.Check(context.Background()); !errors.Is(err, ErrPendingMigrations) {
+			t.Errorf("Expected a legacy database to have pending migrations, got %v", err)
+		}
+
+		if err := migrator.Force(BaselineVersion); err != nil {
+			t.Fatalf("Failed to record the baseline: %v", err)
+		}
+		if migrator.NeedsBaseline() {
+			t.Error("Expected the baseline to be recorded")
+		}
+		if _, err := migrator.Up(); err != nil {
+			t.Errorf("Expected later migrations to apply on the baseline, got %v", err)
+		}
+		if err := migrator.Check(context.Background()); err != nil {
+			t.Errorf("Expected an up-to-date schema, got %v", err)
+		}
+	})
+}
+
+func TestMigrator_FailedMigrationRollsBack(t *testing.T) {
+	db := openTestDB(t, config.DatabaseConfig{Driver: database.DriverSQLite, Name: filepath.Join(t.TempDir(), "test.db")})
+	migrator, err := NewWithFS(db, fstest.MapFS{
+		"0001_init.up.sql":     {Data: []byte("CREATE TABLE a (id integer);")},
+		"0001_init.down.sql":   {Data: []byte("DROP TABLE a;")},
+		"0002_broken.up.sql":   {Data: []byte("CREATE TABLE b (id integer);\nINSERT INTO missing VALUES (1);")},
+		"0002_broken.down.sql": {Data: []byte("DROP TABLE b;")},
+	})
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	applied, err := migrator.Up()
+	if err == nil || !strings.Contains(err.Error(), "0002_broken") {
+		t.Fatalf("Expected migration 0002_broken to fail, got %v", err)
+	}
+	if len(applied) != 1 || applied[0].Version != 1 {
+		t.Errorf("Expected only 0001 applied, got %v", applied)
+	}
+	if db.Migrator().HasTable("b") {
+		t.Error("Expected the failed migration to be rolled back")
+	}
+	if err := migrator.Check(context.Background()); !errors.Is(err, ErrPendingMigrations) || !strings.Contains(err.Error(), "0002_broken") {
+		t.Errorf("Expected 0002_broken to stay pending, got %v", err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sqlite"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"0003_init.up.sql", "0003_init.down.sql"} {
+		if err := os.WriteFile(filepath.Join(dir, "sqlite", name), []byte("SELECT 1;"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := Create(dir, "Add link-tags")
+	if err != nil {
+		t.Fatalf("Failed to create migration: %v", err)
+	}
+	if len(paths) != 2*len(Drivers) {
+		t.Fatalf("Expected an up and a down file per driver, got %v", paths)
+	}
+	for _, driver := range Drivers {
+		migrations, err := Load(os.DirFS(filepath.Join(dir, driver)))
+		if err != nil {
+			t.Fatalf("Failed to load %s migrations: %v", driver, err)
+		}
+		latest := migrations[len(migrations)-1]
+		if latest.ID() != "0004_add_link_tags" {
+			t.Errorf("Expected %s migration 0004_add_link_tags, got %s", driver, latest.ID())
+		}
+	}
+
+	if _, err := Create(dir, "--"); err == nil {
+		t.Error("Expected an error for an invalid name")
+	}
+}
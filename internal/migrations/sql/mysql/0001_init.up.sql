@@ -0,0 +1,85 @@
+-- Schéma initial : tables des liens, clics, clés d'API, visiteurs uniques et rollups.
+CREATE TABLE `links` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `short_code` varchar(32) NOT NULL,
+    `long_url` longtext NOT NULL,
+    `created_at` datetime(3) NULL,
+    `expires_at` datetime(3) NULL,
+    `max_clicks` bigint,
+    `expired` boolean NOT NULL DEFAULT false,
+    `disabled` boolean NOT NULL DEFAULT false,
+    `deleted_at` datetime(3) NULL,
+    `owner_id` bigint unsigned,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_links_short_code` (`short_code`),
+    INDEX `idx_links_expires_at` (`expires_at`),
+    INDEX `idx_links_expired` (`expired`),
+    INDEX `idx_links_deleted_at` (`deleted_at`),
+    INDEX `idx_links_owner_id` (`owner_id`)
+);
+
+CREATE TABLE `clicks` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `link_id` bigint unsigned,
+    `timestamp` datetime(3) NULL,
+    `user_agent` varchar(255),
+    `ip_address` varchar(50),
+    `referrer` varchar(2048),
+    `referrer_host` varchar(255),
+    `browser_family` varchar(64),
+    `browser_version` varchar(32),
+    `os_family` varchar(64),
+    `device_class` varchar(16),
+    `is_bot` boolean NOT NULL DEFAULT false,
+    PRIMARY KEY (`id`),
+    INDEX `idx_clicks_link_id` (`link_id`),
+    INDEX `idx_clicks_referrer_host` (`referrer_host`),
+    INDEX `idx_clicks_browser_family` (`browser_family`),
+    INDEX `idx_clicks_os_family` (`os_family`),
+    INDEX `idx_clicks_device_class` (`device_class`),
+    INDEX `idx_clicks_is_bot` (`is_bot`),
+    CONSTRAINT `fk_clicks_link` FOREIGN KEY (`link_id`) REFERENCES `links`(`id`)
+);
+
+CREATE TABLE `api_keys` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `name` varchar(100) NOT NULL,
+    `prefix` varchar(16) NOT NULL,
+    `key_hash` varchar(64) NOT NULL,
+    `created_at` datetime(3) NULL,
+    `last_used_at` datetime(3) NULL,
+    `revoked_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_api_keys_key_hash` (`key_hash`)
+);
+
+CREATE TABLE `visitor_sketches` (
+    `link_id` bigint unsigned,
+    `day` datetime(3),
+    `registers` longblob NOT NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`link_id`, `day`)
+);
+
+CREATE TABLE `visitor_salts` (
+    `day` varchar(10),
+    `salt` longblob NOT NULL,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`day`)
+);
+
+CREATE TABLE `click_rollups_hourly` (
+    `link_id` bigint unsigned,
+    `bucket` datetime(3),
+    `human_clicks` bigint NOT NULL DEFAULT 0,
+    `bot_clicks` bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (`link_id`, `bucket`)
+);
+
+CREATE TABLE `click_rollups_daily` (
+    `link_id` bigint unsigned,
+    `bucket` datetime(3),
+    `human_clicks` bigint NOT NULL DEFAULT 0,
+    `bot_clicks` bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (`link_id`, `bucket`)
+);
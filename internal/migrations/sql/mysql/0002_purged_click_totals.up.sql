@@ -0,0 +1,17 @@
+-- Ventilation des clics supprimés par la rétention, et historique des suppressions.
+-- IF NOT EXISTS : la mise à niveau d'une base antérieure aux migrations versionnées crée déjà ces tables.
+CREATE TABLE IF NOT EXISTS `purged_click_totals` (
+    `link_id` bigint unsigned,
+    `dimension` varchar(16),
+    `value` varchar(255),
+    `clicks` bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (`link_id`, `dimension`, `value`)
+);
+
+CREATE TABLE IF NOT EXISTS `click_purges` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `purged_before` datetime(3) NULL,
+    `deleted` bigint,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`)
+);
@@ -0,0 +1,85 @@
+-- Schéma initial : tables des liens, clics, clés d'API, visiteurs uniques et rollups.
+CREATE TABLE "links" (
+    "id" bigserial,
+    "short_code" varchar(32) NOT NULL,
+    "long_url" text NOT NULL,
+    "created_at" timestamptz,
+    "expires_at" timestamptz,
+    "max_clicks" bigint,
+    "expired" boolean NOT NULL DEFAULT false,
+    "disabled" boolean NOT NULL DEFAULT false,
+    "deleted_at" timestamptz,
+    "owner_id" bigint,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_links_short_code" ON "links" ("short_code");
+CREATE INDEX "idx_links_expires_at" ON "links" ("expires_at");
+CREATE INDEX "idx_links_expired" ON "links" ("expired");
+CREATE INDEX "idx_links_deleted_at" ON "links" ("deleted_at");
+CREATE INDEX "idx_links_owner_id" ON "links" ("owner_id");
+
+CREATE TABLE "clicks" (
+    "id" bigserial,
+    "link_id" bigint,
+    "timestamp" timestamptz,
+    "user_agent" varchar(255),
+    "ip_address" varchar(50),
+    "referrer" varchar(2048),
+    "referrer_host" varchar(255),
+    "browser_family" varchar(64),
+    "browser_version" varchar(32),
+    "os_family" varchar(64),
+    "device_class" varchar(16),
+    "is_bot" boolean NOT NULL DEFAULT false,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_clicks_link" FOREIGN KEY ("link_id") REFERENCES "links"("id")
+);
+CREATE INDEX "idx_clicks_link_id" ON "clicks" ("link_id");
+CREATE INDEX "idx_clicks_referrer_host" ON "clicks" ("referrer_host");
+CREATE INDEX "idx_clicks_browser_family" ON "clicks" ("browser_family");
+CREATE INDEX "idx_clicks_os_family" ON "clicks" ("os_family");
+CREATE INDEX "idx_clicks_device_class" ON "clicks" ("device_class");
+CREATE INDEX "idx_clicks_is_bot" ON "clicks" ("is_bot");
+
+CREATE TABLE "api_keys" (
+    "id" bigserial,
+    "name" varchar(100) NOT NULL,
+    "prefix" varchar(16) NOT NULL,
+    "key_hash" varchar(64) NOT NULL,
+    "created_at" timestamptz,
+    "last_used_at" timestamptz,
+    "revoked_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_api_keys_key_hash" ON "api_keys" ("key_hash");
+
+CREATE TABLE "visitor_sketches" (
+    "link_id" bigint,
+    "day" timestamptz,
+    "registers" bytea NOT NULL,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("link_id", "day")
+);
+
+CREATE TABLE "visitor_salts" (
+    "day" varchar(10),
+    "salt" bytea NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("day")
+);
+
+CREATE TABLE "click_rollups_hourly" (
+    "link_id" bigint,
+    "bucket" timestamptz,
+    "human_clicks" bigint NOT NULL DEFAULT 0,
+    "bot_clicks" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("link_id", "bucket")
+);
+
+CREATE TABLE "click_rollups_daily" (
+    "link_id" bigint,
+    "bucket" timestamptz,
+    "human_clicks" bigint NOT NULL DEFAULT 0,
+    "bot_clicks" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("link_id", "bucket")
+);
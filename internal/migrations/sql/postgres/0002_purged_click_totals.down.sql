@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "click_purges";
+DROP TABLE IF EXISTS "purged_click_totals";
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS `click_rollups_daily`;
+DROP TABLE IF EXISTS `click_rollups_hourly`;
+DROP TABLE IF EXISTS `visitor_salts`;
+DROP TABLE IF EXISTS `visitor_sketches`;
+DROP TABLE IF EXISTS `api_keys`;
+DROP TABLE IF EXISTS `clicks`;
+DROP TABLE IF EXISTS `links`;
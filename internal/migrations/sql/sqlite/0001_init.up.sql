@@ -0,0 +1,82 @@
+-- Schéma initial : tables des liens, clics, clés d'API, visiteurs uniques et rollups.
+CREATE TABLE `links` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `short_code` text NOT NULL,
+    `long_url` text NOT NULL,
+    `created_at` datetime,
+    `expires_at` datetime,
+    `max_clicks` integer,
+    `expired` numeric NOT NULL DEFAULT false,
+    `disabled` numeric NOT NULL DEFAULT false,
+    `deleted_at` datetime,
+    `owner_id` integer
+);
+CREATE UNIQUE INDEX `idx_links_short_code` ON `links`(`short_code`);
+CREATE INDEX `idx_links_expires_at` ON `links`(`expires_at`);
+CREATE INDEX `idx_links_expired` ON `links`(`expired`);
+CREATE INDEX `idx_links_deleted_at` ON `links`(`deleted_at`);
+CREATE INDEX `idx_links_owner_id` ON `links`(`owner_id`);
+
+CREATE TABLE `clicks` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `link_id` integer,
+    `timestamp` datetime,
+    `user_agent` text,
+    `ip_address` text,
+    `referrer` text,
+    `referrer_host` text,
+    `browser_family` text,
+    `browser_version` text,
+    `os_family` text,
+    `device_class` text,
+    `is_bot` numeric NOT NULL DEFAULT false,
+    CONSTRAINT `fk_clicks_link` FOREIGN KEY (`link_id`) REFERENCES `links`(`id`)
+);
+CREATE INDEX `idx_clicks_link_id` ON `clicks`(`link_id`);
+CREATE INDEX `idx_clicks_referrer_host` ON `clicks`(`referrer_host`);
+CREATE INDEX `idx_clicks_browser_family` ON `clicks`(`browser_family`);
+CREATE INDEX `idx_clicks_os_family` ON `clicks`(`os_family`);
+CREATE INDEX `idx_clicks_device_class` ON `clicks`(`device_class`);
+CREATE INDEX `idx_clicks_is_bot` ON `clicks`(`is_bot`);
+
+CREATE TABLE `api_keys` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` text NOT NULL,
+    `prefix` text NOT NULL,
+    `key_hash` text NOT NULL,
+    `created_at` datetime,
+    `last_used_at` datetime,
+    `revoked_at` datetime
+);
+CREATE UNIQUE INDEX `idx_api_keys_key_hash` ON `api_keys`(`key_hash`);
+
+CREATE TABLE `visitor_sketches` (
+    `link_id` integer,
+    `day` datetime,
+    `registers` blob NOT NULL,
+    `updated_at` datetime,
+    PRIMARY KEY (`link_id`, `day`)
+);
+
+CREATE TABLE `visitor_salts` (
+    `day` text,
+    `salt` blob NOT NULL,
+    `created_at` datetime,
+    PRIMARY KEY (`day`)
+);
+
+CREATE TABLE `click_rollups_hourly` (
+    `link_id` integer,
+    `bucket` datetime,
+    `human_clicks` integer NOT NULL DEFAULT 0,
+    `bot_clicks` integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (`link_id`, `bucket`)
+);
+
+CREATE TABLE `click_rollups_daily` (
+    `link_id` integer,
+    `bucket` datetime,
+    `human_clicks` integer NOT NULL DEFAULT 0,
+    `bot_clicks` integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (`link_id`, `bucket`)
+);
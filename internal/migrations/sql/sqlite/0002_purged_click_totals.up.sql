@@ -0,0 +1,16 @@
+-- Ventilation des clics supprimés par la rétention, et historique des suppressions.
+-- IF NOT EXISTS : la mise à niveau d'une base antérieure aux migrations versionnées crée déjà ces tables.
+CREATE TABLE IF NOT EXISTS `purged_click_totals` (
+    `link_id` integer,
+    `dimension` text,
+    `value` text,
+    `clicks` integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (`link_id`, `dimension`, `value`)
+);
+
+CREATE TABLE IF NOT EXISTS `click_purges` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `purged_before` datetime,
+    `deleted` integer,
+    `created_at` datetime
+);
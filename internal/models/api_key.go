@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// APIKey représente une clé d'API permettant d'appeler les routes /api/v1.
+// Seule l'empreinte SHA-256 de la clé est stockée : la clé en clair n'est affichée qu'à sa création.
+// Prefix conserve les premiers caractères de la clé pour l'identifier dans les listings.
+type APIKey struct {
+	ID         uint   `gorm:"primaryKey"`
+	Name       string `gorm:"size:100;not null"`
+	Prefix     string `gorm:"size:16;not null"`
+	KeyHash    string `gorm:"uniqueIndex;size:64;not null"`
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// IsRevoked indique si la clé a été révoquée.
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
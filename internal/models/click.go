@@ -3,17 +3,33 @@ package models
 import "time"
 
 type Click struct {
-	ID        uint      `gorm:"primaryKey"`        
-	LinkID    uint      `gorm:"index"`             
-	Link      Link      `gorm:"foreignKey:LinkID"` 
-	Timestamp time.Time 
-	UserAgent string    `gorm:"size:255"` 
-	IPAddress string    `gorm:"size:50"`  
+	ID           uint `gorm:"primaryKey"`
+	LinkID       uint `gorm:"index"`
+	Link         Link `gorm:"foreignKey:LinkID"`
+	Timestamp    time.Time
+	UserAgent    string `gorm:"size:255"`
+	IPAddress    string `gorm:"size:50"`
+	Referrer     string `gorm:"size:2048"`
+	ReferrerHost string `gorm:"size:255;index"`
+	// Champs déduits du User-Agent à l'ingestion.
+	BrowserFamily  string `gorm:"size:64;index"`
+	BrowserVersion string `gorm:"size:32"`
+	OSFamily       string `gorm:"size:64;index"`
+	DeviceClass    string `gorm:"size:16;index"`
+	// IsBot marque les clics de robots (aperçus de liens, crawlers, requêtes HEAD) :
+	// ils sont conservés mais exclus des compteurs de clics humains.
+	IsBot bool `gorm:"not null;default:false;index"`
 }
 
 type ClickEvent struct {
-	LinkID    uint      
-	Timestamp time.Time 
-	UserAgent string    
-	IPAddress string    
-}
\ No newline at end of file
+	LinkID    uint
+	Timestamp time.Time
+	UserAgent string
+	IPAddress string
+	Referrer  string
+	Method    string
+	// Done, s'il est renseigné, est appelé par les workers une fois l'événement traité (enregistré,
+	// remis en spool ou compté comme perdu). Le spool s'en sert pour ne retirer un événement relu
+	// qu'après son traitement.
+	Done func() `json:"-"`
+}
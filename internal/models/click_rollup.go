@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// HourlyClickRollup est le nombre de clics d'un lien sur une heure (UTC), tenu à jour à chaque
+// clic enregistré : les statistiques n'ont pas à parcourir la table clicks.
+type HourlyClickRollup struct {
+	LinkID      uint      `gorm:"primaryKey;autoIncrement:false"`
+	Bucket      time.Time `gorm:"primaryKey"`
+	HumanClicks int       `gorm:"not null;default:0"`
+	BotClicks   int       `gorm:"not null;default:0"`
+}
+
+func (HourlyClickRollup) TableName() string {
+	return "click_rollups_hourly"
+}
+
+// DailyClickRollup est le nombre de clics d'un lien sur une journée (UTC).
+type DailyClickRollup struct {
+	LinkID      uint      `gorm:"primaryKey;autoIncrement:false"`
+	Bucket      time.Time `gorm:"primaryKey"`
+	HumanClicks int       `gorm:"not null;default:0"`
+	BotClicks   int       `gorm:"not null;default:0"`
+}
+
+func (DailyClickRollup) TableName() string {
+	return "click_rollups_daily"
+}
+
+// PurgedClickTotal est le nombre de clics d'un lien supprimés par la politique de rétention pour
+// une valeur d'une dimension (hôte référent, navigateur, système ou classe d'appareil) : les
+// ventilations restent complètes une fois les clics bruts supprimés.
+type PurgedClickTotal struct {
+	LinkID    uint   `gorm:"primaryKey;autoIncrement:false"`
+	Dimension string `gorm:"primaryKey;size:16"`
+	Value     string `gorm:"primaryKey;size:255"`
+	Clicks    int    `gorm:"not null;default:0"`
+}
+
+func (PurgedClickTotal) TableName() string {
+	return "purged_click_totals"
+}
+
+// ClickPurge enregistre une suppression de clics bruts : les rollups des journées antérieures à
+// PurgedBefore ne peuvent plus être recalculés à partir des clics.
+type ClickPurge struct {
+	ID           uint `gorm:"primaryKey"`
+	PurgedBefore time.Time
+	Deleted      int64
+	CreatedAt    time.Time
+}
@@ -6,6 +6,23 @@ var (
 	ErrLinkNotFound = errors.New("link not found")
 	ErrInvalidURL = errors.New("invalid URL format")
 	ErrDuplicateShortCode = errors.New("short code already exists")
+	ErrInvalidShortCode = errors.New("invalid short code: must be 3 to 32 characters among letters, digits, '-' and '_'")
+	ErrReservedShortCode = errors.New("short code is reserved")
+	ErrLinkExpired = errors.New("link has expired")
+	ErrLinkClickLimitReached = errors.New("link has reached its maximum number of clicks")
+	ErrInvalidExpiration = errors.New("expiration date must be in the future")
+	ErrInvalidMaxClicks = errors.New("max clicks must be greater than zero")
+	ErrLinkDisabled = errors.New("link is disabled")
+	ErrLinkNotDeleted = errors.New("link is not deleted")
+	ErrInvalidCursor = errors.New("invalid pagination cursor")
+	ErrInvalidListQuery = errors.New("invalid list query")
+	ErrInvalidAPIKey = errors.New("invalid or revoked API key")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrLinkForbidden = errors.New("link belongs to another API key")
+	ErrInvalidTimeRange = errors.New("invalid time range")
+	ErrInvalidDimension = errors.New("invalid breakdown dimension: must be browser, os or device")
+	ErrInvalidRetentionMode = errors.New("invalid retention mode: must be delete or aggregate")
+	ErrClicksPurged = errors.New("raw clicks have been purged")
 	ErrShortCodeGenerationFailed = errors.New("failed to generate unique short code after maximum retries")	
 	ErrDatabaseConnection = errors.New("database connection error")
 	ErrConfigurationLoad = errors.New("failed to load configuration")
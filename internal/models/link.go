@@ -1,17 +1,67 @@
 package models
 
-import "time"
-// TODO : Créer la struct Link
-// Link représente un lien raccourci dans la base de données.
-// Les tags `gorm:"..."` définissent comment GORM doit mapper cette structure à une table SQL.
-// ID qui est une primaryKey
-// Shortcode : doit être unique, indexé pour des recherches rapide (voir doc), taille max 10 caractères
-// LongURL : doit pas être null
-// CreateAt : Horodatage de la créatino du lien
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// Link représente un lien raccourci. ShortCode est unique et limité à 32 caractères, pour
+// permettre les alias personnalisés.
 type Link struct {
-	ID        uint      `gorm:"primaryKey"`
-	ShortCode string    `gorm:"uniqueIndex;size:10;not null"`
-	LongURL   string    `gorm:"not null"`
+	ID        uint   `gorm:"primaryKey"`
+	ShortCode string `gorm:"uniqueIndex;size:32;not null"`
+	LongURL   string `gorm:"not null"`
 	CreatedAt time.Time
+	// ExpiresAt est la date au-delà de laquelle le lien ne redirige plus ; nil, il n'expire pas.
+	ExpiresAt *time.Time `gorm:"index"`
+	// MaxClicks est le nombre de clics après lequel le lien est épuisé ; nil, il est illimité.
+	MaxClicks *int
+	// Expired est positionné par le sweeper lorsque le lien a expiré ou épuisé son budget de clics.
+	Expired bool `gorm:"not null;default:false;index"`
+	// Disabled désactive manuellement le lien : il ne redirige plus mais reste consultable.
+	Disabled bool `gorm:"not null;default:false"`
+	// DeletedAt marque la suppression logique du lien, qui peut être restauré.
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	// OwnerID est la clé d'API ayant créé le lien, nil pour les liens créés depuis la CLI.
+	OwnerID *uint `gorm:"index"`
+}
+
+// IsOwnedBy indique si le lien appartient à la clé d'API donnée.
+func (l *Link) IsOwnedBy(apiKeyID uint) bool {
+	return l.OwnerID != nil && *l.OwnerID == apiKeyID
+}
+
+// États du cycle de vie d'un lien retournés par Link.Status.
+const (
+	LinkStatusActive    = "active"
+	LinkStatusDisabled  = "disabled"
+	LinkStatusExpired   = "expired"
+	LinkStatusExhausted = "exhausted"
+)
+
+// HasExpired indique si la date d'expiration du lien est dépassée.
+func (l *Link) HasExpired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// HasReachedClickLimit indique si le budget de clics du lien est épuisé.
+func (l *Link) HasReachedClickLimit(clicks int) bool {
+	return l.MaxClicks != nil && clicks >= *l.MaxClicks
+}
+
+// Status retourne l'état du cycle de vie du lien pour un nombre de clics donné. Un lien désactivé
+// l'emporte sur un lien expiré, lui-même prioritaire sur un lien épuisé (marqué Expired par le
+// sweeper ou ayant atteint MaxClicks).
+func (l *Link) Status(clicks int, now time.Time) string {
+	switch {
+	case l.Disabled:
+		return LinkStatusDisabled
+	case l.HasExpired(now):
+		return LinkStatusExpired
+	case l.Expired, l.HasReachedClickLimit(clicks):
+		return LinkStatusExhausted
+	default:
+		return LinkStatusActive
+	}
 }
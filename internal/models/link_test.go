@@ -90,4 +90,55 @@ func TestLink_Creation(t *testing.T) {
 	if !link.CreatedAt.Equal(now) {
 		t.Errorf("Expected CreatedAt to be %v, got %v", now, link.CreatedAt)
 	}
-} 
\ No newline at end of file
+}
+
+func TestLink_Status(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	maxClicks := 3
+
+	tests := []struct {
+		name     string
+		link     Link
+		clicks   int
+		expected string
+	}{
+		{
+			name:     "no limits",
+			link:     Link{ShortCode: "abc123"},
+			clicks:   42,
+			expected: LinkStatusActive,
+		},
+		{
+			name:     "expiration in the future",
+			link:     Link{ShortCode: "abc123", ExpiresAt: &future},
+			expected: LinkStatusActive,
+		},
+		{
+			name:     "expiration in the past",
+			link:     Link{ShortCode: "abc123", ExpiresAt: &past},
+			expected: LinkStatusExpired,
+		},
+		{
+			name:     "click budget remaining",
+			link:     Link{ShortCode: "abc123", MaxClicks: &maxClicks},
+			clicks:   2,
+			expected: LinkStatusActive,
+		},
+		{
+			name:     "click budget exhausted",
+			link:     Link{ShortCode: "abc123", MaxClicks: &maxClicks},
+			clicks:   3,
+			expected: LinkStatusExhausted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := tt.link.Status(tt.clicks, now); status != tt.expected {
+				t.Errorf("Expected status %s, got %s", tt.expected, status)
+			}
+		})
+	}
+}
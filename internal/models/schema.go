@@ -0,0 +1,11 @@
+package models
+
+// AllModels retourne un exemplaire de chaque modèle persisté, dans l'ordre de création des tables.
+func AllModels() []interface{} {
+	return []interface{}{
+		&Link{}, &Click{}, &APIKey{},
+		&VisitorSketch{}, &VisitorSalt{},
+		&HourlyClickRollup{}, &DailyClickRollup{},
+		&PurgedClickTotal{}, &ClickPurge{},
+	}
+}
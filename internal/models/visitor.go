@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// VisitorSketch est le sketch HyperLogLog des visiteurs uniques d'un lien sur une journée (UTC).
+type VisitorSketch struct {
+	LinkID    uint      `gorm:"primaryKey;autoIncrement:false"`
+	Day       time.Time `gorm:"primaryKey"`
+	Registers []byte    `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+// VisitorSalt est le sel aléatoire utilisé pour calculer les empreintes de visiteurs d'une journée.
+// Les sels des journées passées sont supprimés : une empreinte ne peut plus être recalculée
+// ni rapprochée d'un visiteur d'un autre jour.
+type VisitorSalt struct {
+	Day       string `gorm:"primaryKey;size:10"`
+	Salt      []byte `gorm:"not null"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+)
+
+// ClickPurger applique périodiquement la politique de rétention : les clics plus anciens que
+// retention sont supprimés ou dépouillés de leurs données personnelles selon mode.
+type ClickPurger struct {
+	clickService *services.ClickService
+	retention    time.Duration
+	mode         string
+	interval     time.Duration
+	logger       *slog.Logger
+}
+
+func NewClickPurger(clickService *services.ClickService, retention time.Duration, mode string, interval time.Duration,
+	logger *slog.Logger) *ClickPurger {
+	return &ClickPurger{
+		clickService: clickService,
+		retention:    retention,
+		mode:         mode,
+		interval:     interval,
+		logger:       logger.With("component", "purge"),
+	}
+}
+
+// Start applique la politique de rétention à chaque intervalle jusqu'à l'annulation de ctx.
+func (p *ClickPurger) Start(ctx context.Context) {
+	p.logger.Info("Démarrage de la purge des clics", "retention", p.retention, "mode", p.mode, "interval", p.interval)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	p.purge()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.purge()
+		case <-ctx.Done():
+			p.logger.Info("Purge des clics arrêtée")
+			return
+		}
+	}
+}
+
+func (p *ClickPurger) purge() {
+	before := time.Now().Add(-p.retention)
+	purged, err := p.clickService.PurgeClicks(before, p.mode, false)
+	if err != nil {
+		p.logger.Error("Échec de la purge des clics", "before", before, "error", err)
+		return
+	}
+
+	if purged > 0 {
+		p.logger.Info("Clics purgés", "count", purged, "before", before, "mode", p.mode)
+	}
+}
@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// ExpirationSweeper marque périodiquement comme expirés les liens dont la date d'expiration
+// est dépassée ou dont le budget de clics est épuisé, afin que le UrlMonitor cesse de les vérifier.
+type ExpirationSweeper struct {
+	linkRepo  repository.LinkRepository
+	interval  time.Duration
+	logger    *slog.Logger
+	heartbeat heartbeat
+}
+
+func NewExpirationSweeper(linkRepo repository.LinkRepository, interval time.Duration, logger *slog.Logger) *ExpirationSweeper {
+	return &ExpirationSweeper{
+		linkRepo: linkRepo,
+		interval: interval,
+		logger:   logger.With("component", "sweeper"),
+	}
+}
+
+// Start marque les liens expirés à chaque intervalle jusqu'à l'annulation de ctx.
+func (s *ExpirationSweeper) Start(ctx context.Context) {
+	s.logger.Info("Démarrage du sweeper d'expiration", "interval", s.interval)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.sweep()
+	s.heartbeat.beat()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.sweep()
+			s.heartbeat.beat()
+		case <-ctx.Done():
+			s.logger.Info("Sweeper d'expiration arrêté")
+			return
+		}
+	}
+}
+
+// LastHeartbeat retourne l'instant du dernier passage du sweeper, ou le temps zéro s'il n'a pas démarré.
+func (s *ExpirationSweeper) LastHeartbeat() time.Time {
+	return s.heartbeat.last()
+}
+
+func (s *ExpirationSweeper) sweep() {
+	marked, err := s.linkRepo.MarkExpiredLinks(time.Now())
+	if err != nil {
+		s.logger.Error("Échec du marquage des liens expirés", "error", err)
+		return
+	}
+
+	if marked > 0 {
+		s.logger.Info("Liens marqués comme expirés", "count", marked)
+	}
+}
@@ -0,0 +1,25 @@
+package monitor
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// heartbeat mémorise l'instant de la dernière activité d'un processus de fond, pour que les
+// sondes de disponibilité détectent une boucle arrêtée ou bloquée.
+type heartbeat struct {
+	unixNano atomic.Int64
+}
+
+func (h *heartbeat) beat() {
+	h.unixNano.Store(time.Now().UnixNano())
+}
+
+// last retourne l'instant du dernier battement, ou le temps zéro s'il n'y en a pas encore eu.
+func (h *heartbeat) last() time.Time {
+	n := h.unixNano.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
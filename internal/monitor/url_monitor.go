@@ -1,11 +1,14 @@
 package monitor
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/axellelanca/urlshortener/internal/analytics"
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	_ "github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository"
 )
@@ -15,39 +18,80 @@ type UrlMonitor struct {
 	interval    time.Duration
 	knownStates map[uint]bool
 	mu          sync.Mutex
+	metrics     *metrics.Metrics
+	logger      *slog.Logger
+	heartbeat   heartbeat
 }
 
-func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration) *UrlMonitor {
+// NewUrlMonitor crée un moniteur. Si m est non nil, la durée des vérifications et le nombre de
+// liens accessibles et inaccessibles y sont publiés.
+func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration, m *metrics.Metrics, logger *slog.Logger) *UrlMonitor {
 	return &UrlMonitor{
 		linkRepo:    linkRepo,
 		interval:    interval,
 		knownStates: make(map[uint]bool),
+		metrics:     m,
+		logger:      logger.With("component", "monitor"),
 	}
 }
 
-func (m *UrlMonitor) Start() {
-	log.Printf("[MONITOR] Démarrage du moniteur d'URLs avec un intervalle de %v...", m.interval)
+// Start vérifie les URLs à chaque intervalle jusqu'à l'annulation de ctx, qui interrompt
+// aussi la vérification en cours.
+func (m *UrlMonitor) Start(ctx context.Context) {
+	m.logger.Info("Démarrage du moniteur d'URLs", "interval", m.interval)
+	m.heartbeat.beat()
 	ticker := time.NewTicker(m.interval)
 	defer ticker.Stop()
 
-	m.checkUrls()
-
-	for range ticker.C {
-		m.checkUrls()
+	m.checkUrls(ctx)
+	m.heartbeat.beat()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.checkUrls(ctx)
+			m.heartbeat.beat()
+		case <-ctx.Done():
+			m.logger.Info("Moniteur d'URLs arrêté")
+			return
+		}
 	}
 }
 
-func (m *UrlMonitor) checkUrls() {
-	log.Println("[MONITOR] Lancement de la vérification de l'état des URLs...")
+// LastHeartbeat retourne l'instant de la dernière activité du moniteur, ou le temps zéro
+// s'il n'a pas démarré.
+func (m *UrlMonitor) LastHeartbeat() time.Time {
+	return m.heartbeat.last()
+}
+
+func (m *UrlMonitor) checkUrls(ctx context.Context) {
+	m.logger.Debug("Lancement de la vérification de l'état des URLs")
 
-	links, err := m.linkRepo.GetAllLinks()
+	links, err := m.linkRepo.GetActiveLinks()
 	if err != nil {
-		log.Printf("[MONITOR] ERREUR lors de la récupération des liens pour la surveillance : %v", err)
+		m.logger.Error("Échec de la récupération des liens à surveiller", "error", err)
 		return
 	}
 
+	accessible, inaccessible := 0, 0
 	for _, link := range links {
-		currentState := m.isUrlAccessible(link.LongURL)
+		if ctx.Err() != nil {
+			return
+		}
+		// Une vérification complète peut durer plus d'un intervalle : chaque lien vérifié compte.
+		m.heartbeat.beat()
+		start := time.Now()
+		currentState := m.isUrlAccessible(ctx, link.LongURL)
+		if ctx.Err() != nil {
+			// Une vérification interrompue par l'arrêt ne dit rien de l'état du lien.
+			return
+		}
+		m.metrics.ObserveURLCheck(currentState, time.Since(start))
+		if currentState {
+			accessible++
+		} else {
+			inaccessible++
+		}
 
 		m.mu.Lock()
 		previousState, exists := m.knownStates[link.ID]
@@ -55,28 +99,37 @@ func (m *UrlMonitor) checkUrls() {
 		m.mu.Unlock()
 
 		if !exists {
-			log.Printf("[MONITOR] État initial pour le lien %s (%s) : %s",
-				link.ShortCode, link.LongURL, formatState(currentState))
+			m.logger.Info("État initial d'un lien",
+				"short_code", link.ShortCode, "long_url", link.LongURL, "state", formatState(currentState))
 			continue
 		}
 
 		if previousState != currentState {
-			log.Printf("[NOTIFICATION] Le lien %s (%s) est passé de %s à %s !",
-				link.ShortCode, link.LongURL, formatState(previousState), formatState(currentState))
+			m.logger.Warn("Changement d'état d'un lien",
+				"short_code", link.ShortCode, "long_url", link.LongURL,
+				"previous_state", formatState(previousState), "state", formatState(currentState))
 		}
 
 	}
-	log.Println("[MONITOR] Vérification de l'état des URLs terminée.")
+	m.metrics.SetMonitoredLinks(accessible, inaccessible)
+	m.logger.Debug("Vérification de l'état des URLs terminée", "accessible", accessible, "inaccessible", inaccessible)
 }
 
-func (m *UrlMonitor) isUrlAccessible(url string) bool {
+func (m *UrlMonitor) isUrlAccessible(ctx context.Context, url string) bool {
 	client := &http.Client{
 		Timeout: 5 * time.Second,
 	}
 
-	resp, err := client.Head(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		m.logger.Warn("URL invalide", "url", url, "error", err)
+		return false
+	}
+	req.Header.Set("User-Agent", analytics.MonitorUserAgent)
+
+	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
+		m.logger.Warn("Erreur d'accès à l'URL", "url", url, "error", err)
 		return false
 	}
 
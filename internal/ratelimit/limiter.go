@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// cleanupInterval est la fréquence à laquelle les seaux inactifs sont supprimés de la mémoire.
+const cleanupInterval = time.Minute
+
+// Limiter est un limiteur de débit à seau à jetons (token bucket), avec un seau par clé
+// (adresse IP, clé d'API...). Chaque seau contient au plus Burst jetons et se remplit
+// au rythme de RequestsPerMinute jetons par minute. Il est sûr pour un usage concurrent.
+type Limiter struct {
+	ratePerSecond float64
+	burst         float64
+
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Result décrit la décision du limiteur pour une requête.
+type Result struct {
+	Allowed bool
+	// Limit est la capacité du seau (nombre de requêtes autorisées en rafale).
+	Limit int
+	// Remaining est le nombre de jetons restants après cette requête.
+	Remaining int
+	// RetryAfter est le délai avant qu'un jeton soit disponible. Nul si la requête est autorisée.
+	RetryAfter time.Duration
+	// Reset est le délai avant que le seau soit de nouveau plein.
+	Reset time.Duration
+}
+
+// NewLimiter crée un limiteur autorisant requestsPerMinute requêtes par minute en régime
+// permanent et jusqu'à burst requêtes en rafale. Un burst inférieur à 1 est ramené à 1.
+func NewLimiter(requestsPerMinute int, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		ratePerSecond: float64(requestsPerMinute) / 60,
+		burst:         float64(burst),
+		buckets:       make(map[string]*bucket),
+		now:           time.Now,
+	}
+}
+
+// Allow consomme un jeton du seau associé à key si possible.
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.cleanup(now)
+
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.ratePerSecond)
+		b.updated = now
+	}
+
+	result := Result{Limit: int(l.burst)}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.durationFor(1 - b.tokens)
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = l.durationFor(l.burst - b.tokens)
+
+	return result
+}
+
+// durationFor retourne le temps nécessaire pour regagner le nombre de jetons donné.
+func (l *Limiter) durationFor(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	if l.ratePerSecond <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(tokens / l.ratePerSecond * float64(time.Second))
+}
+
+// cleanup supprime les seaux redevenus pleins : ils sont équivalents à un seau neuf.
+// L'appelant doit détenir le verrou.
+func (l *Limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < cleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= l.durationFor(l.burst-b.tokens) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestLimiter(requestsPerMinute, burst int) (*Limiter, *time.Time) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(requestsPerMinute, burst)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestLimiter_Burst(t *testing.T) {
+	limiter, _ := newTestLimiter(60, 3)
+
+	for i := 0; i < 3; i++ {
+		result := limiter.Allow("client")
+		if !result.Allowed {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+		if result.Remaining != 2-i {
+			t.Errorf("Expected %d remaining, got %d", 2-i, result.Remaining)
+		}
+	}
+
+	result := limiter.Allow("client")
+	if result.Allowed {
+		t.Fatalf("Expected request beyond burst to be rejected")
+	}
+	if result.RetryAfter != time.Second {
+		t.Errorf("Expected RetryAfter of 1s, got %v", result.RetryAfter)
+	}
+	if result.Limit != 3 {
+		t.Errorf("Expected limit 3, got %d", result.Limit)
+	}
+}
+
+func TestLimiter_Refill(t *testing.T) {
+	limiter, now := newTestLimiter(60, 1)
+
+	if !limiter.Allow("client").Allowed {
+		t.Fatalf("Expected first request to be allowed")
+	}
+	if limiter.Allow("client").Allowed {
+		t.Fatalf("Expected second request to be rejected")
+	}
+
+	*now = now.Add(time.Second)
+	if !limiter.Allow("client").Allowed {
+		t.Errorf("Expected request to be allowed after refill")
+	}
+}
+
+func TestLimiter_SeparateKeys(t *testing.T) {
+	limiter, _ := newTestLimiter(60, 1)
+
+	if !limiter.Allow("a").Allowed {
+		t.Fatalf("Expected request for key a to be allowed")
+	}
+	if !limiter.Allow("b").Allowed {
+		t.Errorf("Expected request for key b to be allowed independently of key a")
+	}
+}
+
+func TestLimiter_Cleanup(t *testing.T) {
+	limiter, now := newTestLimiter(60, 5)
+
+	limiter.Allow("idle")
+	*now = now.Add(2 * cleanupInterval)
+	limiter.Allow("active")
+
+	if _, exists := limiter.buckets["idle"]; exists {
+		t.Errorf("Expected idle bucket to be removed")
+	}
+	if _, exists := limiter.buckets["active"]; !exists {
+		t.Errorf("Expected active bucket to be kept")
+	}
+}
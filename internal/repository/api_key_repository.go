@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(key *models.APIKey) error
+	GetAPIKeyByHash(keyHash string) (*models.APIKey, error)
+	GetAPIKeyByID(id uint) (*models.APIKey, error)
+	ListAPIKeys() ([]models.APIKey, error)
+	RevokeAPIKey(id uint, revokedAt time.Time) error
+	TouchAPIKey(id uint, usedAt time.Time) error
+}
+
+type GormAPIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *GormAPIKeyRepository {
+	return &GormAPIKeyRepository{db: db}
+}
+
+func (r *GormAPIKeyRepository) CreateAPIKey(key *models.APIKey) error {
+	if err := r.db.Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+	return nil
+}
+
+func (r *GormAPIKeyRepository) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *GormAPIKeyRepository) GetAPIKeyByID(id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.First(&key, id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *GormAPIKeyRepository) ListAPIKeys() ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := r.db.Order("id").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (r *GormAPIKeyRepository) RevokeAPIKey(id uint, revokedAt time.Time) error {
+	if err := r.db.Model(&models.APIKey{}).Where("id = ?", id).Update("revoked_at", revokedAt).Error; err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return nil
+}
+
+func (r *GormAPIKeyRepository) TouchAPIKey(id uint, usedAt time.Time) error {
+	if err := r.db.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error; err != nil {
+		return fmt.Errorf("failed to update API key usage: %w", err)
+	}
+	return nil
+}
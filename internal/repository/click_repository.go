@@ -1,17 +1,102 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 
 type ClickRepository interface {
 	CreateClick(click *models.Click) error
+	CreateClicks(clicks []*models.Click) error
 	GetClicksByLinkID(linkID uint) ([]models.Click, error)
 	CountClicksByLinkID(linkID uint) (int, error)
+	CountClicksByKind(linkID uint) (ClickCounts, error)
+	CountClicksByInterval(linkID uint, from, to time.Time, interval string) ([]ClickBucket, error)
+	TopReferrers(linkID uint, limit int) ([]ReferrerCount, error)
+	CountClicksByDimension(linkID uint, dimension string, limit int) ([]DimensionCount, error)
+	CountClicksBefore(before time.Time, identifiableOnly bool) (int64, error)
+	DeleteClicksBefore(before time.Time, batchSize int) (int64, error)
+	AnonymizeClicksBefore(before time.Time, batchSize int) (int64, error)
+	RebuildRollups(from, to time.Time) (RollupRebuildResult, error)
+}
+
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+// ClickCounts sépare les clics humains des clics de robots d'un lien.
+type ClickCounts struct {
+	Human int
+	Bot   int
+}
+
+// Total retourne le nombre de clics, robots compris.
+func (c ClickCounts) Total() int {
+	return c.Human + c.Bot
+}
+
+// RollupRebuildResult résume une reconstruction des rollups.
+type RollupRebuildResult struct {
+	Clicks        int64
+	HourlyBuckets int
+	DailyBuckets  int
+}
+
+// ClickBucket est le nombre de clics humains et de robots d'un lien sur un intervalle commençant à Start (UTC).
+type ClickBucket struct {
+	Start time.Time
+	ClickCounts
+}
+
+// ReferrerCount est le nombre de clics d'un lien provenant d'un hôte référent.
+// Un hôte vide correspond au trafic direct (sans en-tête Referer).
+type ReferrerCount struct {
+	Host  string
+	Count int
+}
+
+// Dimensions selon lesquelles les clics d'un lien peuvent être ventilés.
+const (
+	DimensionBrowser = "browser"
+	DimensionOS      = "os"
+	DimensionDevice  = "device"
+)
+
+// dimensionColumns associe chaque dimension à la colonne de la table clicks correspondante.
+var dimensionColumns = map[string]string{
+	DimensionBrowser: "browser_family",
+	DimensionOS:      "os_family",
+	DimensionDevice:  "device_class",
+}
+
+// dimensionReferrer désigne l'hôte référent dans les totaux des clics purgés.
+const dimensionReferrer = "referrer"
+
+// purgedDimensionColumns associe chaque dimension conservée par DeleteClicksBefore à sa colonne
+// de la table clicks.
+var purgedDimensionColumns = map[string]string{
+	dimensionReferrer: "referrer_host",
+	DimensionBrowser:  "browser_family",
+	DimensionOS:       "os_family",
+	DimensionDevice:   "device_class",
+}
+
+// DimensionCount est le nombre de clics d'un lien pour une valeur d'une dimension.
+type DimensionCount struct {
+	Value string
+	Count int
 }
 
 type GormClickRepository struct {
@@ -22,13 +107,134 @@ func NewClickRepository(db *gorm.DB) *GormClickRepository {
 	return &GormClickRepository{db: db}
 }
 
+// CreateClick enregistre un clic et incrémente les rollups horaire et journalier
+// correspondants dans la même transaction.
 func (r *GormClickRepository) CreateClick(click *models.Click) error {
-	if err := r.db.Create(click).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(click).Error; err != nil {
+			return err
+		}
+		return incrementRollups(tx, []*models.Click{click})
+	})
+	if err != nil {
 		return fmt.Errorf("failed to create click: %w", err)
 	}
 	return nil
 }
 
+// clickInsertBatchSize borne le nombre de clics insérés par requête, pour rester sous la limite
+// de paramètres des requêtes SQLite.
+const clickInsertBatchSize = 200
+
+// CreateClicks enregistre un lot de clics et incrémente les rollups correspondants dans une seule
+// transaction : soit tout le lot est enregistré, soit aucun clic ne l'est. En cas d'échec, les IDs
+// des clics sont remis à zéro pour que le lot puisse être soumis à nouveau.
+func (r *GormClickRepository) CreateClicks(clicks []*models.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(clicks, clickInsertBatchSize).Error; err != nil {
+			return err
+		}
+		return incrementRollups(tx, clicks)
+	})
+	if err != nil {
+		for _, click := range clicks {
+			click.ID = 0
+		}
+		return fmt.Errorf("failed to create clicks: %w", err)
+	}
+	return nil
+}
+
+// IsTransientError indique si err est un conflit d'accès passager à la base (base SQLite verrouillée
+// par une autre connexion, interblocage ou attente de verrou expirée sous PostgreSQL et MySQL),
+// après lequel l'opération peut être retentée.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "40001", "40P01", "55P03": // serialization_failure, deadlock_detected, lock_not_available
+			return true
+		}
+		return false
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// ER_LOCK_WAIT_TIMEOUT, ER_LOCK_DEADLOCK
+		return mysqlErr.Number == 1205 || mysqlErr.Number == 1213
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "database table is locked")
+}
+
+// incrementRollups ajoute des clics aux rollups horaires et journaliers de leurs liens,
+// avec une seule mise à jour par rollup concerné.
+func incrementRollups(tx *gorm.DB, clicks []*models.Click) error {
+	hourly := make(map[rollupKey]*models.HourlyClickRollup)
+	daily := make(map[rollupKey]*models.DailyClickRollup)
+	var hourlyRows []*models.HourlyClickRollup
+	var dailyRows []*models.DailyClickRollup
+
+	for _, click := range clicks {
+		hour := click.Timestamp.UTC().Truncate(time.Hour)
+		hourKey := rollupKey{linkID: click.LinkID, bucket: hour.Unix()}
+		if hourly[hourKey] == nil {
+			hourly[hourKey] = &models.HourlyClickRollup{LinkID: click.LinkID, Bucket: hour}
+			hourlyRows = append(hourlyRows, hourly[hourKey])
+		}
+		day := truncateToDay(hour)
+		dayKey := rollupKey{linkID: click.LinkID, bucket: day.Unix()}
+		if daily[dayKey] == nil {
+			daily[dayKey] = &models.DailyClickRollup{LinkID: click.LinkID, Bucket: day}
+			dailyRows = append(dailyRows, daily[dayKey])
+		}
+
+		if click.IsBot {
+			hourly[hourKey].BotClicks++
+			daily[dayKey].BotClicks++
+		} else {
+			hourly[hourKey].HumanClicks++
+			daily[dayKey].HumanClicks++
+		}
+	}
+
+	for _, rollup := range hourlyRows {
+		if err := upsertRollup(tx, rollup, rollup.HumanClicks, rollup.BotClicks); err != nil {
+			return err
+		}
+	}
+	for _, rollup := range dailyRows {
+		if err := upsertRollup(tx, rollup, rollup.HumanClicks, rollup.BotClicks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsertRollup insère le rollup ou, s'il existe déjà, lui ajoute human et bot clics.
+func upsertRollup(tx *gorm.DB, rollup interface{}, human, bot int) error {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(rollup); err != nil {
+		return err
+	}
+	table := stmt.Schema.Table
+
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "link_id"}, {Name: "bucket"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"human_clicks": gorm.Expr(table+".human_clicks + ?", human),
+			"bot_clicks":   gorm.Expr(table+".bot_clicks + ?", bot),
+		}),
+	}).Create(rollup).Error
+}
+
 func (r *GormClickRepository) GetClicksByLinkID(linkID uint) ([]models.Click, error) {
 	var clicks []models.Click
 	if err := r.db.Where("link_id = ?", linkID).Find(&clicks).Error; err != nil {
@@ -37,10 +243,429 @@ func (r *GormClickRepository) GetClicksByLinkID(linkID uint) ([]models.Click, er
 	return clicks, nil
 }
 
+// CountClicksByLinkID compte les clics d'un lien, robots compris, à partir des rollups journaliers.
 func (r *GormClickRepository) CountClicksByLinkID(linkID uint) (int, error) {
+	counts, err := r.CountClicksByKind(linkID)
+	if err != nil {
+		return 0, err
+	}
+	return counts.Total(), nil
+}
+
+// CountClicksByKind compte séparément les clics humains et les clics de robots d'un lien,
+// à partir des rollups journaliers.
+func (r *GormClickRepository) CountClicksByKind(linkID uint) (ClickCounts, error) {
+	var counts ClickCounts
+	err := r.db.Model(&models.DailyClickRollup{}).
+		Select("COALESCE(SUM(human_clicks), 0) AS human, COALESCE(SUM(bot_clicks), 0) AS bot").
+		Where("link_id = ?", linkID).
+		Scan(&counts).Error
+	if err != nil {
+		return ClickCounts{}, fmt.Errorf("failed to count clicks: %w", err)
+	}
+	return counts, nil
+}
+
+// CountClicksByInterval regroupe les clics d'un lien entre from (inclus) et to (exclu) par heure,
+// jour ou semaine (commençant le lundi), en UTC, à partir des rollups horaires ou journaliers.
+// from et to doivent être alignés sur l'intervalle. Seuls les intervalles contenant des clics sont retournés.
+func (r *GormClickRepository) CountClicksByInterval(linkID uint, from, to time.Time, interval string) ([]ClickBucket, error) {
+	var model interface{}
+	switch interval {
+	case IntervalHour:
+		model = &models.HourlyClickRollup{}
+	case IntervalDay, IntervalWeek:
+		model = &models.DailyClickRollup{}
+	default:
+		return nil, fmt.Errorf("unsupported interval '%s'", interval)
+	}
+
+	var rows []struct {
+		Bucket      time.Time
+		HumanClicks int
+		BotClicks   int
+	}
+	err := r.db.Model(model).
+		Select("bucket, human_clicks, bot_clicks").
+		Where("link_id = ? AND bucket >= ? AND bucket < ?", linkID, from.UTC(), to.UTC()).
+		Order("bucket").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate clicks: %w", err)
+	}
+
+	var buckets []ClickBucket
+	for _, row := range rows {
+		start := row.Bucket.UTC()
+		if interval == IntervalWeek {
+			start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		}
+		if n := len(buckets); n > 0 && buckets[n-1].Start.Equal(start) {
+			buckets[n-1].Human += row.HumanClicks
+			buckets[n-1].Bot += row.BotClicks
+			continue
+		}
+		buckets = append(buckets, ClickBucket{Start: start, ClickCounts: ClickCounts{Human: row.HumanClicks, Bot: row.BotClicks}})
+	}
+	return buckets, nil
+}
+
+// firstUnpurgedDay retourne la première journée (UTC) dont aucun clic n'a été supprimé par
+// DeleteClicksBefore, ou une date nulle si aucun clic n'a été supprimé.
+func (r *GormClickRepository) firstUnpurgedDay() (time.Time, error) {
+	var purge models.ClickPurge
+	if err := r.db.Order("purged_before DESC").Limit(1).Find(&purge).Error; err != nil || purge.ID == 0 {
+		return time.Time{}, err
+	}
+	purgedBefore := purge.PurgedBefore.UTC()
+	day := truncateToDay(purgedBefore)
+	if day.Before(purgedBefore) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// rollupKey identifie un rollup : un lien et le début de son intervalle.
+type rollupKey struct {
+	linkID uint
+	bucket int64
+}
+
+// rebuildBatchSize est le nombre de clics lus par requête lors d'une reconstruction des rollups.
+const rebuildBatchSize = 5000
+
+// RebuildRollups recalcule, dans une transaction, les rollups horaires et journaliers des journées
+// (UTC) comprises entre from (inclus) et to (exclu) à partir des clics bruts. Une borne nulle n'est
+// pas limitée. Les journées dont des clics ont été supprimés par DeleteClicksBefore ne peuvent plus
+// être recalculées : si la plage en contient, models.ErrClicksPurged est retourné.
+func (r *GormClickRepository) RebuildRollups(from, to time.Time) (RollupRebuildResult, error) {
+	if !from.IsZero() {
+		from = truncateToDay(from)
+	}
+	if !to.IsZero() {
+		to = truncateToDay(to)
+	}
+
+	firstDay, err := r.firstUnpurgedDay()
+	if err != nil {
+		return RollupRebuildResult{}, fmt.Errorf("failed to rebuild rollups: %w", err)
+	}
+	if !firstDay.IsZero() && (from.IsZero() || from.Before(firstDay)) {
+		return RollupRebuildResult{}, fmt.Errorf("%w before %s: rollups can only be rebuilt from that day",
+			models.ErrClicksPurged, firstDay.Format("2006-01-02"))
+	}
+	inRange := func(ts time.Time) bool {
+		return (from.IsZero() || !ts.Before(from)) && (to.IsZero() || ts.Before(to))
+	}
+
+	var result RollupRebuildResult
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.HourlyClickRollup{}, &models.DailyClickRollup{}} {
+			// "1 = 1" autorise la suppression de tous les rollups quand aucune borne n'est donnée.
+			if err := bucketRange(tx.Where("1 = 1"), from, to, "bucket").Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		hourly := make(map[rollupKey]*models.HourlyClickRollup)
+		daily := make(map[rollupKey]*models.DailyClickRollup)
+		// Les horodatages peuvent avoir été stockés avec des fuseaux différents : la sélection SQL
+		// est élargie d'un jour et le filtrage exact se fait après conversion en UTC.
+		selectFrom, selectTo := from, to
+		if !from.IsZero() {
+			selectFrom = from.AddDate(0, 0, -1)
+		}
+		if !to.IsZero() {
+			selectTo = to.AddDate(0, 0, 1)
+		}
+		query := bucketRange(tx.Model(&models.Click{}), selectFrom, selectTo, "timestamp")
+
+		var lastID uint
+		for {
+			var clicks []struct {
+				ID        uint
+				LinkID    uint
+				Timestamp time.Time
+				IsBot     bool
+			}
+			err := query.Session(&gorm.Session{}).
+				Select("id, link_id, timestamp, is_bot").
+				Where("id > ?", lastID).
+				Order("id").
+				Limit(rebuildBatchSize).
+				Scan(&clicks).Error
+			if err != nil {
+				return err
+			}
+
+			for _, click := range clicks {
+				ts := click.Timestamp.UTC()
+				if !inRange(ts) {
+					continue
+				}
+				result.Clicks++
+
+				hour := ts.Truncate(time.Hour)
+				hourKey := rollupKey{linkID: click.LinkID, bucket: hour.Unix()}
+				if hourly[hourKey] == nil {
+					hourly[hourKey] = &models.HourlyClickRollup{LinkID: click.LinkID, Bucket: hour}
+				}
+				day := truncateToDay(ts)
+				dayKey := rollupKey{linkID: click.LinkID, bucket: day.Unix()}
+				if daily[dayKey] == nil {
+					daily[dayKey] = &models.DailyClickRollup{LinkID: click.LinkID, Bucket: day}
+				}
+
+				if click.IsBot {
+					hourly[hourKey].BotClicks++
+					daily[dayKey].BotClicks++
+				} else {
+					hourly[hourKey].HumanClicks++
+					daily[dayKey].HumanClicks++
+				}
+			}
+
+			if len(clicks) < rebuildBatchSize {
+				break
+			}
+			lastID = clicks[len(clicks)-1].ID
+		}
+
+		hourlyRows := make([]*models.HourlyClickRollup, 0, len(hourly))
+		for _, rollup := range hourly {
+			hourlyRows = append(hourlyRows, rollup)
+		}
+		dailyRows := make([]*models.DailyClickRollup, 0, len(daily))
+		for _, rollup := range daily {
+			dailyRows = append(dailyRows, rollup)
+		}
+		if len(hourlyRows) > 0 {
+			if err := tx.CreateInBatches(hourlyRows, 500).Error; err != nil {
+				return err
+			}
+		}
+		if len(dailyRows) > 0 {
+			if err := tx.CreateInBatches(dailyRows, 500).Error; err != nil {
+				return err
+			}
+		}
+		result.HourlyBuckets, result.DailyBuckets = len(hourlyRows), len(dailyRows)
+		return nil
+	})
+	if err != nil {
+		return RollupRebuildResult{}, fmt.Errorf("failed to rebuild rollups: %w", err)
+	}
+	return result, nil
+}
+
+// bucketRange restreint query à column >= from et column < to, une borne nulle n'étant pas appliquée.
+func bucketRange(query *gorm.DB, from, to time.Time, column string) *gorm.DB {
+	if !from.IsZero() {
+		query = query.Where(column+" >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where(column+" < ?", to)
+	}
+	return query
+}
+
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// TopReferrers retourne les hôtes référents ayant généré le plus de clics pour un lien,
+// clics purgés compris.
+func (r *GormClickRepository) TopReferrers(linkID uint, limit int) ([]ReferrerCount, error) {
+	counts, err := r.countByValue(linkID, dimensionReferrer, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top referrers: %w", err)
+	}
+	referrers := make([]ReferrerCount, len(counts))
+	for i, count := range counts {
+		referrers[i] = ReferrerCount{Host: count.Value, Count: count.Count}
+	}
+	return referrers, nil
+}
+
+// CountClicksByDimension retourne le nombre de clics d'un lien par valeur d'une dimension
+// (navigateur, système ou classe d'appareil), clics purgés compris, des plus fréquentes aux
+// moins fréquentes.
+func (r *GormClickRepository) CountClicksByDimension(linkID uint, dimension string, limit int) ([]DimensionCount, error) {
+	if _, ok := dimensionColumns[dimension]; !ok {
+		return nil, fmt.Errorf("unsupported dimension %q", dimension)
+	}
+
+	counts, err := r.countByValue(linkID, dimension, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count clicks by %s: %w", dimension, err)
+	}
+	return counts, nil
+}
+
+// countByValue compte les clics bruts et purgés d'un lien par valeur d'une dimension de
+// purgedDimensionColumns, et retourne au plus limit valeurs, par nombre de clics décroissant.
+func (r *GormClickRepository) countByValue(linkID uint, dimension string, limit int) ([]DimensionCount, error) {
+	var purged []DimensionCount
+	err := r.db.Model(&models.PurgedClickTotal{}).
+		Select("value, clicks AS count").
+		Where("link_id = ? AND dimension = ?", linkID, dimension).
+		Scan(&purged).Error
+	if err != nil {
+		return nil, err
+	}
+
+	column := purgedDimensionColumns[dimension]
+	query := r.db.Model(&models.Click{}).
+		Select(column+" AS value, COUNT(*) AS count").
+		Where("link_id = ?", linkID).
+		Group(column)
+	var counts []DimensionCount
+	if len(purged) == 0 {
+		// Sans clics purgés, la base trie et limite elle-même.
+		err := query.Order("count DESC, value").Limit(limit).Scan(&counts).Error
+		return counts, err
+	}
+	if err := query.Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]int, len(counts))
+	for i, count := range counts {
+		positions[count.Value] = i
+	}
+	for _, count := range purged {
+		if i, ok := positions[count.Value]; ok {
+			counts[i].Count += count.Count
+		} else {
+			counts = append(counts, count)
+		}
+	}
+	slices.SortFunc(counts, func(a, b DimensionCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Value, b.Value)
+	})
+	return counts[:min(limit, len(counts))], nil
+}
+
+// identifiableClickCondition sélectionne les clics contenant encore des données personnelles.
+const identifiableClickCondition = "ip_address <> '' OR user_agent <> '' OR referrer <> ''"
+
+// CountClicksBefore compte les clics antérieurs à before, ou seulement ceux qui contiennent
+// encore des données personnelles si identifiableOnly est vrai.
+func (r *GormClickRepository) CountClicksBefore(before time.Time, identifiableOnly bool) (int64, error) {
+	query := r.db.Model(&models.Click{}).Where("timestamp < ?", before)
+	if identifiableOnly {
+		query = query.Where(identifiableClickCondition)
+	}
+
 	var count int64
-	if err := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count).Error; err != nil {
+	if err := query.Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count clicks: %w", err)
 	}
-	return int(count), nil
+	return count, nil
+}
+
+// DeleteClicksBefore supprime les clics antérieurs à before par lots de batchSize,
+// pour ne pas verrouiller la table pendant toute la purge, et retourne le nombre de clics supprimés.
+// Dans la transaction de chaque lot, les clics sont ajoutés aux totaux des clics purgés, pour que
+// les ventilations par référent, navigateur, système et appareil gardent leur historique, et la
+// purge est enregistrée, pour que RebuildRollups refuse les journées concernées.
+func (r *GormClickRepository) DeleteClicksBefore(before time.Time, batchSize int) (int64, error) {
+	var purge *models.ClickPurge
+	return r.inBatches(before, batchSize, "", func(ids []uint) (int64, error) {
+		var deleted int64
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			if err := addPurgedTotals(tx, ids); err != nil {
+				return err
+			}
+			result := tx.Where("id IN ?", ids).Delete(&models.Click{})
+			if result.Error != nil {
+				return result.Error
+			}
+			deleted = result.RowsAffected
+
+			if purge == nil {
+				purge = &models.ClickPurge{PurgedBefore: before.UTC(), Deleted: deleted}
+				return tx.Create(purge).Error
+			}
+			return tx.Model(purge).Update("deleted", gorm.Expr("deleted + ?", deleted)).Error
+		})
+		return deleted, err
+	})
+}
+
+// addPurgedTotals ajoute les clics ids, par lien, aux totaux des clics purgés de chaque dimension.
+func addPurgedTotals(tx *gorm.DB, ids []uint) error {
+	for dimension, column := range purgedDimensionColumns {
+		var rows []struct {
+			LinkID uint
+			Value  string
+			Clicks int
+		}
+		err := tx.Model(&models.Click{}).
+			Select("link_id, "+column+" AS value, COUNT(*) AS clicks").
+			Where("id IN ?", ids).
+			Group("link_id, " + column).
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			total := &models.PurgedClickTotal{LinkID: row.LinkID, Dimension: dimension, Value: row.Value, Clicks: row.Clicks}
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "link_id"}, {Name: "dimension"}, {Name: "value"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"clicks": gorm.Expr("purged_click_totals.clicks + ?", row.Clicks)}),
+			}).Create(total).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AnonymizeClicksBefore efface l'IP, le User-Agent brut et le referrer complet des clics antérieurs
+// à before, par lots de batchSize. Les champs agrégés (hôte référent, navigateur, système, appareil,
+// robot) sont conservés pour les statistiques. Retourne le nombre de clics anonymisés.
+func (r *GormClickRepository) AnonymizeClicksBefore(before time.Time, batchSize int) (int64, error) {
+	return r.inBatches(before, batchSize, identifiableClickCondition, func(ids []uint) (int64, error) {
+		result := r.db.Model(&models.Click{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{"ip_address": "", "user_agent": "", "referrer": ""})
+		return result.RowsAffected, result.Error
+	})
+}
+
+// inBatches applique apply aux clics antérieurs à before (et vérifiant condition si elle est
+// non vide), par lots d'IDs, jusqu'à ce qu'il n'y en ait plus. apply doit faire sortir les clics
+// traités de la sélection.
+func (r *GormClickRepository) inBatches(before time.Time, batchSize int, condition string, apply func(ids []uint) (int64, error)) (int64, error) {
+	var total int64
+	for {
+		query := r.db.Model(&models.Click{}).Where("timestamp < ?", before)
+		if condition != "" {
+			query = query.Where(condition)
+		}
+
+		var ids []uint
+		if err := query.Order("id").Limit(batchSize).Pluck("id", &ids).Error; err != nil {
+			return total, fmt.Errorf("failed to select clicks: %w", err)
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		affected, err := apply(ids)
+		if err != nil {
+			return total, fmt.Errorf("failed to purge clicks: %w", err)
+		}
+		total += affected
+		if len(ids) < batchSize {
+			return total, nil
+		}
+	}
 }
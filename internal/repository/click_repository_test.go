@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestClickRepository(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *gorm.DB) {
+		mustMigrate(t, db)
+		link := mustCreateLink(t, db, &models.Link{ShortCode: "abc123", LongURL: "https://example.com"})
+		repo := NewClickRepository(db)
+
+		// Lundi 10 mars 2025.
+		base := time.Date(2025, 3, 10, 9, 15, 0, 0, time.UTC)
+		newClick := func(at time.Time, referrer, browser string, bot bool) *models.Click {
+			return &models.Click{
+				LinkID: link.ID, Timestamp: at, IPAddress: "203.0.113.0", UserAgent: "Mozilla/5.0",
+				ReferrerHost: referrer, BrowserFamily: browser, IsBot: bot,
+			}
+		}
+		clicks := []*models.Click{
+			newClick(base, "news.ycombinator.com", "Firefox", false),
+			newClick(base.Add(10*time.Minute), "news.ycombinator.com", "Chrome", false),
+			newClick(base.Add(2*time.Hour), "", "Other", true),
+			newClick(base.AddDate(0, 0, 1), "t.co", "Chrome", false),
+			newClick(base.AddDate(0, 0, 7), "", "Chrome", false),
+		}
+		if err := repo.CreateClicks(clicks); err != nil {
+			t.Fatalf("Failed to create clicks: %v", err)
+		}
+		for _, click := range clicks {
+			if click.ID == 0 {
+				t.Fatal("Expected created clicks to have an ID")
+			}
+		}
+
+		assertCounts := func(when string) {
+			t.Helper()
+			counts, err := repo.CountClicksByKind(link.ID)
+			if err != nil {
+				t.Fatalf("Failed to count clicks %s: %v", when, err)
+			}
+			if counts.Human != 4 || counts.Bot != 1 {
+				t.Errorf("Expected 4 human and 1 bot clicks %s, got %+v", when, counts)
+			}
+		}
+		assertCounts("after insert")
+
+		day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+		intervals := []struct {
+			interval string
+			from, to time.Time
+			expected string
+		}{
+			{IntervalHour, day, day.Add(24 * time.Hour), "[09:00=2+0 11:00=0+1]"},
+			{IntervalDay, day, day.AddDate(0, 0, 14), "[03-10=2+1 03-11=1+0 03-17=1+0]"},
+			{IntervalWeek, day, day.AddDate(0, 0, 14), "[03-10=3+1 03-17=1+0]"},
+		}
+		for _, tt := range intervals {
+			buckets, err := repo.CountClicksByInterval(link.ID, tt.from, tt.to, tt.interval)
+			if err != nil {
+				t.Fatalf("Failed to count clicks by %s: %v", tt.interval, err)
+			}
+			if got := formatBuckets(buckets, tt.interval); got != tt.expected {
+				t.Errorf("Expected %s buckets %s, got %s", tt.interval, tt.expected, got)
+			}
+		}
+
+		referrers, err := repo.TopReferrers(link.ID, 2)
+		if err != nil {
+			t.Fatalf("Failed to get top referrers: %v", err)
+		}
+		if len(referrers) != 2 || referrers[0] != (ReferrerCount{Host: "", Count: 2}) ||
+			referrers[1] != (ReferrerCount{Host: "news.ycombinator.com", Count: 2}) {
+			t.Errorf("Unexpected top referrers: %+v", referrers)
+		}
+
+		browsers, err := repo.CountClicksByDimension(link.ID, DimensionBrowser, 10)
+		if err != nil {
+			t.Fatalf("Failed to count clicks by browser: %v", err)
+		}
+		if len(browsers) != 3 || browsers[0] != (DimensionCount{Value: "Chrome", Count: 3}) {
+			t.Errorf("Unexpected browser breakdown: %+v", browsers)
+		}
+
+		result, err := repo.RebuildRollups(time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatalf("Failed to rebuild rollups: %v", err)
+		}
+		if result.Clicks != 5 || result.HourlyBuckets != 4 || result.DailyBuckets != 3 {
+			t.Errorf("Unexpected rebuild result: %+v", result)
+		}
+		assertCounts("after rebuild")
+
+		cutoff := base.AddDate(0, 0, 2)
+		if count, err := repo.CountClicksBefore(cutoff, false); err != nil || count != 4 {
+			t.Errorf("Expected 4 clicks before the cutoff, got %d (%v)", count, err)
+		}
+		if anonymized, err := repo.AnonymizeClicksBefore(cutoff, 3); err != nil || anonymized != 4 {
+			t.Errorf("Expected 4 anonymized clicks, got %d (%v)", anonymized, err)
+		}
+		if count, err := repo.CountClicksBefore(cutoff, true); err != nil || count != 0 {
+			t.Errorf("Expected no identifiable clicks left before the cutoff, got %d (%v)", count, err)
+		}
+		if deleted, err := repo.DeleteClicksBefore(cutoff, 3); err != nil || deleted != 4 {
+			t.Errorf("Expected 4 deleted clicks, got %d (%v)", deleted, err)
+		}
+		// Les compteurs sont lus dans les rollups, qui survivent à la purge.
+		assertCounts("after purge")
+
+		// Les ventilations ajoutent les totaux des clics purgés aux clics restants.
+		if after, err := repo.TopReferrers(link.ID, 2); err != nil || fmt.Sprint(after) != fmt.Sprint(referrers) {
+			t.Errorf("Expected top referrers %v after purge, got %v (%v)", referrers, after, err)
+		}
+		if after, err := repo.CountClicksByDimension(link.ID, DimensionBrowser, 10); err != nil || fmt.Sprint(after) != fmt.Sprint(browsers) {
+			t.Errorf("Expected browser breakdown %v after purge, got %v (%v)", browsers, after, err)
+		}
+
+		// Les journées purgées ne peuvent plus être reconstruites à partir des clics bruts.
+		firstDay := time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC)
+		for _, from := range []time.Time{{}, firstDay.AddDate(0, 0, -1)} {
+			if _, err := repo.RebuildRollups(from, time.Time{}); !errors.Is(err, models.ErrClicksPurged) {
+				t.Errorf("Expected rebuilding from %v to be refused, got %v", from, err)
+			}
+		}
+		if result, err := repo.RebuildRollups(firstDay, time.Time{}); err != nil || result.Clicks != 1 {
+			t.Errorf("Expected the days after the purge to be rebuilt, got %+v (%v)", result, err)
+		}
+		assertCounts("after partial rebuild")
+	})
+}
+
+func TestClickRepository_Rollups(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *gorm.DB) {
+		mustMigrate(t, db)
+		link := mustCreateLink(t, db, &models.Link{ShortCode: "abc123", LongURL: "https://example.com"})
+		other := mustCreateLink(t, db, &models.Link{ShortCode: "def456", LongURL: "https://example.org"})
+		repo := NewClickRepository(db)
+
+		base := time.Date(2025, 3, 10, 9, 15, 0, 0, time.UTC)
+		newClick := func(linkID uint, at time.Time, bot bool) *models.Click {
+			return &models.Click{LinkID: linkID, Timestamp: at, IPAddress: "203.0.113.0", IsBot: bot}
+		}
+		if err := repo.CreateClick(newClick(link.ID, base, false)); err != nil {
+			t.Fatalf("Failed to create click: %v", err)
+		}
+		// Le lot incrémente les rollups existants et en crée de nouveaux.
+		if err := repo.CreateClicks([]*models.Click{
+			newClick(link.ID, base.Add(5*time.Minute), false),
+			newClick(link.ID, base.Add(10*time.Minute), true),
+			newClick(link.ID, base.Add(time.Hour), false),
+			newClick(link.ID, base.AddDate(0, 0, 1), true),
+			newClick(link.ID, base.AddDate(0, 0, 2), false),
+			newClick(other.ID, base, false),
+		}); err != nil {
+			t.Fatalf("Failed to create clicks: %v", err)
+		}
+
+		hourly := dumpRollups(t, db, &models.HourlyClickRollup{})
+		daily := dumpRollups(t, db, &models.DailyClickRollup{})
+		expectedHourly := fmt.Sprintf("[%[1]d@03-10T09=2+1 %[2]d@03-10T09=1+0 %[1]d@03-10T10=1+0 %[1]d@03-11T09=0+1 %[1]d@03-12T09=1+0]", link.ID, other.ID)
+		expectedDaily := fmt.Sprintf("[%[1]d@03-10T00=3+1 %[2]d@03-10T00=1+0 %[1]d@03-11T00=0+1 %[1]d@03-12T00=1+0]", link.ID, other.ID)
+		if hourly != expectedHourly {
+			t.Errorf("Expected hourly rollups %s, got %s", expectedHourly, hourly)
+		}
+		if daily != expectedDaily {
+			t.Errorf("Expected daily rollups %s, got %s", expectedDaily, daily)
+		}
+
+		// Une reconstruction sur une plage ne touche que les journées de la plage.
+		for _, model := range []interface{}{&models.HourlyClickRollup{}, &models.DailyClickRollup{}} {
+			if err := db.Model(model).Where("1 = 1").Update("human_clicks", 99).Error; err != nil {
+				t.Fatalf("Failed to corrupt rollups: %v", err)
+			}
+		}
+		result, err := repo.RebuildRollups(base, base.AddDate(0, 0, 2))
+		if err != nil {
+			t.Fatalf("Failed to rebuild rollups: %v", err)
+		}
+		if result.Clicks != 6 || result.HourlyBuckets != 4 || result.DailyBuckets != 3 {
+			t.Errorf("Unexpected rebuild result: %+v", result)
+		}
+		if counts, err := repo.CountClicksByKind(link.ID); err != nil || counts.Human != 102 || counts.Bot != 2 {
+			t.Errorf("Expected the day after the range to keep its corrupted rollup, got %+v (%v)", counts, err)
+		}
+
+		if _, err := repo.RebuildRollups(time.Time{}, time.Time{}); err != nil {
+			t.Fatalf("Failed to rebuild rollups: %v", err)
+		}
+		if got := dumpRollups(t, db, &models.HourlyClickRollup{}); got != hourly {
+			t.Errorf("Expected the rebuild to reproduce hourly rollups %s, got %s", hourly, got)
+		}
+		if got := dumpRollups(t, db, &models.DailyClickRollup{}); got != daily {
+			t.Errorf("Expected the rebuild to reproduce daily rollups %s, got %s", daily, got)
+		}
+	})
+}
+
+// dumpRollups écrit les rollups de model sous la forme lien@début=humains+robots, triés par début puis par lien.
+func dumpRollups(t *testing.T, db *gorm.DB, model interface{}) string {
+	t.Helper()
+	var rows []struct {
+		LinkID      uint
+		Bucket      time.Time
+		HumanClicks int
+		BotClicks   int
+	}
+	if err := db.Model(model).Order("bucket, link_id").Scan(&rows).Error; err != nil {
+		t.Fatalf("Failed to read rollups: %v", err)
+	}
+	var parts []string
+	for _, row := range rows {
+		parts = append(parts, fmt.Sprintf("%d@%s=%d+%d", row.LinkID, row.Bucket.UTC().Format("01-02T15"), row.HumanClicks, row.BotClicks))
+	}
+	return fmt.Sprint(parts)
+}
+
+// formatBuckets écrit chaque intervalle sous la forme début=humains+robots.
+func formatBuckets(buckets []ClickBucket, interval string) string {
+	layout := "01-02"
+	if interval == IntervalHour {
+		layout = "15:04"
+	}
+	var parts []string
+	for _, bucket := range buckets {
+		parts = append(parts, fmt.Sprintf("%s=%d+%d", bucket.Start.Format(layout), bucket.Human, bucket.Bot))
+	}
+	return fmt.Sprint(parts)
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("database is locked"), true},
+		{fmt.Errorf("failed to create clicks: %w", errors.New("database table is locked")), true},
+		{&pgconn.PgError{Code: "40P01"}, true},
+		{fmt.Errorf("failed to create clicks: %w", &pgconn.PgError{Code: "40001"}), true},
+		{&pgconn.PgError{Code: "23505"}, false},
+		{&mysql.MySQLError{Number: 1213}, true},
+		{&mysql.MySQLError{Number: 1205}, true},
+		{&mysql.MySQLError{Number: 1062}, false},
+		{errors.New("no such table: clicks"), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTransientError(tt.err); got != tt.expected {
+			t.Errorf("IsTransientError(%v) = %v, expected %v", tt.err, got, tt.expected)
+		}
+	}
+}
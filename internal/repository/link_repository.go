@@ -1,17 +1,74 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 )
 
 type LinkRepository interface {
 	CreateLink(link *models.Link) error
 	GetLinkByShortCode(shortCode string) (*models.Link, error)
+	GetDeletedLinkByShortCode(shortCode string) (*models.Link, error)
+	UpdateLink(link *models.Link) error
+	DeleteLink(link *models.Link) error
+	RestoreLink(link *models.Link) error
 	GetAllLinks() ([]models.Link, error)
+	ListLinks(query LinkListQuery) ([]LinkWithClicks, error)
+	GetActiveLinks() ([]models.Link, error)
 	CountClicksByLinkID(linkID uint) (int, error)
+	MarkExpiredLinks(now time.Time) (int64, error)
+	CountShortCodes() (int64, error)
+	ListShortCodes(afterID uint, limit int) ([]ShortCodeEntry, error)
+	MaxLinkID() (uint, error)
+}
+
+const (
+	LinkSortCreatedAt = "created_at"
+	LinkSortClicks    = "clicks"
+)
+
+// LinkListQuery décrit une page de la liste des liens : filtres, tri et position du curseur.
+type LinkListQuery struct {
+	// OwnerID restreint la liste aux liens d'une clé d'API. Nil pour lister tous les liens.
+	OwnerID *uint
+	// Domain filtre sur l'hôte de l'URL longue (sous-domaines inclus).
+	Domain string
+	// Search filtre sur une sous-chaîne de l'URL longue ou du code court.
+	Search        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// SortBy vaut LinkSortCreatedAt ou LinkSortClicks.
+	SortBy     string
+	Descending bool
+	// After positionne la page juste après ce lien dans l'ordre de tri. Nil pour la première page.
+	After *LinkCursor
+	Limit int
+}
+
+// LinkCursor identifie la position d'un lien dans un ordre de tri (valeur de tri + ID pour départager).
+type LinkCursor struct {
+	CreatedAt  time.Time `json:"created_at"`
+	ClickCount int       `json:"click_count"`
+	ID         uint      `json:"id"`
+}
+
+// LinkWithClicks associe un lien à son nombre de clics humains (hors robots).
+type LinkWithClicks struct {
+	models.Link
+	ClickCount int
+}
+
+// ShortCodeEntry associe un code court à l'ID de son lien.
+type ShortCodeEntry struct {
+	ID        uint
+	ShortCode string
 }
 
 type GormLinkRepository struct {
@@ -22,13 +79,45 @@ func NewLinkRepository(db *gorm.DB) *GormLinkRepository {
 	return &GormLinkRepository{db: db}
 }
 
+// CreateLink insère un lien. L'index unique sur short_code porte sur toutes les lignes de la table,
+// y compris les liens supprimés logiquement : si le code est déjà attribué, y compris par une
+// insertion concurrente, CreateLink retourne models.ErrDuplicateShortCode.
 func (r *GormLinkRepository) CreateLink(link *models.Link) error {
 	if err := r.db.Create(link).Error; err != nil {
+		if IsShortCodeConflict(err) {
+			return models.ErrDuplicateShortCode
+		}
 		return fmt.Errorf("failed to create link: %w", err)
 	}
 	return nil
 }
 
+// shortCodeIndex est le nom de l'index unique sur links.short_code, identique pour tous les drivers.
+const shortCodeIndex = "idx_links_short_code"
+
+// IsShortCodeConflict indique si err est la violation de l'index unique sur links.short_code
+// (SQLite, PostgreSQL ou MySQL).
+func IsShortCodeConflict(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505" && pgErr.ConstraintName == shortCodeIndex // unique_violation
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// ER_DUP_ENTRY. Le message ("Duplicate entry '...' for key 'links.idx_links_short_code'")
+		// varie selon les versions et les serveurs compatibles : seule la clé primaire est exclue,
+		// la table links n'a pas d'autre index unique.
+		return mysqlErr.Number == 1062 && !strings.Contains(strings.ToLower(mysqlErr.Message), "primary")
+	}
+
+	// SQLite nomme la colonne et non l'index : "UNIQUE constraint failed: links.short_code".
+	return strings.Contains(err.Error(), "UNIQUE constraint failed: links.short_code")
+}
+
 func (r *GormLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
 	var link models.Link
 	if err := r.db.Where("short_code = ?", shortCode).First(&link).Error; err != nil {
@@ -37,6 +126,67 @@ func (r *GormLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link,
 	return &link, nil
 }
 
+// GetDeletedLinkByShortCode récupère un lien supprimé logiquement, en vue de sa restauration.
+func (r *GormLinkRepository) GetDeletedLinkByShortCode(shortCode string) (*models.Link, error) {
+	var link models.Link
+	if err := r.db.Unscoped().Where("short_code = ? AND deleted_at IS NOT NULL", shortCode).First(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *GormLinkRepository) UpdateLink(link *models.Link) error {
+	if err := r.db.Save(link).Error; err != nil {
+		return fmt.Errorf("failed to update link: %w", err)
+	}
+	return nil
+}
+
+func (r *GormLinkRepository) DeleteLink(link *models.Link) error {
+	if err := r.db.Delete(link).Error; err != nil {
+		return fmt.Errorf("failed to delete link: %w", err)
+	}
+	return nil
+}
+
+func (r *GormLinkRepository) RestoreLink(link *models.Link) error {
+	if err := r.db.Unscoped().Model(link).Update("deleted_at", nil).Error; err != nil {
+		return fmt.Errorf("failed to restore link: %w", err)
+	}
+	return nil
+}
+
+// CountShortCodes compte les codes courts attribués, y compris ceux des liens supprimés logiquement.
+func (r *GormLinkRepository) CountShortCodes() (int64, error) {
+	var count int64
+	if err := r.db.Unscoped().Model(&models.Link{}).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count short codes: %w", err)
+	}
+	return count, nil
+}
+
+// ListShortCodes retourne au plus limit codes courts, par ID croissant, des liens d'ID supérieur
+// à afterID ; les liens supprimés logiquement sont inclus.
+func (r *GormLinkRepository) ListShortCodes(afterID uint, limit int) ([]ShortCodeEntry, error) {
+	var entries []ShortCodeEntry
+	err := r.db.Unscoped().Model(&models.Link{}).Select("id", "short_code").
+		Where("id > ?", afterID).Order("id").Limit(limit).Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list short codes: %w", err)
+	}
+	return entries, nil
+}
+
+// MaxLinkID retourne le plus grand ID de lien attribué, y compris à un lien supprimé logiquement,
+// ou 0 si la table est vide.
+func (r *GormLinkRepository) MaxLinkID() (uint, error) {
+	var maxID uint
+	if err := r.db.Unscoped().Model(&models.Link{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error; err != nil {
+		return 0, fmt.Errorf("failed to get max link ID: %w", err)
+	}
+	return maxID, nil
+}
+
 func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
 	var links []models.Link
 	if err := r.db.Find(&links).Error; err != nil {
@@ -45,10 +195,116 @@ func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
 	return links, nil
 }
 
+// ListLinks retourne une page de liens filtrés et triés, avec leur nombre de clics humains.
+// La pagination se fait par curseur (keyset) sur la colonne de tri puis l'ID.
+func (r *GormLinkRepository) ListLinks(query LinkListQuery) ([]LinkWithClicks, error) {
+	clickCount := humanClickCount(r.db)
+	base := r.db.Model(&models.Link{}).Select("links.*, (?) AS click_count", clickCount)
+
+	if query.OwnerID != nil {
+		base = base.Where("owner_id = ?", *query.OwnerID)
+	}
+	if query.Domain != "" {
+		domain := escapeLike(strings.ToLower(query.Domain))
+		var conditions []string
+		var args []interface{}
+		for _, host := range []string{domain, "%." + domain} {
+			for _, suffix := range []string{"", "/%", ":%", "?%", "#%"} {
+				conditions = append(conditions, `LOWER(long_url) LIKE ? ESCAPE '!'`)
+				args = append(args, "%://"+host+suffix)
+			}
+		}
+		base = base.Where(strings.Join(conditions, " OR "), args...)
+	}
+	if query.Search != "" {
+		pattern := "%" + escapeLike(strings.ToLower(query.Search)) + "%"
+		base = base.Where(`LOWER(long_url) LIKE ? ESCAPE '!' OR LOWER(short_code) LIKE ? ESCAPE '!'`, pattern, pattern)
+	}
+	if query.CreatedAfter != nil {
+		base = base.Where("created_at >= ?", *query.CreatedAfter)
+	}
+	if query.CreatedBefore != nil {
+		base = base.Where("created_at < ?", *query.CreatedBefore)
+	}
+
+	sortColumn := "created_at"
+	if query.SortBy == LinkSortClicks {
+		sortColumn = "click_count"
+	}
+	direction, comparator := "ASC", ">"
+	if query.Descending {
+		direction, comparator = "DESC", "<"
+	}
+
+	page := r.db.Table("(?) AS l", base)
+	if query.After != nil {
+		var value interface{} = query.After.CreatedAt
+		if query.SortBy == LinkSortClicks {
+			value = query.After.ClickCount
+		}
+		page = page.Where(
+			fmt.Sprintf("l.%s %s ? OR (l.%s = ? AND l.id %s ?)", sortColumn, comparator, sortColumn, comparator),
+			value, value, query.After.ID,
+		)
+	}
+
+	var links []LinkWithClicks
+	err := page.
+		Order(fmt.Sprintf("l.%s %s, l.id %s", sortColumn, direction, direction)).
+		Limit(query.Limit).
+		Scan(&links).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	return links, nil
+}
+
+// escapeLike échappe les caractères spéciaux de LIKE pour une recherche littérale.
+// Le caractère d'échappement '!' est utilisé car '\' n'est pas interprété de la même façon par tous les SGBD.
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
+
+// GetActiveLinks retourne les liens qui ne sont ni expirés, ni désactivés, ni supprimés.
+func (r *GormLinkRepository) GetActiveLinks() ([]models.Link, error) {
+	var links []models.Link
+	if err := r.db.Where("expired = ? AND disabled = ?", false, false).Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to get active links: %w", err)
+	}
+	return links, nil
+}
+
+// MarkExpiredLinks marque comme expirés les liens dont la date d'expiration est dépassée
+// ou dont le budget de clics (humains) est épuisé, et retourne le nombre de liens mis à jour.
+func (r *GormLinkRepository) MarkExpiredLinks(now time.Time) (int64, error) {
+	clickCount := humanClickCount(r.db)
+
+	result := r.db.Model(&models.Link{}).
+		Where("expired = ?", false).
+		Where(r.db.Where("expires_at IS NOT NULL AND expires_at <= ?", now).
+			Or("max_clicks IS NOT NULL AND max_clicks <= (?)", clickCount)).
+		Update("expired", true)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to mark expired links: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
 func (r *GormLinkRepository) CountClicksByLinkID(linkID uint) (int, error) {
 	var count int64
-	if err := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count).Error; err != nil {
+	err := r.db.Model(&models.DailyClickRollup{}).
+		Select("COALESCE(SUM(human_clicks + bot_clicks), 0)").
+		Where("link_id = ?", linkID).
+		Scan(&count).Error
+	if err != nil {
 		return 0, fmt.Errorf("failed to count clicks: %w", err)
 	}
 	return int(count), nil
 }
+
+// humanClickCount est la sous-requête du nombre de clics humains de links.id, lue dans les rollups journaliers.
+func humanClickCount(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.DailyClickRollup{}).
+		Select("COALESCE(SUM(human_clicks), 0)").
+		Where("click_rollups_daily.link_id = links.id")
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestLinkRepository_ListLinks(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *gorm.DB) {
+		mustMigrate(t, db)
+		repo := NewLinkRepository(db)
+		created := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+		for i, url := range []string{
+			"https://example.com/100%_off",
+			"https://shop.example.com/cart",
+			"https://other.net/example.com",
+			"https://EXAMPLE.org:8443/docs",
+		} {
+			mustCreateLink(t, db, &models.Link{
+				ShortCode: string(rune('a'+i)) + "code",
+				LongURL:   url,
+				CreatedAt: created.Add(time.Duration(i) * time.Hour),
+			})
+		}
+		link, err := repo.GetLinkByShortCode("ccode")
+		if err != nil {
+			t.Fatalf("Failed to get link: %v", err)
+		}
+		clicks := []*models.Click{{LinkID: link.ID, Timestamp: created}, {LinkID: link.ID, Timestamp: created}}
+		if err := NewClickRepository(db).CreateClicks(clicks); err != nil {
+			t.Fatalf("Failed to create clicks: %v", err)
+		}
+
+		list := func(query LinkListQuery) []string {
+			t.Helper()
+			if query.Limit == 0 {
+				query.Limit = 10
+			}
+			links, err := repo.ListLinks(query)
+			if err != nil {
+				t.Fatalf("Failed to list links: %v", err)
+			}
+			var codes []string
+			for _, l := range links {
+				codes = append(codes, l.ShortCode)
+			}
+			return codes
+		}
+		assertCodes := func(name string, got []string, want ...string) {
+			t.Helper()
+			if len(got) != len(want) {
+				t.Errorf("%s: expected %v, got %v", name, want, got)
+				return
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("%s: expected %v, got %v", name, want, got)
+					return
+				}
+			}
+		}
+
+		assertCodes("literal search", list(LinkListQuery{Search: "100%_"}), "acode")
+		assertCodes("domain", list(LinkListQuery{Domain: "example.com"}), "acode", "bcode")
+		assertCodes("domain with port", list(LinkListQuery{Domain: "example.org"}), "dcode")
+		assertCodes("newest first", list(LinkListQuery{Descending: true, Limit: 2}), "dcode", "ccode")
+
+		first, err := repo.ListLinks(LinkListQuery{SortBy: LinkSortClicks, Descending: true, Limit: 1})
+		if err != nil || len(first) != 1 || first[0].ShortCode != "ccode" || first[0].ClickCount != 2 {
+			t.Fatalf("Expected the most clicked link first, got %+v (%v)", first, err)
+		}
+		cursor := &LinkCursor{CreatedAt: first[0].CreatedAt, ClickCount: first[0].ClickCount, ID: first[0].ID}
+		assertCodes("next page by clicks",
+			list(LinkListQuery{SortBy: LinkSortClicks, Descending: true, After: cursor}), "dcode", "bcode", "acode")
+	})
+}
+
+func TestLinkRepository_MarkExpiredLinks(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *gorm.DB) {
+		mustMigrate(t, db)
+		repo := NewLinkRepository(db)
+		now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+		past, future := now.Add(-time.Hour), now.Add(time.Hour)
+		maxClicks := 1
+
+		mustCreateLink(t, db, &models.Link{ShortCode: "past", LongURL: "https://example.com", ExpiresAt: &past})
+		mustCreateLink(t, db, &models.Link{ShortCode: "future", LongURL: "https://example.com", ExpiresAt: &future})
+		exhausted := mustCreateLink(t, db, &models.Link{ShortCode: "exhausted", LongURL: "https://example.com", MaxClicks: &maxClicks})
+		if err := NewClickRepository(db).CreateClicks([]*models.Click{{LinkID: exhausted.ID, Timestamp: past}}); err != nil {
+			t.Fatalf("Failed to create click: %v", err)
+		}
+
+		marked, err := repo.MarkExpiredLinks(now)
+		if err != nil {
+			t.Fatalf("Failed to mark expired links: %v", err)
+		}
+		if marked != 2 {
+			t.Errorf("Expected 2 expired links, got %d", marked)
+		}
+		active, err := repo.GetActiveLinks()
+		if err != nil || len(active) != 1 || active[0].ShortCode != "future" {
+			t.Errorf("Expected only the future link to stay active, got %+v (%v)", active, err)
+		}
+	})
+}
+
+func TestLinkRepository_ShortCodes(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *gorm.DB) {
+		mustMigrate(t, db)
+		repo := NewLinkRepository(db)
+		for _, code := range []string{"first", "second", "third"} {
+			mustCreateLink(t, db, &models.Link{ShortCode: code, LongURL: "https://example.com"})
+		}
+		deleted, err := repo.GetLinkByShortCode("second")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.DeleteLink(deleted); err != nil {
+			t.Fatalf("Failed to delete link: %v", err)
+		}
+
+		// Les liens supprimés logiquement peuvent être restaurés : leurs codes restent attribués.
+		count, err := repo.CountShortCodes()
+		if err != nil || count != 3 {
+			t.Errorf("Expected 3 short codes, got %d (%v)", count, err)
+		}
+
+		page, err := repo.ListShortCodes(0, 2)
+		if err != nil || len(page) != 2 || page[0].ShortCode != "first" || page[1].ShortCode != "second" {
+			t.Fatalf("Expected the first two codes by ID, got %+v (%v)", page, err)
+		}
+		next, err := repo.ListShortCodes(page[1].ID, 2)
+		if err != nil || len(next) != 1 || next[0].ShortCode != "third" {
+			t.Errorf("Expected the last code, got %+v (%v)", next, err)
+		}
+
+		maxID, err := repo.MaxLinkID()
+		if err != nil || maxID != next[0].ID {
+			t.Errorf("Expected max link ID %d, got %d (%v)", next[0].ID, maxID, err)
+		}
+	})
+}
+
+func TestLinkRepository_CreateLinkConflict(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *gorm.DB) {
+		mustMigrate(t, db)
+		repo := NewLinkRepository(db)
+		link := mustCreateLink(t, db, &models.Link{ShortCode: "promo", LongURL: "https://example.com"})
+		if err := repo.DeleteLink(link); err != nil {
+			t.Fatalf("Failed to delete link: %v", err)
+		}
+
+		err := repo.CreateLink(&models.Link{ShortCode: "promo", LongURL: "https://example.org"})
+		if !errors.Is(err, models.ErrDuplicateShortCode) {
+			t.Errorf("Expected ErrDuplicateShortCode for a code taken by a deleted link, got %v", err)
+		}
+
+		// Les autres violations de contrainte ne sont pas des conflits de code.
+		err = repo.CreateLink(&models.Link{ID: link.ID, ShortCode: "other", LongURL: "https://example.org"})
+		if err == nil || errors.Is(err, models.ErrDuplicateShortCode) {
+			t.Errorf("Expected a primary key error, got %v", err)
+		}
+	})
+}
+
+func TestIsShortCodeConflict(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("UNIQUE constraint failed: links.short_code"), true},
+		{errors.New("UNIQUE constraint failed: links.id"), false},
+		{&pgconn.PgError{Code: "23505", ConstraintName: "idx_links_short_code"}, true},
+		{fmt.Errorf("failed to create link: %w", &pgconn.PgError{Code: "23505", ConstraintName: "idx_links_short_code"}), true},
+		{&pgconn.PgError{Code: "23505", ConstraintName: "links_pkey"}, false},
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'promo' for key 'links.idx_links_short_code'"}, true},
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'promo' for key 'idx_links_short_code'"}, true},
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'links.PRIMARY'"}, false},
+		{&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsShortCodeConflict(tt.err); got != tt.expected {
+			t.Errorf("IsShortCodeConflict(%v) = %v, expected %v", tt.err, got, tt.expected)
+		}
+	}
+}
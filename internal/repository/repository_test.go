@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Les tests des repositories s'exécutent sur SQLite, ainsi que sur PostgreSQL et MySQL quand
+// URLSHORTENER_TEST_POSTGRES_DSN ou URLSHORTENER_TEST_MYSQL_DSN désignent une base jetable :
+// toutes ses tables sont supprimées au début de chaque test (voir la cible test-databases du Makefile).
+var testDatabaseDSNEnv = map[string]string{
+	database.DriverPostgres: "URLSHORTENER_TEST_POSTGRES_DSN",
+	database.DriverMySQL:    "URLSHORTENER_TEST_MYSQL_DSN",
+}
+
+// forEachDatabase exécute test dans un sous-test par base de données disponible, sur une base vide.
+func forEachDatabase(t *testing.T, test func(t *testing.T, db *gorm.DB)) {
+	t.Helper()
+	t.Run(database.DriverSQLite, func(t *testing.T) {
+		test(t, openTestDB(t, config.DatabaseConfig{
+			Driver: database.DriverSQLite,
+			Name:   filepath.Join(t.TempDir(), "test.db"),
+		}))
+	})
+	for _, driver := range []string{database.DriverPostgres, database.DriverMySQL} {
+		t.Run(driver, func(t *testing.T) {
+			dsn := os.Getenv(testDatabaseDSNEnv[driver])
+			if dsn == "" {
+				t.Skipf("%s not set", testDatabaseDSNEnv[driver])
+			}
+			db := openTestDB(t, config.DatabaseConfig{Driver: driver, DSN: dsn})
+			if err := dropTables(db); err != nil {
+				t.Fatalf("Failed to reset database: %v", err)
+			}
+			test(t, db)
+		})
+	}
+}
+
+func openTestDB(t *testing.T, cfg config.DatabaseConfig) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db = db.Session(&gorm.Session{Logger: logger.Discard})
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// dropTables supprime les tables des modèles, dans l'ordre inverse de leur création
+// pour respecter les clés étrangères.
+func dropTables(db *gorm.DB) error {
+	all := models.AllModels()
+	for i := len(all) - 1; i >= 0; i-- {
+		if err := db.Migrator().DropTable(all[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mustMigrate(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	if err := db.AutoMigrate(models.AllModels()...); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+}
+
+func mustCreateLink(t *testing.T, db *gorm.DB, link *models.Link) *models.Link {
+	t.Helper()
+	if err := NewLinkRepository(db).CreateLink(link); err != nil {
+		t.Fatalf("Failed to create link: %v", err)
+	}
+	return link
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrSchemaOutdated signale une base à laquelle il manque des tables ou des colonnes des modèles :
+// les migrations n'ont pas été exécutées.
+var ErrSchemaOutdated = errors.New("database schema is outdated, run the migrate up command")
+
+// CheckSchema vérifie que chaque modèle persisté dispose de sa table et de toutes ses colonnes.
+func CheckSchema(db *gorm.DB) error {
+	migrator := db.Migrator()
+	var missing []string
+	for _, model := range models.AllModels() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return fmt.Errorf("failed to parse model schema: %w", err)
+		}
+		table := stmt.Schema.Table
+		if !migrator.HasTable(model) {
+			missing = append(missing, "table "+table)
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !migrator.HasColumn(model, field.DBName) {
+				missing = append(missing, "column "+table+"."+field.DBName)
+			}
+		}
+	}
+	if err := db.Error; err != nil {
+		return fmt.Errorf("failed to inspect database schema: %w", err)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrSchemaOutdated, strings.Join(missing, ", "))
+	}
+	return nil
+}
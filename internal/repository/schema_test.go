@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestCheckSchema(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *gorm.DB) {
+		err := CheckSchema(db)
+		if !errors.Is(err, ErrSchemaOutdated) || !strings.Contains(err.Error(), "table links") {
+			t.Fatalf("Expected missing tables on an empty database, got %v", err)
+		}
+
+		mustMigrate(t, db)
+		if err := CheckSchema(db); err != nil {
+			t.Fatalf("Expected a migrated schema to be up to date, got %v", err)
+		}
+
+		if err := db.Migrator().DropColumn(&models.Link{}, "MaxClicks"); err != nil {
+			t.Fatalf("Failed to drop column: %v", err)
+		}
+		err = CheckSchema(db)
+		if !errors.Is(err, ErrSchemaOutdated) || !strings.Contains(err.Error(), "column links.max_clicks") {
+			t.Errorf("Expected the missing column to be reported, got %v", err)
+		}
+	})
+}
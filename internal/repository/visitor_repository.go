@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VisitorRepository interface {
+	GetOrCreateSalt(day string, candidate []byte) ([]byte, error)
+	DeleteSaltsBefore(day string) error
+	MergeSketch(linkID uint, day time.Time, registers []byte, merge func(stored, pending []byte) ([]byte, error)) error
+	ListSketches(linkID uint, from, to time.Time) ([]models.VisitorSketch, error)
+}
+
+type GormVisitorRepository struct {
+	db *gorm.DB
+}
+
+func NewVisitorRepository(db *gorm.DB) *GormVisitorRepository {
+	return &GormVisitorRepository{db: db}
+}
+
+// GetOrCreateSalt retourne le sel de la journée, en enregistrant candidate s'il n'en existe pas encore.
+// Si plusieurs processus créent le sel en même temps, tous obtiennent celui qui a été enregistré en premier.
+func (r *GormVisitorRepository) GetOrCreateSalt(day string, candidate []byte) ([]byte, error) {
+	salt := models.VisitorSalt{Day: day, Salt: candidate}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&salt).Error; err != nil {
+		return nil, fmt.Errorf("failed to create visitor salt: %w", err)
+	}
+
+	var stored models.VisitorSalt
+	if err := r.db.Where("day = ?", day).First(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to get visitor salt: %w", err)
+	}
+	return stored.Salt, nil
+}
+
+// DeleteSaltsBefore supprime les sels des journées antérieures à day.
+func (r *GormVisitorRepository) DeleteSaltsBefore(day string) error {
+	if err := r.db.Where("day < ?", day).Delete(&models.VisitorSalt{}).Error; err != nil {
+		return fmt.Errorf("failed to delete visitor salts: %w", err)
+	}
+	return nil
+}
+
+// MergeSketch fusionne registers dans le sketch stocké pour le lien et la journée, dans une transaction.
+// merge reçoit le sketch stocké et le sketch en attente et retourne le résultat à enregistrer.
+func (r *GormVisitorRepository) MergeSketch(linkID uint, day time.Time, registers []byte, merge func(stored, pending []byte) ([]byte, error)) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var sketch models.VisitorSketch
+		err := tx.Where("link_id = ? AND day = ?", linkID, day).First(&sketch).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(&models.VisitorSketch{LinkID: linkID, Day: day, Registers: registers}).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		merged, err := merge(sketch.Registers, registers)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.VisitorSketch{}).
+			Where("link_id = ? AND day = ?", linkID, day).
+			Updates(map[string]interface{}{"registers": merged, "updated_at": time.Now()}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to merge visitor sketch: %w", err)
+	}
+	return nil
+}
+
+// ListSketches retourne les sketches d'un lien pour les journées comprises entre from (inclus) et to (exclu).
+func (r *GormVisitorRepository) ListSketches(linkID uint, from, to time.Time) ([]models.VisitorSketch, error) {
+	var sketches []models.VisitorSketch
+	err := r.db.Where("link_id = ? AND day >= ? AND day < ?", linkID, from, to).
+		Order("day").
+		Find(&sketches).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list visitor sketches: %w", err)
+	}
+	return sketches, nil
+}
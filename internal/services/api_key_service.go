@@ -0,0 +1,137 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	// apiKeyPrefix permet de reconnaître une clé d'API de ce service (ex: dans un scanner de secrets).
+	apiKeyPrefix = "usk_"
+	// apiKeySecretBytes est le nombre d'octets aléatoires d'une clé.
+	apiKeySecretBytes = 24
+	// apiKeyDisplayLength est le nombre de caractères de la clé conservés en clair pour l'identifier.
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+	// apiKeyTouchInterval limite la fréquence de mise à jour de LastUsedAt pour éviter une écriture par requête.
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIKeyService fournit l'émission, la révocation et la vérification des clés d'API.
+type APIKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	logger     *slog.Logger
+}
+
+// NewAPIKeyService crée et retourne une nouvelle instance de APIKeyService.
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		logger:     slog.Default(),
+	}
+}
+
+// SetLogger remplace le logger du service, par défaut slog.Default().
+func (s *APIKeyService) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// IssueKey génère une nouvelle clé d'API et retourne sa valeur en clair, qui ne pourra plus être récupérée ensuite.
+func (s *APIKeyService) IssueKey(name string) (string, *models.APIKey, error) {
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	plaintext := apiKeyPrefix + hex.EncodeToString(secret)
+
+	key := &models.APIKey{
+		Name:      name,
+		Prefix:    plaintext[:apiKeyDisplayLength],
+		KeyHash:   hashAPIKey(plaintext),
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.apiKeyRepo.CreateAPIKey(key); err != nil {
+		return "", nil, fmt.Errorf("failed to store API key: %w", err)
+	}
+
+	return plaintext, key, nil
+}
+
+// Authenticate vérifie une clé d'API en clair et retourne l'enregistrement correspondant.
+// Retourne models.ErrInvalidAPIKey si la clé est inconnue ou révoquée.
+func (s *APIKeyService) Authenticate(plaintext string) (*models.APIKey, error) {
+	if !strings.HasPrefix(plaintext, apiKeyPrefix) {
+		return nil, models.ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetAPIKeyByHash(hashAPIKey(plaintext))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("database error retrieving API key: %w", err)
+	}
+
+	if key.IsRevoked() {
+		return nil, models.ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.apiKeyRepo.TouchAPIKey(key.ID, now); err != nil {
+			s.logger.Warn("Failed to record API key usage", "key_prefix", key.Prefix, "error", err)
+		}
+		key.LastUsedAt = &now
+	}
+
+	return key, nil
+}
+
+// ListKeys retourne toutes les clés d'API, révoquées comprises.
+func (s *APIKeyService) ListKeys() ([]models.APIKey, error) {
+	keys, err := s.apiKeyRepo.ListAPIKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeKey révoque une clé d'API : elle ne permet plus de s'authentifier.
+func (s *APIKeyService) RevokeKey(id uint) (*models.APIKey, error) {
+	key, err := s.apiKeyRepo.GetAPIKeyByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("database error retrieving API key: %w", err)
+	}
+
+	if key.IsRevoked() {
+		return key, nil
+	}
+
+	now := time.Now()
+	if err := s.apiKeyRepo.RevokeAPIKey(id, now); err != nil {
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	key.RevokedAt = &now
+
+	return key, nil
+}
+
+// hashAPIKey calcule l'empreinte stockée en base. Les clés étant aléatoires et longues,
+// un hachage rapide suffit : aucune attaque par dictionnaire n'est envisageable.
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,7 +2,10 @@ package services
 
 import (
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/axellelanca/urlshortener/internal/analytics"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository"
 )
@@ -10,6 +13,7 @@ import (
 // ClickService fournit des méthodes pour la logique métier des clics.
 type ClickService struct {
 	clickRepo repository.ClickRepository
+	enricher  *analytics.ClickEnricher
 }
 
 // NewClickService crée et retourne une nouvelle instance de ClickService.
@@ -17,16 +21,15 @@ type ClickService struct {
 func NewClickService(clickRepo repository.ClickRepository) *ClickService {
 	return &ClickService{
 		clickRepo: clickRepo,
+		enricher:  analytics.NewClickEnricher(analytics.NewBotDetector(nil), nil),
 	}
 }
 
 // ProcessClickEvent traite un événement de clic et le persiste en base de données.
 func (s *ClickService) ProcessClickEvent(event models.ClickEvent) error {
-	click := &models.Click{
-		LinkID:    event.LinkID,
-		Timestamp: event.Timestamp,
-		UserAgent: event.UserAgent,
-		IPAddress: event.IPAddress,
+	click, err := s.enricher.NewClick(event)
+	if err != nil {
+		return fmt.Errorf("failed to prepare click: %w", err)
 	}
 
 	if err := s.clickRepo.CreateClick(click); err != nil {
@@ -53,3 +56,89 @@ func (s *ClickService) CountClicksByLinkID(linkID uint) (int, error) {
 	}
 	return count, nil
 }
+
+// ClickDimensions liste, dans l'ordre d'affichage, les dimensions de ventilation des clics.
+var ClickDimensions = []string{repository.DimensionBrowser, repository.DimensionOS, repository.DimensionDevice}
+
+// GetClickBreakdown ventile les clics d'un lien selon une dimension (browser, os ou device)
+// et retourne au plus limit valeurs. Retourne models.ErrInvalidDimension pour une dimension inconnue.
+func (s *ClickService) GetClickBreakdown(linkID uint, dimension string, limit int) ([]repository.DimensionCount, error) {
+	if !slices.Contains(ClickDimensions, dimension) {
+		return nil, models.ErrInvalidDimension
+	}
+
+	counts, err := s.clickRepo.CountClicksByDimension(linkID, dimension, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get click breakdown: %w", err)
+	}
+	return counts, nil
+}
+
+// Modes d'application de la politique de rétention des clics.
+const (
+	// RetentionModeDelete supprime les clics trop anciens ; leurs référents, navigateurs, systèmes
+	// et appareils sont conservés sous forme de totaux par lien.
+	RetentionModeDelete = "delete"
+	// RetentionModeAggregate conserve les clics trop anciens pour les statistiques agrégées
+	// (compteurs, séries, ventilations) mais efface leurs données personnelles
+	// (IP, User-Agent brut, referrer complet).
+	RetentionModeAggregate = "aggregate"
+)
+
+// ValidateRetentionMode vérifie que mode est un mode de rétention connu.
+func ValidateRetentionMode(mode string) error {
+	if mode != RetentionModeDelete && mode != RetentionModeAggregate {
+		return models.ErrInvalidRetentionMode
+	}
+	return nil
+}
+
+// RebuildRollups recalcule les rollups horaires et journaliers des journées (UTC) comprises
+// entre from (inclus) et to (exclu) à partir des clics bruts. Nil, une borne n'est pas limitée.
+func (s *ClickService) RebuildRollups(from, to *time.Time) (repository.RollupRebuildResult, error) {
+	var start, end time.Time
+	if from != nil {
+		start = *from
+	}
+	if to != nil {
+		end = *to
+	}
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		return repository.RollupRebuildResult{}, fmt.Errorf("%w: 'from' must be before 'to'", models.ErrInvalidTimeRange)
+	}
+
+	result, err := s.clickRepo.RebuildRollups(start, end)
+	if err != nil {
+		return result, fmt.Errorf("failed to rebuild rollups: %w", err)
+	}
+	return result, nil
+}
+
+// purgeBatchSize est le nombre de clics traités par requête lors d'une purge.
+const purgeBatchSize = 5000
+
+// PurgeClicks applique la politique de rétention aux clics antérieurs à before selon mode,
+// et retourne le nombre de clics supprimés ou anonymisés. En dryRun, rien n'est modifié et
+// le nombre retourné est celui des clics qui seraient concernés.
+func (s *ClickService) PurgeClicks(before time.Time, mode string, dryRun bool) (int64, error) {
+	if err := ValidateRetentionMode(mode); err != nil {
+		return 0, err
+	}
+
+	var (
+		count int64
+		err   error
+	)
+	switch {
+	case dryRun:
+		count, err = s.clickRepo.CountClicksBefore(before, mode == RetentionModeAggregate)
+	case mode == RetentionModeDelete:
+		count, err = s.clickRepo.DeleteClicksBefore(before, purgeBatchSize)
+	default:
+		count, err = s.clickRepo.AnonymizeClicksBefore(before, purgeBatchSize)
+	}
+	if err != nil {
+		return count, fmt.Errorf("failed to purge clicks: %w", err)
+	}
+	return count, nil
+}
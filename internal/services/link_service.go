@@ -1,84 +1,200 @@
 package services
 
 import (
-	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
-	"math/big"
+	"log/slog"
+	"maps"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/axellelanca/urlshortener/internal/bloom"
+	"github.com/axellelanca/urlshortener/internal/cache"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/shortcode"
 	"gorm.io/gorm"
 )
 
-const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+// maxCodeGenerationAttempts borne le nombre de codes candidats essayés pour un lien ; il laisse
+// aux générateurs le temps de mesurer les collisions et d'allonger les codes.
+const maxCodeGenerationAttempts = 10
+
+// customCodePattern définit les alias personnalisés acceptés : lettres, chiffres, '-' et '_', de 3 à 32 caractères.
+var customCodePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{3,32}$`)
+
+// reservedShortCodes liste les codes qui entreraient en collision avec les routes fixes du serveur.
+// Les routes configurables (chemin des métriques) sont réservées par LinkService.ReserveRoute.
+var reservedShortCodes = map[string]struct{}{
+	"health":  {},
+	"livez":   {},
+	"readyz":  {},
+	"api":     {},
+	"metrics": {},
+	"admin":   {},
+}
+
+// CreateLinkOptions regroupe les paramètres optionnels de création d'un lien.
+type CreateLinkOptions struct {
+	// CustomCode est l'alias choisi par l'utilisateur. Vide, un code aléatoire est généré.
+	CustomCode string
+	// ExpiresAt est la date au-delà de laquelle le lien ne redirige plus. Nil, le lien n'expire pas.
+	ExpiresAt *time.Time
+	// MaxClicks est le nombre de clics après lequel le lien est épuisé. Nil, le nombre est illimité.
+	MaxClicks *int
+	// OwnerID est la clé d'API propriétaire du lien. Nil pour un lien sans propriétaire (créé depuis la CLI).
+	OwnerID *uint
+}
 
 type LinkService struct {
 	linkRepo  repository.LinkRepository
 	clickRepo repository.ClickRepository
+	logger    *slog.Logger
+	// cache sert les recherches des redirections ; nil, elles interrogent toujours la base.
+	cache *cache.LinkCache
+	// codeFilter écarte les codes inexistants avant le cache et la base ; nil, aucun code n'est écarté.
+	codeFilter *bloom.CodeFilter
+	// codeGenerator produit les codes des liens créés sans alias personnalisé.
+	codeGenerator shortcode.Generator
+	// reservedCodes complète reservedShortCodes par les routes réservées avec ReserveRoute.
+	reservedCodes map[string]struct{}
 }
 
 func NewLinkService(linkRepo repository.LinkRepository, clickRepo repository.ClickRepository) *LinkService {
 	return &LinkService{
-		linkRepo:  linkRepo,
-		clickRepo: clickRepo,
+		linkRepo:      linkRepo,
+		clickRepo:     clickRepo,
+		logger:        slog.Default(),
+		codeGenerator: shortcode.NewDefault(),
+		reservedCodes: maps.Clone(reservedShortCodes),
 	}
 }
 
-func (s *LinkService) GenerateShortCode(length int) (string, error) {
-	result := make([]byte, length)
-	for i := range result {
-		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
-		if err != nil {
-			return "", fmt.Errorf("failed to generate random number: %w", err)
-		}
-		result[i] = charset[num.Int64()]
-	}
-	return string(result), nil
+// SetLogger remplace le logger du service, par défaut slog.Default().
+func (s *LinkService) SetLogger(logger *slog.Logger) {
+	s.logger = logger
 }
 
-func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
-	var shortCode string
-	const maxRetries = 5
+// SetCache place un cache devant la recherche des liens à rediriger. Le service invalide les
+// entrées des liens qu'il modifie ; les modifications faites par un autre processus (CLI, autre
+// instance) ne sont visibles qu'à l'expiration des entrées.
+func (s *LinkService) SetCache(linkCache *cache.LinkCache) {
+	s.cache = linkCache
+}
 
-	for i := 0; i < maxRetries; i++ {
-		code, err := s.GenerateShortCode(6)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate short code: %w", err)
-		}
-		_, err = s.linkRepo.GetLinkByShortCode(code)
+// SetCodeFilter place un filtre de Bloom des codes attribués devant la recherche des liens à
+// rediriger. Le service y ajoute le code de chaque lien qu'il crée.
+func (s *LinkService) SetCodeFilter(codeFilter *bloom.CodeFilter) {
+	s.codeFilter = codeFilter
+}
 
-		if err != nil {
-	
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				shortCode = code 
-				break            
-			}
+// SetCodeGenerator remplace le générateur des codes courts, par défaut 6 caractères base62 aléatoires.
+func (s *LinkService) SetCodeGenerator(codeGenerator shortcode.Generator) {
+	s.codeGenerator = codeGenerator
+}
 
-			return nil, fmt.Errorf("database error checking short code uniqueness: %w", err)
-		}
-		log.Printf("Short code '%s' already exists, retrying generation (%d/%d)...", code, i+1, maxRetries)
+// ReserveRoute interdit comme code court le premier segment de path, une route servie par le
+// serveur à un chemin configurable (par exemple le chemin des métriques).
+func (s *LinkService) ReserveRoute(path string) {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if segment != "" {
+		s.reservedCodes[strings.ToLower(segment)] = struct{}{}
 	}
+}
+
+// ValidateCustomCode vérifie qu'un alias personnalisé respecte le format attendu
+// et qu'il n'entre pas en conflit avec une route réservée.
+func (s *LinkService) ValidateCustomCode(code string) error {
+	if !customCodePattern.MatchString(code) {
+		return models.ErrInvalidShortCode
+	}
+	if s.isReserved(code) {
+		return models.ErrReservedShortCode
+	}
+	return nil
+}
 
-	if shortCode == "" {
-		return nil, models.ErrShortCodeGenerationFailed
+func (s *LinkService) isReserved(code string) bool {
+	_, reserved := s.reservedCodes[strings.ToLower(code)]
+	return reserved
+}
+
+func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
+	return s.CreateLinkWithOptions(longURL, CreateLinkOptions{})
+}
+
+func (s *LinkService) CreateLinkWithOptions(longURL string, opts CreateLinkOptions) (*models.Link, error) {
+	if opts.ExpiresAt != nil && !opts.ExpiresAt.After(time.Now()) {
+		return nil, models.ErrInvalidExpiration
+	}
+	if opts.MaxClicks != nil && *opts.MaxClicks <= 0 {
+		return nil, models.ErrInvalidMaxClicks
 	}
 
 	link := &models.Link{
-		ShortCode: shortCode,
+		ShortCode: opts.CustomCode,
 		LongURL:   longURL,
 		CreatedAt: time.Now(),
+		ExpiresAt: opts.ExpiresAt,
+		MaxClicks: opts.MaxClicks,
+		OwnerID:   opts.OwnerID,
 	}
 
-	if err := s.linkRepo.CreateLink(link); err != nil {
+	// L'unicité du code est garantie par l'index unique de la base, et non par une vérification
+	// préalable : deux créations concurrentes ne peuvent pas obtenir le même code.
+	var err error
+	if opts.CustomCode != "" {
+		if err := s.ValidateCustomCode(opts.CustomCode); err != nil {
+			return nil, err
+		}
+		err = s.linkRepo.CreateLink(link)
+	} else {
+		err = s.createWithGeneratedCode(link)
+	}
+	if errors.Is(err, models.ErrDuplicateShortCode) || errors.Is(err, models.ErrShortCodeGenerationFailed) {
+		return nil, err
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to create link in database: %w", err)
 	}
+	s.codeFilter.Add(link.ShortCode)
+	s.cache.Invalidate(link.ShortCode)
 
 	return link, nil
 }
 
+// createWithGeneratedCode insère link avec un code produit par le générateur, en réessayant avec
+// un nouveau code tant que l'insertion échoue parce que le code est déjà attribué.
+func (s *LinkService) createWithGeneratedCode(link *models.Link) error {
+	for i := 0; i < maxCodeGenerationAttempts; i++ {
+		code, err := s.codeGenerator.Generate()
+		if err != nil {
+			return fmt.Errorf("failed to generate short code: %w", err)
+		}
+
+		if s.isReserved(code) {
+			s.codeGenerator.Observe(true)
+			continue
+		}
+
+		link.ShortCode = code
+		err = s.linkRepo.CreateLink(link)
+		if !errors.Is(err, models.ErrDuplicateShortCode) {
+			if err == nil {
+				s.codeGenerator.Observe(false)
+			}
+			return err
+		}
+		s.codeGenerator.Observe(true)
+		s.logger.Debug("Short code already exists, retrying generation", "short_code", code, "attempt", i+1, "max_attempts", maxCodeGenerationAttempts)
+	}
+
+	return models.ErrShortCodeGenerationFailed
+}
+
 
 func (s *LinkService) GetLinkByShortCode(shortCode string) (*models.Link, error) {
 	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
@@ -93,17 +209,381 @@ func (s *LinkService) GetLinkByShortCode(shortCode string) (*models.Link, error)
 }
 
 
-func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error) {
+// ResolveLink récupère le lien à utiliser pour une redirection et vérifie qu'il est toujours actif.
+// Retourne models.ErrLinkExpired ou models.ErrLinkClickLimitReached si le lien ne doit plus rediriger.
+func (s *LinkService) ResolveLink(shortCode string) (*models.Link, error) {
+	if !s.codeFilter.MayContain(shortCode) {
+		return nil, models.ErrLinkNotFound
+	}
+	// Un lien en cache peut avoir été marqué expiré depuis par le sweeper : les vérifications
+	// ci-dessous ne reposent pas sur ce marquage seul.
+	link, err := s.cache.Get(shortCode, s.GetLinkByShortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.Disabled {
+		return link, models.ErrLinkDisabled
+	}
+	if link.HasExpired(time.Now()) {
+		return link, models.ErrLinkExpired
+	}
+	// Le sweeper ne marque un lien non échu que lorsque son budget de clics est épuisé.
+	if link.Expired {
+		return link, models.ErrLinkClickLimitReached
+	}
+
+	if link.MaxClicks != nil {
+		// Les clics de robots (aperçus de liens, vérificateurs) n'entament pas le budget.
+		clicks, err := s.clickRepo.CountClicksByKind(link.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count clicks: %w", err)
+		}
+		if link.HasReachedClickLimit(clicks.Human) {
+			return link, models.ErrLinkClickLimitReached
+		}
+	}
+
+	return link, nil
+}
+
+// GetLinkStats retourne un lien et ses clics, séparés entre humains et robots.
+func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, repository.ClickCounts, error) {
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, repository.ClickCounts{}, err
+	}
+
+	clicks, err := s.clickRepo.CountClicksByKind(link.ID)
+	if err != nil {
+		return nil, repository.ClickCounts{}, fmt.Errorf("failed to count clicks: %w", err)
+	}
+
+	return link, clicks, nil
+}
+
+// CheckLinkOwner vérifie que le lien, supprimé ou non, appartient à la clé d'API donnée.
+// Retourne models.ErrLinkNotFound si le lien n'existe pas et models.ErrLinkForbidden s'il appartient à une autre clé.
+func (s *LinkService) CheckLinkOwner(shortCode string, apiKeyID uint) error {
 	link, err := s.GetLinkByShortCode(shortCode)
+	if errors.Is(err, models.ErrLinkNotFound) {
+		link, err = s.linkRepo.GetDeletedLinkByShortCode(shortCode)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrLinkNotFound
+		}
+	}
 	if err != nil {
-		return nil, 0, err
+		return fmt.Errorf("database error retrieving link: %w", err)
 	}
 
-	totalClicks, err := s.clickRepo.CountClicksByLinkID(link.ID)
+	if !link.IsOwnedBy(apiKeyID) {
+		return models.ErrLinkForbidden
+	}
+	return nil
+}
+
+// UpdateLinkOptions regroupe les champs modifiables d'un lien. Un champ nil n'est pas modifié.
+type UpdateLinkOptions struct {
+	LongURL  *string
+	Disabled *bool
+}
+
+// UpdateLink modifie la destination et/ou l'état d'activation d'un lien existant.
+func (s *LinkService) UpdateLink(shortCode string, opts UpdateLinkOptions) (*models.Link, error) {
+	link, err := s.GetLinkByShortCode(shortCode)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count clicks: %w", err)
+		return nil, err
 	}
 
-	return link, totalClicks, nil
+	if opts.LongURL != nil {
+		link.LongURL = *opts.LongURL
+	}
+	if opts.Disabled != nil {
+		link.Disabled = *opts.Disabled
+	}
+
+	if err := s.linkRepo.UpdateLink(link); err != nil {
+		return nil, fmt.Errorf("failed to update link in database: %w", err)
+	}
+	s.cache.Invalidate(shortCode)
+
+	return link, nil
 }
 
+// SetLinkDisabled active ou désactive un lien sans modifier sa destination.
+func (s *LinkService) SetLinkDisabled(shortCode string, disabled bool) (*models.Link, error) {
+	return s.UpdateLink(shortCode, UpdateLinkOptions{Disabled: &disabled})
+}
+
+// DeleteLink supprime logiquement un lien : il ne redirige plus mais peut être restauré.
+func (s *LinkService) DeleteLink(shortCode string) error {
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return err
+	}
+
+	if err := s.linkRepo.DeleteLink(link); err != nil {
+		return fmt.Errorf("failed to delete link in database: %w", err)
+	}
+	s.cache.Invalidate(shortCode)
+
+	return nil
+}
+
+// RestoreLink restaure un lien précédemment supprimé.
+// Retourne models.ErrLinkNotDeleted si le lien existe mais n'a pas été supprimé.
+func (s *LinkService) RestoreLink(shortCode string) (*models.Link, error) {
+	link, err := s.linkRepo.GetDeletedLinkByShortCode(shortCode)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("database error retrieving deleted link: %w", err)
+		}
+		if _, err := s.GetLinkByShortCode(shortCode); err != nil {
+			return nil, err
+		}
+		return nil, models.ErrLinkNotDeleted
+	}
+
+	if err := s.linkRepo.RestoreLink(link); err != nil {
+		return nil, fmt.Errorf("failed to restore link in database: %w", err)
+	}
+	s.cache.Invalidate(shortCode)
+	link.DeletedAt = gorm.DeletedAt{}
+
+	return link, nil
+}
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// ListLinksOptions regroupe les filtres, le tri et la pagination de la liste des liens.
+type ListLinksOptions struct {
+	// OwnerID restreint la liste aux liens d'une clé d'API. Nil pour lister tous les liens.
+	OwnerID       *uint
+	Domain        string
+	Search        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// SortBy vaut "created_at" (par défaut) ou "clicks".
+	SortBy string
+	// Order vaut "desc" (par défaut) ou "asc".
+	Order string
+	// Cursor est le curseur opaque retourné par la page précédente. Vide pour la première page.
+	Cursor string
+	// Limit est le nombre maximal de liens par page (20 par défaut, 100 au maximum).
+	Limit int
+}
+
+// LinkPage est une page de la liste des liens.
+type LinkPage struct {
+	Links []repository.LinkWithClicks
+	// NextCursor permet de récupérer la page suivante. Vide s'il n'y a plus de résultats.
+	NextCursor string
+}
+
+// ListLinks retourne une page de liens selon les options fournies.
+func (s *LinkService) ListLinks(opts ListLinksOptions) (*LinkPage, error) {
+	query := repository.LinkListQuery{
+		OwnerID:       opts.OwnerID,
+		Domain:        strings.TrimSpace(opts.Domain),
+		Search:        strings.TrimSpace(opts.Search),
+		CreatedAfter:  opts.CreatedAfter,
+		CreatedBefore: opts.CreatedBefore,
+		Limit:         opts.Limit,
+	}
+
+	switch opts.SortBy {
+	case "", repository.LinkSortCreatedAt:
+		query.SortBy = repository.LinkSortCreatedAt
+	case repository.LinkSortClicks:
+		query.SortBy = repository.LinkSortClicks
+	default:
+		return nil, fmt.Errorf("%w: unknown sort field '%s'", models.ErrInvalidListQuery, opts.SortBy)
+	}
+
+	switch strings.ToLower(opts.Order) {
+	case "", "desc":
+		query.Descending = true
+	case "asc":
+		query.Descending = false
+	default:
+		return nil, fmt.Errorf("%w: unknown order '%s'", models.ErrInvalidListQuery, opts.Order)
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = defaultListLimit
+	}
+	if query.Limit > maxListLimit {
+		query.Limit = maxListLimit
+	}
+
+	if opts.Cursor != "" {
+		cursor, err := decodeLinkCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query.After = cursor
+	}
+
+	// Une ligne supplémentaire est demandée pour savoir s'il existe une page suivante.
+	requested := query.Limit
+	query.Limit++
+
+	links, err := s.linkRepo.ListLinks(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+
+	page := &LinkPage{Links: links}
+	if len(links) > requested {
+		page.Links = links[:requested]
+		last := page.Links[requested-1]
+		page.NextCursor = encodeLinkCursor(repository.LinkCursor{
+			CreatedAt:  last.CreatedAt,
+			ClickCount: last.ClickCount,
+			ID:         last.ID,
+		})
+	}
+
+	return page, nil
+}
+
+func encodeLinkCursor(cursor repository.LinkCursor) string {
+	payload, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func decodeLinkCursor(encoded string) (*repository.LinkCursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, models.ErrInvalidCursor
+	}
+
+	var cursor repository.LinkCursor
+	if err := json.Unmarshal(payload, &cursor); err != nil || cursor.ID == 0 {
+		return nil, models.ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// GetTopReferrers retourne les hôtes référents ayant généré le plus de clics pour un lien.
+func (s *LinkService) GetTopReferrers(linkID uint, limit int) ([]repository.ReferrerCount, error) {
+	referrers, err := s.clickRepo.TopReferrers(linkID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top referrers: %w", err)
+	}
+	return referrers, nil
+}
+
+// maxTimeSeriesBuckets borne le nombre d'intervalles d'une série temporelle.
+const maxTimeSeriesBuckets = 2000
+
+// ClickTimeSeries est le nombre de clics humains et de robots d'un lien par intervalle, sans trou entre From et To.
+type ClickTimeSeries struct {
+	Link     *models.Link
+	From     time.Time
+	To       time.Time
+	Interval string
+	Buckets  []repository.ClickBucket
+}
+
+// GetClickTimeSeries retourne les clics d'un lien regroupés par heure, jour ou semaine.
+// from et to sont alignés sur les intervalles (UTC) ; les intervalles sans clic valent 0.
+// Nil, to vaut maintenant et from couvre une période par défaut adaptée à l'intervalle.
+func (s *LinkService) GetClickTimeSeries(shortCode string, from, to *time.Time, interval string) (*ClickTimeSeries, error) {
+	if interval == "" {
+		interval = repository.IntervalDay
+	}
+
+	var defaultSpan time.Duration
+	switch interval {
+	case repository.IntervalHour:
+		defaultSpan = 24 * time.Hour
+	case repository.IntervalDay:
+		defaultSpan = 30 * 24 * time.Hour
+	case repository.IntervalWeek:
+		defaultSpan = 12 * 7 * 24 * time.Hour
+	default:
+		return nil, fmt.Errorf("%w: unknown interval '%s'", models.ErrInvalidTimeRange, interval)
+	}
+
+	end := time.Now().UTC()
+	if to != nil {
+		end = to.UTC()
+	}
+	start := end.Add(-defaultSpan)
+	if from != nil {
+		start = from.UTC()
+	}
+
+	start = truncateToInterval(start, interval)
+	// La borne de fin est exclusive : on l'étend jusqu'à la fin de son intervalle.
+	end = nextInterval(truncateToInterval(end, interval), interval)
+	if !start.Before(end) {
+		return nil, fmt.Errorf("%w: 'from' must be before 'to'", models.ErrInvalidTimeRange)
+	}
+
+	var expected []time.Time
+	for t := start; t.Before(end); t = nextInterval(t, interval) {
+		if len(expected) == maxTimeSeriesBuckets {
+			return nil, fmt.Errorf("%w: more than %d %s buckets requested", models.ErrInvalidTimeRange, maxTimeSeriesBuckets, interval)
+		}
+		expected = append(expected, t)
+	}
+
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	counted, err := s.clickRepo.CountClicksByInterval(link.ID, start, end, interval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate clicks: %w", err)
+	}
+
+	counts := make(map[int64]repository.ClickCounts, len(counted))
+	for _, bucket := range counted {
+		counts[bucket.Start.Unix()] = bucket.ClickCounts
+	}
+
+	buckets := make([]repository.ClickBucket, 0, len(expected))
+	for _, t := range expected {
+		buckets = append(buckets, repository.ClickBucket{Start: t, ClickCounts: counts[t.Unix()]})
+	}
+
+	return &ClickTimeSeries{
+		Link:     link,
+		From:     start,
+		To:       end,
+		Interval: interval,
+		Buckets:  buckets,
+	}, nil
+}
+
+// truncateToInterval ramène t (UTC) au début de son heure, de son jour ou de sa semaine (lundi).
+func truncateToInterval(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	switch interval {
+	case repository.IntervalHour:
+		return t.Truncate(time.Hour)
+	case repository.IntervalWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		daysSinceMonday := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -daysSinceMonday)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func nextInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case repository.IntervalHour:
+		return t.Add(time.Hour)
+	case repository.IntervalWeek:
+		return t.AddDate(0, 0, 7)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/shortcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Les créations concurrentes s'exécutent sur une vraie base SQLite : seul l'index unique de la base
+// garantit qu'un code n'est attribué qu'une fois.
+
+const concurrentCreators = 8
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(config.DatabaseConfig{
+		Driver: database.DriverSQLite,
+		Name:   filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db = db.Session(&gorm.Session{Logger: logger.Discard})
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(models.AllModels()...); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	return db
+}
+
+// staleIDs simule des processus qui ne voient pas les liens créés par les autres.
+type staleIDs struct{}
+
+func (staleIDs) MaxLinkID() (uint, error) {
+	return 0, nil
+}
+
+// runConcurrently appelle create depuis concurrentCreators goroutines démarrées ensemble.
+func runConcurrently(create func(i int) error) []error {
+	errs := make([]error, concurrentCreators)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = create(i)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+func TestCreateLink_ConcurrentGeneratedCodes(t *testing.T) {
+	db := openTestDB(t)
+	linkRepo := repository.NewLinkRepository(db)
+	clickRepo := repository.NewClickRepository(db)
+
+	// Chaque créateur, comme une instance distincte du serveur, propose la même suite de codes :
+	// tous visent le même code au premier essai.
+	errs := runConcurrently(func(i int) error {
+		linkService := NewLinkService(linkRepo, clickRepo)
+		linkService.SetCodeGenerator(shortcode.NewSequential("abcdefghij", 3, staleIDs{}))
+		_, err := linkService.CreateLink(fmt.Sprintf("https://example.com/%d", i))
+		return err
+	})
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("Creator %d failed: %v", i, err)
+		}
+	}
+
+	var codes []string
+	if err := db.Model(&models.Link{}).Order("short_code").Pluck("short_code", &codes).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != concurrentCreators {
+		t.Fatalf("Expected %d links, got %d", concurrentCreators, len(codes))
+	}
+	for i, code := range codes {
+		if expected := "aa" + string(rune('b'+i)); code != expected {
+			t.Errorf("Expected the first %d codes of the sequence, got %v", concurrentCreators, codes)
+			break
+		}
+	}
+}
+
+func TestCreateLink_ConcurrentCustomCode(t *testing.T) {
+	db := openTestDB(t)
+	linkService := NewLinkService(repository.NewLinkRepository(db), repository.NewClickRepository(db))
+
+	errs := runConcurrently(func(i int) error {
+		_, err := linkService.CreateLinkWithOptions(fmt.Sprintf("https://example.com/%d", i), CreateLinkOptions{CustomCode: "promo"})
+		return err
+	})
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, models.ErrDuplicateShortCode):
+			t.Errorf("Expected ErrDuplicateShortCode, got %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("Expected exactly one creation to succeed, got %d", created)
+	}
+}
+
+func TestCreateLink_TakenCodes(t *testing.T) {
+	db := openTestDB(t)
+	linkService := NewLinkService(repository.NewLinkRepository(db), repository.NewClickRepository(db))
+
+	if _, err := linkService.CreateLinkWithOptions("https://example.com", CreateLinkOptions{CustomCode: "promo"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := linkService.DeleteLink("promo"); err != nil {
+		t.Fatal(err)
+	}
+	// Le code d'un lien supprimé reste attribué : le lien peut être restauré.
+	if _, err := linkService.CreateLinkWithOptions("https://example.org", CreateLinkOptions{CustomCode: "promo"}); !errors.Is(err, models.ErrDuplicateShortCode) {
+		t.Errorf("Expected ErrDuplicateShortCode, got %v", err)
+	}
+
+	// Un code généré déjà pris par un alias est sauté.
+	linkService.SetCodeGenerator(shortcode.NewSequential("abcdefghij", 3, staleIDs{}))
+	if _, err := linkService.CreateLinkWithOptions("https://example.com", CreateLinkOptions{CustomCode: "aab"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := linkService.CreateLink("https://example.com")
+	if err != nil || link.ShortCode != "aac" {
+		t.Errorf("Expected the code taken by the alias to be skipped, got %v (%v)", link, err)
+	}
+}
+
+func TestGetLinkStats_ReadsRollups(t *testing.T) {
+	db := openTestDB(t)
+	clickRepo := repository.NewClickRepository(db)
+	linkService := NewLinkService(repository.NewLinkRepository(db), clickRepo)
+
+	link, err := linkService.CreateLink("https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2025, 3, 10, 9, 15, 0, 0, time.UTC)
+	if err := clickRepo.CreateClicks([]*models.Click{
+		{LinkID: link.ID, Timestamp: at},
+		{LinkID: link.ID, Timestamp: at.AddDate(0, 0, 1)},
+		{LinkID: link.ID, Timestamp: at, IsBot: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sans clics bruts, les compteurs viennent des rollups.
+	if err := db.Where("1 = 1").Delete(&models.Click{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	_, clicks, err := linkService.GetLinkStats(link.ShortCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clicks.Human != 2 || clicks.Bot != 1 {
+		t.Errorf("Expected 2 human and 1 bot clicks, got %+v", clicks)
+	}
+}
+
+func TestCreateLink_ReservedRoutes(t *testing.T) {
+	db := openTestDB(t)
+	linkService := NewLinkService(repository.NewLinkRepository(db), repository.NewClickRepository(db))
+	linkService.ReserveRoute("/stats/prom")
+
+	for _, code := range []string{"admin", "Metrics", "stats", "STATS"} {
+		if _, err := linkService.CreateLinkWithOptions("https://example.com", CreateLinkOptions{CustomCode: code}); !errors.Is(err, models.ErrReservedShortCode) {
+			t.Errorf("Expected ErrReservedShortCode for %q, got %v", code, err)
+		}
+	}
+	if _, err := linkService.CreateLinkWithOptions("https://example.com", CreateLinkOptions{CustomCode: "prom"}); err != nil {
+		t.Errorf("Expected only the first segment to be reserved, got %v", err)
+	}
+
+	// Un code généré qui tombe sur une route réservée est sauté.
+	linkService.ReserveRoute("/aab")
+	linkService.SetCodeGenerator(shortcode.NewSequential("abcdefghij", 3, staleIDs{}))
+	link, err := linkService.CreateLink("https://example.com")
+	if err != nil || link.ShortCode != "aac" {
+		t.Errorf("Expected the reserved code to be skipped, got %v (%v)", link, err)
+	}
+
+	// Le réglage ne concerne que ce service.
+	other := NewLinkService(repository.NewLinkRepository(db), repository.NewClickRepository(db))
+	if err := other.ValidateCustomCode("stats"); err != nil {
+		t.Errorf("Expected stats to be free for another service, got %v", err)
+	}
+}
@@ -2,8 +2,14 @@ package mocks
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
 	"gorm.io/gorm"
 )
 
@@ -29,6 +35,10 @@ func (m *MockLinkRepository) CreateLink(link *models.Link) error {
 		return errors.New("mock database error")
 	}
 	
+	if _, exists := m.links[link.ShortCode]; exists {
+		return models.ErrDuplicateShortCode
+	}
+	
 	if link.ID == 0 {
 		link.ID = m.nextID
 		m.nextID++
@@ -44,13 +54,53 @@ func (m *MockLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link,
 	}
 	
 	link, exists := m.links[shortCode]
-	if !exists {
+	if !exists || link.DeletedAt.Valid {
 		return nil, gorm.ErrRecordNotFound
 	}
 	
 	return link, nil
 }
 
+func (m *MockLinkRepository) GetDeletedLinkByShortCode(shortCode string) (*models.Link, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	link, exists := m.links[shortCode]
+	if !exists || !link.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return link, nil
+}
+
+func (m *MockLinkRepository) UpdateLink(link *models.Link) error {
+	if m.shouldFail {
+		return errors.New("mock database error")
+	}
+
+	m.links[link.ShortCode] = link
+	return nil
+}
+
+func (m *MockLinkRepository) DeleteLink(link *models.Link) error {
+	if m.shouldFail {
+		return errors.New("mock database error")
+	}
+
+	link.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func (m *MockLinkRepository) RestoreLink(link *models.Link) error {
+	if m.shouldFail {
+		return errors.New("mock database error")
+	}
+
+	link.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
 func (m *MockLinkRepository) GetAllLinks() ([]models.Link, error) {
 	if m.shouldFail {
 		return nil, errors.New("mock database error")
@@ -58,12 +108,151 @@ func (m *MockLinkRepository) GetAllLinks() ([]models.Link, error) {
 	
 	var allLinks []models.Link
 	for _, link := range m.links {
-		allLinks = append(allLinks, *link)
+		if !link.DeletedAt.Valid {
+			allLinks = append(allLinks, *link)
+		}
 	}
 	
 	return allLinks, nil
 }
 
+func (m *MockLinkRepository) ListLinks(query repository.LinkListQuery) ([]repository.LinkWithClicks, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	var links []repository.LinkWithClicks
+	for _, link := range m.links {
+		if link.DeletedAt.Valid {
+			continue
+		}
+		if query.OwnerID != nil && !link.IsOwnedBy(*query.OwnerID) {
+			continue
+		}
+		if query.Domain != "" {
+			parsed, err := url.Parse(link.LongURL)
+			host := strings.ToLower(parsed.Hostname())
+			domain := strings.ToLower(query.Domain)
+			if err != nil || (host != domain && !strings.HasSuffix(host, "."+domain)) {
+				continue
+			}
+		}
+		if query.Search != "" && !strings.Contains(strings.ToLower(link.LongURL+" "+link.ShortCode), strings.ToLower(query.Search)) {
+			continue
+		}
+		if query.CreatedAfter != nil && link.CreatedAt.Before(*query.CreatedAfter) {
+			continue
+		}
+		if query.CreatedBefore != nil && !link.CreatedAt.Before(*query.CreatedBefore) {
+			continue
+		}
+		links = append(links, repository.LinkWithClicks{Link: *link})
+	}
+
+	// Le mock ne suit pas les clics : le tri se fait sur la date de création puis l'ID.
+	less := func(a, b repository.LinkWithClicks) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if query.Descending {
+			return less(links[j], links[i])
+		}
+		return less(links[i], links[j])
+	})
+
+	if query.After != nil {
+		cursor := repository.LinkWithClicks{Link: models.Link{ID: query.After.ID, CreatedAt: query.After.CreatedAt}}
+		start := len(links)
+		for i, link := range links {
+			if (query.Descending && less(link, cursor)) || (!query.Descending && less(cursor, link)) {
+				start = i
+				break
+			}
+		}
+		links = links[start:]
+	}
+
+	if query.Limit > 0 && len(links) > query.Limit {
+		links = links[:query.Limit]
+	}
+
+	return links, nil
+}
+
+func (m *MockLinkRepository) GetActiveLinks() ([]models.Link, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	var activeLinks []models.Link
+	for _, link := range m.links {
+		if !link.Expired && !link.Disabled && !link.DeletedAt.Valid {
+			activeLinks = append(activeLinks, *link)
+		}
+	}
+
+	return activeLinks, nil
+}
+
+func (m *MockLinkRepository) MarkExpiredLinks(now time.Time) (int64, error) {
+	if m.shouldFail {
+		return 0, errors.New("mock database error")
+	}
+
+	var marked int64
+	for _, link := range m.links {
+		if !link.Expired && !link.DeletedAt.Valid && link.HasExpired(now) {
+			link.Expired = true
+			marked++
+		}
+	}
+
+	return marked, nil
+}
+
+func (m *MockLinkRepository) CountShortCodes() (int64, error) {
+	if m.shouldFail {
+		return 0, errors.New("mock database error")
+	}
+
+	return int64(len(m.links)), nil
+}
+
+func (m *MockLinkRepository) ListShortCodes(afterID uint, limit int) ([]repository.ShortCodeEntry, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	var entries []repository.ShortCodeEntry
+	for _, link := range m.links {
+		if link.ID > afterID {
+			entries = append(entries, repository.ShortCodeEntry{ID: link.ID, ShortCode: link.ShortCode})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries, nil
+}
+
+func (m *MockLinkRepository) MaxLinkID() (uint, error) {
+	if m.shouldFail {
+		return 0, errors.New("mock database error")
+	}
+
+	var maxID uint
+	for _, link := range m.links {
+		maxID = max(maxID, link.ID)
+	}
+
+	return maxID, nil
+}
+
 func (m *MockLinkRepository) CountClicksByLinkID(linkID uint) (int, error) {
 	if m.shouldFail {
 		return 0, errors.New("mock database error")
@@ -96,6 +285,17 @@ func (m *MockClickRepository) CreateClick(click *models.Click) error {
 	return nil
 }
 
+func (m *MockClickRepository) CreateClicks(clicks []*models.Click) error {
+	if m.shouldFail {
+		return errors.New("mock database error")
+	}
+
+	for _, click := range clicks {
+		m.clicks[click.LinkID] = append(m.clicks[click.LinkID], *click)
+	}
+	return nil
+}
+
 func (m *MockClickRepository) GetClicksByLinkID(linkID uint) ([]models.Click, error) {
 	if m.shouldFail {
 		return nil, errors.New("mock database error")
@@ -120,4 +320,381 @@ func (m *MockClickRepository) CountClicksByLinkID(linkID uint) (int, error) {
 	}
 	
 	return len(clicks), nil
-} 
\ No newline at end of file
+}
+
+func (m *MockClickRepository) CountClicksByKind(linkID uint) (repository.ClickCounts, error) {
+	if m.shouldFail {
+		return repository.ClickCounts{}, errors.New("mock database error")
+	}
+
+	var counts repository.ClickCounts
+	for _, click := range m.clicks[linkID] {
+		if click.IsBot {
+			counts.Bot++
+		} else {
+			counts.Human++
+		}
+	}
+	return counts, nil
+}
+
+func (m *MockClickRepository) CountClicksByInterval(linkID uint, from, to time.Time, interval string) ([]repository.ClickBucket, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	counts := make(map[time.Time]repository.ClickCounts)
+	for _, click := range m.clicks[linkID] {
+		ts := click.Timestamp.UTC()
+		if ts.Before(from) || !ts.Before(to) {
+			continue
+		}
+		var start time.Time
+		switch interval {
+		case repository.IntervalHour:
+			start = ts.Truncate(time.Hour)
+		case repository.IntervalWeek:
+			day := time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)
+			start = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		default:
+			start = time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		count := counts[start]
+		if click.IsBot {
+			count.Bot++
+		} else {
+			count.Human++
+		}
+		counts[start] = count
+	}
+
+	var buckets []repository.ClickBucket
+	for start, count := range counts {
+		buckets = append(buckets, repository.ClickBucket{Start: start, ClickCounts: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Start.Before(buckets[j].Start) })
+
+	return buckets, nil
+}
+
+func (m *MockClickRepository) TopReferrers(linkID uint, limit int) ([]repository.ReferrerCount, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	counts := make(map[string]int)
+	for _, click := range m.clicks[linkID] {
+		counts[click.ReferrerHost]++
+	}
+
+	var referrers []repository.ReferrerCount
+	for host, count := range counts {
+		referrers = append(referrers, repository.ReferrerCount{Host: host, Count: count})
+	}
+	sort.Slice(referrers, func(i, j int) bool {
+		if referrers[i].Count != referrers[j].Count {
+			return referrers[i].Count > referrers[j].Count
+		}
+		return referrers[i].Host < referrers[j].Host
+	})
+	if len(referrers) > limit {
+		referrers = referrers[:limit]
+	}
+
+	return referrers, nil
+}
+
+func (m *MockClickRepository) CountClicksByDimension(linkID uint, dimension string, limit int) ([]repository.DimensionCount, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	counts := make(map[string]int)
+	for _, click := range m.clicks[linkID] {
+		switch dimension {
+		case repository.DimensionBrowser:
+			counts[click.BrowserFamily]++
+		case repository.DimensionOS:
+			counts[click.OSFamily]++
+		case repository.DimensionDevice:
+			counts[click.DeviceClass]++
+		default:
+			return nil, fmt.Errorf("unsupported dimension %q", dimension)
+		}
+	}
+
+	var result []repository.DimensionCount
+	for value, count := range counts {
+		result = append(result, repository.DimensionCount{Value: value, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Value < result[j].Value
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+func (m *MockClickRepository) CountClicksBefore(before time.Time, identifiableOnly bool) (int64, error) {
+	if m.shouldFail {
+		return 0, errors.New("mock database error")
+	}
+
+	var count int64
+	for _, clicks := range m.clicks {
+		for _, click := range clicks {
+			if click.Timestamp.Before(before) && (!identifiableOnly || isIdentifiable(click)) {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func (m *MockClickRepository) DeleteClicksBefore(before time.Time, batchSize int) (int64, error) {
+	if m.shouldFail {
+		return 0, errors.New("mock database error")
+	}
+
+	var deleted int64
+	for linkID, clicks := range m.clicks {
+		kept := clicks[:0]
+		for _, click := range clicks {
+			if click.Timestamp.Before(before) {
+				deleted++
+				continue
+			}
+			kept = append(kept, click)
+		}
+		m.clicks[linkID] = kept
+	}
+	return deleted, nil
+}
+
+func (m *MockClickRepository) AnonymizeClicksBefore(before time.Time, batchSize int) (int64, error) {
+	if m.shouldFail {
+		return 0, errors.New("mock database error")
+	}
+
+	var anonymized int64
+	for _, clicks := range m.clicks {
+		for i := range clicks {
+			if clicks[i].Timestamp.Before(before) && isIdentifiable(clicks[i]) {
+				clicks[i].IPAddress, clicks[i].UserAgent, clicks[i].Referrer = "", "", ""
+				anonymized++
+			}
+		}
+	}
+	return anonymized, nil
+}
+
+// RebuildRollups ne fait que compter les clics concernés : le mock calcule ses agrégats
+// directement à partir des clics bruts.
+func (m *MockClickRepository) RebuildRollups(from, to time.Time) (repository.RollupRebuildResult, error) {
+	if m.shouldFail {
+		return repository.RollupRebuildResult{}, errors.New("mock database error")
+	}
+
+	var result repository.RollupRebuildResult
+	for _, clicks := range m.clicks {
+		for _, click := range clicks {
+			if (from.IsZero() || !click.Timestamp.Before(from)) && (to.IsZero() || click.Timestamp.Before(to)) {
+				result.Clicks++
+			}
+		}
+	}
+	return result, nil
+}
+
+func isIdentifiable(click models.Click) bool {
+	return click.IPAddress != "" || click.UserAgent != "" || click.Referrer != ""
+}
+
+type MockAPIKeyRepository struct {
+	keys       map[uint]*models.APIKey
+	nextID     uint
+	shouldFail bool
+}
+
+func NewMockAPIKeyRepository() *MockAPIKeyRepository {
+	return &MockAPIKeyRepository{
+		keys:   make(map[uint]*models.APIKey),
+		nextID: 1,
+	}
+}
+
+func (m *MockAPIKeyRepository) SetShouldFail(shouldFail bool) {
+	m.shouldFail = shouldFail
+}
+
+func (m *MockAPIKeyRepository) CreateAPIKey(key *models.APIKey) error {
+	if m.shouldFail {
+		return errors.New("mock database error")
+	}
+
+	if key.ID == 0 {
+		key.ID = m.nextID
+		m.nextID++
+	}
+
+	m.keys[key.ID] = key
+	return nil
+}
+
+func (m *MockAPIKeyRepository) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	for _, key := range m.keys {
+		if key.KeyHash == keyHash {
+			return key, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockAPIKeyRepository) GetAPIKeyByID(id uint) (*models.APIKey, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	key, exists := m.keys[id]
+	if !exists {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return key, nil
+}
+
+func (m *MockAPIKeyRepository) ListAPIKeys() ([]models.APIKey, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	var keys []models.APIKey
+	for _, key := range m.keys {
+		keys = append(keys, *key)
+	}
+
+	return keys, nil
+}
+
+func (m *MockAPIKeyRepository) RevokeAPIKey(id uint, revokedAt time.Time) error {
+	if m.shouldFail {
+		return errors.New("mock database error")
+	}
+
+	if key, exists := m.keys[id]; exists {
+		key.RevokedAt = &revokedAt
+	}
+	return nil
+}
+
+func (m *MockAPIKeyRepository) TouchAPIKey(id uint, usedAt time.Time) error {
+	if m.shouldFail {
+		return errors.New("mock database error")
+	}
+
+	if key, exists := m.keys[id]; exists {
+		key.LastUsedAt = &usedAt
+	}
+	return nil
+}
+
+type MockVisitorRepository struct {
+	salts      map[string][]byte
+	sketches   map[uint]map[int64][]byte
+	shouldFail bool
+}
+
+func NewMockVisitorRepository() *MockVisitorRepository {
+	return &MockVisitorRepository{
+		salts:    make(map[string][]byte),
+		sketches: make(map[uint]map[int64][]byte),
+	}
+}
+
+func (m *MockVisitorRepository) SetShouldFail(shouldFail bool) {
+	m.shouldFail = shouldFail
+}
+
+func (m *MockVisitorRepository) GetOrCreateSalt(day string, candidate []byte) ([]byte, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	if salt, exists := m.salts[day]; exists {
+		return salt, nil
+	}
+	m.salts[day] = candidate
+	return candidate, nil
+}
+
+func (m *MockVisitorRepository) DeleteSaltsBefore(day string) error {
+	if m.shouldFail {
+		return errors.New("mock database error")
+	}
+
+	for d := range m.salts {
+		if d < day {
+			delete(m.salts, d)
+		}
+	}
+	return nil
+}
+
+// SaltDays retourne les journées dont le sel est encore conservé, triées.
+func (m *MockVisitorRepository) SaltDays() []string {
+	var days []string
+	for day := range m.salts {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	return days
+}
+
+func (m *MockVisitorRepository) MergeSketch(linkID uint, day time.Time, registers []byte, merge func(stored, pending []byte) ([]byte, error)) error {
+	if m.shouldFail {
+		return errors.New("mock database error")
+	}
+
+	if m.sketches[linkID] == nil {
+		m.sketches[linkID] = make(map[int64][]byte)
+	}
+	stored, exists := m.sketches[linkID][day.Unix()]
+	if !exists {
+		m.sketches[linkID][day.Unix()] = registers
+		return nil
+	}
+
+	merged, err := merge(stored, registers)
+	if err != nil {
+		return err
+	}
+	m.sketches[linkID][day.Unix()] = merged
+	return nil
+}
+
+func (m *MockVisitorRepository) ListSketches(linkID uint, from, to time.Time) ([]models.VisitorSketch, error) {
+	if m.shouldFail {
+		return nil, errors.New("mock database error")
+	}
+
+	var sketches []models.VisitorSketch
+	for unix, registers := range m.sketches[linkID] {
+		day := time.Unix(unix, 0).UTC()
+		if day.Before(from) || !day.Before(to) {
+			continue
+		}
+		sketches = append(sketches, models.VisitorSketch{LinkID: linkID, Day: day, Registers: registers})
+	}
+	sort.Slice(sketches, func(i, j int) bool { return sketches[i].Day.Before(sketches[j].Day) })
+	return sketches, nil
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/analytics"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// VisitorService fournit les estimations de visiteurs uniques, calculées à partir des
+// sketches HyperLogLog journaliers enregistrés par les workers de clics.
+type VisitorService struct {
+	visitorRepo repository.VisitorRepository
+}
+
+// NewVisitorService crée et retourne une nouvelle instance de VisitorService.
+func NewVisitorService(visitorRepo repository.VisitorRepository) *VisitorService {
+	return &VisitorService{
+		visitorRepo: visitorRepo,
+	}
+}
+
+// VisitorBucket est le nombre estimé de visiteurs uniques d'un lien sur un intervalle commençant à Start (UTC).
+type VisitorBucket struct {
+	Start    time.Time
+	Visitors int
+}
+
+// CountUniqueVisitors estime le nombre de visiteurs uniques d'un lien sur les journées (UTC)
+// comprises entre from (inclus) et to (exclu). Des bornes nulles couvrent tout l'historique.
+// Le sel des empreintes changeant chaque jour, un visiteur revenu plusieurs jours compte une fois par jour.
+func (s *VisitorService) CountUniqueVisitors(linkID uint, from, to *time.Time) (int, error) {
+	start := time.Time{}
+	if from != nil {
+		start = truncateToInterval(*from, repository.IntervalDay)
+	}
+	end := time.Now().UTC().AddDate(0, 0, 1)
+	if to != nil {
+		end = to.UTC()
+	}
+
+	sketches, err := s.visitorRepo.ListSketches(linkID, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get unique visitors: %w", err)
+	}
+
+	total := analytics.NewHyperLogLog()
+	for _, sketch := range sketches {
+		if err := mergeVisitorSketch(total, sketch); err != nil {
+			return 0, err
+		}
+	}
+	return total.Count(), nil
+}
+
+// UniqueVisitorsByInterval estime les visiteurs uniques d'un lien par jour ou par semaine entre
+// from (inclus) et to (exclu), bornes alignées sur l'intervalle. Seuls les intervalles ayant reçu
+// des visites sont retournés. Les sketches étant journaliers, l'intervalle horaire n'est pas supporté.
+func (s *VisitorService) UniqueVisitorsByInterval(linkID uint, from, to time.Time, interval string) ([]VisitorBucket, error) {
+	if interval != repository.IntervalDay && interval != repository.IntervalWeek {
+		return nil, fmt.Errorf("%w: unique visitors are only available per day or week", models.ErrInvalidTimeRange)
+	}
+
+	sketches, err := s.visitorRepo.ListSketches(linkID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unique visitors: %w", err)
+	}
+
+	var buckets []VisitorBucket
+	var current *analytics.HyperLogLog
+	var currentStart time.Time
+	for _, sketch := range sketches {
+		start := truncateToInterval(sketch.Day, interval)
+		if current == nil || !start.Equal(currentStart) {
+			if current != nil {
+				buckets = append(buckets, VisitorBucket{Start: currentStart, Visitors: current.Count()})
+			}
+			current, currentStart = analytics.NewHyperLogLog(), start
+		}
+		if err := mergeVisitorSketch(current, sketch); err != nil {
+			return nil, err
+		}
+	}
+	if current != nil {
+		buckets = append(buckets, VisitorBucket{Start: currentStart, Visitors: current.Count()})
+	}
+	return buckets, nil
+}
+
+func mergeVisitorSketch(into *analytics.HyperLogLog, sketch models.VisitorSketch) error {
+	hll, err := analytics.HyperLogLogFromBytes(sketch.Registers)
+	if err != nil {
+		return fmt.Errorf("corrupted visitor sketch for link %d on %s: %w", sketch.LinkID, sketch.Day.Format(time.DateOnly), err)
+	}
+	into.Merge(hll)
+	return nil
+}
@@ -0,0 +1,113 @@
+package shortcode
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+const (
+	// growthWindow est le nombre de derniers candidats sur lesquels le taux de collisions est mesuré.
+	growthWindow = 50
+	// growthMinSamples évite d'allonger les codes sur la foi de quelques candidats.
+	growthMinSamples = 10
+)
+
+// Random génère des codes de caractères tirés au hasard dans un alphabet.
+type Random struct {
+	alphabet string
+	growth   *growth
+}
+
+// NewRandom crée un générateur de codes de length caractères, allongés d'un caractère, jusqu'à
+// maxLength, chaque fois que la part de collisions atteint growthRate (0 : jamais).
+func NewRandom(alphabet string, length, maxLength int, growthRate float64) *Random {
+	return &Random{alphabet: alphabet, growth: newGrowth(length, maxLength, growthRate)}
+}
+
+// Generate retourne un code aléatoire de la longueur courante.
+func (g *Random) Generate() (string, error) {
+	result := make([]byte, g.growth.current())
+	for i := range result {
+		n, err := randomIndex(len(g.alphabet))
+		if err != nil {
+			return "", err
+		}
+		result[i] = g.alphabet[n]
+	}
+	return string(result), nil
+}
+
+// Observe mesure le taux de collisions.
+func (g *Random) Observe(taken bool) {
+	g.growth.observe(taken)
+}
+
+// Length retourne la longueur courante des codes.
+func (g *Random) Length() int {
+	return g.growth.current()
+}
+
+func randomIndex(n int) (int, error) {
+	num, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number: %w", err)
+	}
+	return int(num.Int64()), nil
+}
+
+// growth suit la taille des codes (en caractères ou en mots) et l'augmente quand la part des
+// candidats déjà attribués, parmi les growthWindow derniers, atteint rate.
+type growth struct {
+	rate float64
+
+	mu      sync.Mutex
+	size    int
+	maxSize int
+	// samples est un tampon circulaire des derniers résultats d'Observe.
+	samples []bool
+	next    int
+	count   int
+	taken   int
+}
+
+func newGrowth(size, maxSize int, rate float64) *growth {
+	return &growth{rate: rate, size: size, maxSize: max(size, maxSize), samples: make([]bool, growthWindow)}
+}
+
+func (g *growth) current() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.size
+}
+
+func (g *growth) observe(taken bool) {
+	if g.rate <= 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.size >= g.maxSize {
+		return
+	}
+
+	if g.count == len(g.samples) {
+		if g.samples[g.next] {
+			g.taken--
+		}
+	} else {
+		g.count++
+	}
+	g.samples[g.next] = taken
+	g.next = (g.next + 1) % len(g.samples)
+	if taken {
+		g.taken++
+	}
+
+	if g.count >= growthMinSamples && float64(g.taken) >= g.rate*float64(g.count) {
+		g.size++
+		// Les mesures faites sur l'ancienne taille ne valent plus pour la nouvelle.
+		g.next, g.count, g.taken = 0, 0, 0
+	}
+}
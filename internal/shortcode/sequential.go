@@ -0,0 +1,178 @@
+package shortcode
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"sync"
+)
+
+// sequence numérote les codes à partir du plus grand ID de lien : le numéro suit l'ID des liens
+// tant qu'un seul processus en crée. Un numéro n'est jamais réutilisé par un même processus,
+// même si le candidat était déjà attribué (lien créé par un autre processus).
+type sequence struct {
+	ids IDSource
+
+	mu   sync.Mutex
+	last uint64
+}
+
+func (s *sequence) next() (uint64, error) {
+	maxID, err := s.ids.MaxLinkID()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get next sequence number: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = max(uint64(maxID)+1, s.last+1)
+	return s.last, nil
+}
+
+// Sequential encode le numéro de chaque lien dans l'alphabet : les codes sont les plus courts
+// possibles, mais permettent d'énumérer les liens.
+type Sequential struct {
+	alphabet  string
+	minLength int
+	sequence  sequence
+}
+
+// NewSequential crée un générateur de codes d'au moins minLength caractères, complétés à gauche
+// par le premier caractère de l'alphabet.
+func NewSequential(alphabet string, minLength int, ids IDSource) *Sequential {
+	return &Sequential{alphabet: alphabet, minLength: minLength, sequence: sequence{ids: ids}}
+}
+
+// Generate retourne le code du numéro suivant.
+func (g *Sequential) Generate() (string, error) {
+	n, err := g.sequence.next()
+	if err != nil {
+		return "", err
+	}
+	return encode(n, g.alphabet, g.minLength), nil
+}
+
+// Observe n'a pas d'effet : un candidat déjà attribué est remplacé par celui du numéro suivant.
+func (g *Sequential) Observe(taken bool) {}
+
+// Hashids encode le numéro de chaque lien, à la manière de hashids : l'alphabet est mélangé selon
+// le sel, et les numéros sont permutés pour que deux liens consécutifs n'aient pas des codes voisins.
+// Les codes restent aussi courts que ceux de Sequential.
+type Hashids struct {
+	alphabet  string
+	minLength int
+	// multiplier et offset définissent la permutation n -> (n*multiplier + offset) mod len(alphabet)^longueur,
+	// bijective car multiplier est premier avec len(alphabet).
+	multiplier uint64
+	offset     uint64
+	sequence   sequence
+}
+
+// NewHashids crée un générateur de codes d'au moins minLength caractères, masqués par salt.
+func NewHashids(alphabet string, minLength int, salt string, ids IDSource) *Hashids {
+	shuffled := []byte(alphabet)
+	shuffle(shuffled, salt)
+
+	h := fnv.New64a()
+	h.Write([]byte(salt))
+	sum := h.Sum64()
+	multiplier := sum | 1
+	for gcd(multiplier, uint64(len(alphabet))) != 1 {
+		multiplier += 2
+	}
+
+	return &Hashids{
+		alphabet:   string(shuffled),
+		minLength:  minLength,
+		multiplier: multiplier,
+		offset:     bits.RotateLeft64(sum, 32),
+		sequence:   sequence{ids: ids},
+	}
+}
+
+// Generate retourne le code du numéro suivant.
+func (g *Hashids) Generate() (string, error) {
+	n, err := g.sequence.next()
+	if err != nil {
+		return "", err
+	}
+	return g.encode(n), nil
+}
+
+// Observe n'a pas d'effet : un candidat déjà attribué est remplacé par celui du numéro suivant.
+func (g *Hashids) Observe(taken bool) {}
+
+// encode permute n parmi les codes de la plus petite longueur (au moins minLength) qui peut le
+// représenter : deux numéros distincts donnent deux codes distincts.
+func (g *Hashids) encode(n uint64) string {
+	base := uint64(len(g.alphabet))
+	size, length := uint64(1), 0
+	for length < g.minLength || size <= n {
+		hi, lo := bits.Mul64(size, base)
+		if hi != 0 {
+			// len(alphabet)^longueur dépasse 64 bits : n est encodé sans permutation. New refuse les
+			// minLength concernés, seuls les numéros proches de 2^64 arrivent ici.
+			return encode(n, g.alphabet, length)
+		}
+		size, length = lo, length+1
+	}
+
+	hi, lo := bits.Mul64(n, g.multiplier%size)
+	permuted := bits.Rem64(hi, lo, size)
+	permuted, carry := bits.Add64(permuted, g.offset%size, 0)
+	if carry != 0 || permuted >= size {
+		permuted -= size
+	}
+	return encode(permuted, g.alphabet, length)
+}
+
+// maxHashidsLength retourne la plus grande longueur minimale des codes Hashids pour alphabet :
+// au-delà, len(alphabet)^longueur dépasse 64 bits et les numéros ne pourraient plus être permutés.
+func maxHashidsLength(alphabet string) int {
+	base := uint64(len(alphabet))
+	size, length := uint64(1), 0
+	for {
+		hi, lo := bits.Mul64(size, base)
+		if hi != 0 {
+			return length
+		}
+		size, length = lo, length+1
+	}
+}
+
+// encode écrit n en base len(alphabet), complété à gauche jusqu'à minLength caractères.
+func encode(n uint64, alphabet string, minLength int) string {
+	base := uint64(len(alphabet))
+	var digits []byte
+	for n > 0 || len(digits) < minLength {
+		digits = append(digits, alphabet[n%base])
+		n /= base
+	}
+	var b strings.Builder
+	for i := len(digits) - 1; i >= 0; i-- {
+		b.WriteByte(digits[i])
+	}
+	return b.String()
+}
+
+// shuffle mélange alphabet de façon déterministe selon salt (mélange cohérent de hashids).
+func shuffle(alphabet []byte, salt string) {
+	if salt == "" {
+		return
+	}
+	for i, v, p := len(alphabet)-1, 0, 0; i > 0; i-- {
+		v %= len(salt)
+		n := int(salt[v])
+		p += n
+		j := (n + v + p) % i
+		alphabet[i], alphabet[j] = alphabet[j], alphabet[i]
+		v++
+	}
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
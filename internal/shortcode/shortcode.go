@@ -0,0 +1,98 @@
+package shortcode
+
+import (
+	"fmt"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+// Stratégies de génération des codes courts.
+const (
+	StrategyRandom     = "random"
+	StrategySequential = "sequential"
+	StrategyHashids    = "hashids"
+	StrategyWords      = "words"
+)
+
+// Alphabets des codes random, sequential et hashids.
+const (
+	AlphabetBase62 = "base62"
+	// AlphabetUnambiguous exclut les caractères faciles à confondre à la lecture : 0/O et 1/l/I.
+	AlphabetUnambiguous = "unambiguous"
+)
+
+var alphabets = map[string]string{
+	AlphabetBase62:      "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789",
+	AlphabetUnambiguous: "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789",
+}
+
+// MaxCodeLength est la taille de la colonne short_code.
+const MaxCodeLength = 32
+
+// Generator produit les codes candidats des nouveaux liens. L'appelant vérifie que chaque candidat
+// est libre et signale le résultat avec Observe, ce qui permet d'allonger les codes quand l'espace
+// se remplit. Les implémentations sont sûres pour un usage concurrent.
+type Generator interface {
+	Generate() (string, error)
+	// Observe indique si le dernier candidat était déjà attribué.
+	Observe(taken bool)
+}
+
+// IDSource fournit le plus grand ID de lien attribué, à partir duquel les stratégies
+// sequential et hashids numérotent les codes.
+type IDSource interface {
+	MaxLinkID() (uint, error)
+}
+
+// NewDefault crée le générateur utilisé sans configuration : codes de 6 caractères base62
+// aléatoires, sans allongement.
+func NewDefault() Generator {
+	return NewRandom(alphabets[AlphabetBase62], 6, 6, 0)
+}
+
+// New crée le générateur de la stratégie configurée. ids n'est utilisé que par les stratégies
+// sequential et hashids.
+func New(cfg config.ShortCodeConfig, ids IDSource) (Generator, error) {
+	alphabet, ok := alphabets[cfg.Alphabet]
+	if !ok {
+		return nil, fmt.Errorf("unknown alphabet %q: must be %s or %s", cfg.Alphabet, AlphabetBase62, AlphabetUnambiguous)
+	}
+	if cfg.GrowthCollisionRate < 0 || cfg.GrowthCollisionRate > 1 {
+		return nil, fmt.Errorf("growth collision rate must be between 0 and 1, got %v", cfg.GrowthCollisionRate)
+	}
+
+	switch cfg.Strategy {
+	case StrategyRandom:
+		if cfg.Length < 1 || cfg.MaxLength < cfg.Length || cfg.MaxLength > MaxCodeLength {
+			return nil, fmt.Errorf("invalid random code lengths %d to %d: must be between 1 and %d", cfg.Length, cfg.MaxLength, MaxCodeLength)
+		}
+		return NewRandom(alphabet, cfg.Length, cfg.MaxLength, cfg.GrowthCollisionRate), nil
+	case StrategySequential, StrategyHashids:
+		if cfg.Length < 1 || cfg.Length > MaxCodeLength {
+			return nil, fmt.Errorf("invalid code length %d: must be between 1 and %d", cfg.Length, MaxCodeLength)
+		}
+		if ids == nil {
+			return nil, fmt.Errorf("strategy %q requires an ID source", cfg.Strategy)
+		}
+		if cfg.Strategy == StrategySequential {
+			return NewSequential(alphabet, cfg.Length, ids), nil
+		}
+		if maxLength := maxHashidsLength(alphabet); cfg.Length > maxLength {
+			return nil, fmt.Errorf("invalid hashids code length %d: must be at most %d with the %s alphabet", cfg.Length, maxLength, cfg.Alphabet)
+		}
+		return NewHashids(alphabet, cfg.Length, cfg.Salt, ids), nil
+	case StrategyWords:
+		switch cfg.WordSeparator {
+		case "", "-", "_":
+		default:
+			return nil, fmt.Errorf("invalid word separator %q: must be \"-\", \"_\" or empty", cfg.WordSeparator)
+		}
+		longest := cfg.MaxWords*maxWordLength + (cfg.MaxWords-1)*len(cfg.WordSeparator)
+		if cfg.Words < 2 || cfg.MaxWords < cfg.Words || longest > MaxCodeLength {
+			return nil, fmt.Errorf("invalid word counts %d to %d: codes must have at least 2 words and at most %d characters", cfg.Words, cfg.MaxWords, MaxCodeLength)
+		}
+		return NewWords(cfg.Words, cfg.MaxWords, cfg.WordSeparator, cfg.GrowthCollisionRate), nil
+	default:
+		return nil, fmt.Errorf("unknown strategy %q: must be random, sequential, hashids or words", cfg.Strategy)
+	}
+}
@@ -0,0 +1,251 @@
+package shortcode
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+// fakeIDs simule le plus grand ID de lien de la base.
+type fakeIDs struct {
+	maxID uint
+	err   error
+}
+
+func (f *fakeIDs) MaxLinkID() (uint, error) {
+	return f.maxID, f.err
+}
+
+func defaultConfig(strategy string) config.ShortCodeConfig {
+	return config.ShortCodeConfig{
+		Strategy:            strategy,
+		Alphabet:            AlphabetBase62,
+		Length:              6,
+		MaxLength:           10,
+		GrowthCollisionRate: 0.2,
+		Words:               3,
+		MaxWords:            4,
+		WordSeparator:       "-",
+	}
+}
+
+func generate(t *testing.T, g Generator) string {
+	t.Helper()
+	code, err := g.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	return code
+}
+
+func TestNew_Strategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		pattern  string
+	}{
+		{StrategyRandom, `^[a-zA-Z0-9]{6}$`},
+		{StrategySequential, `^[a-zA-Z0-9]{6}$`},
+		{StrategyHashids, `^[a-zA-Z0-9]{6}$`},
+		{StrategyWords, `^[a-z]+-[a-z]+-[a-z]+$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			g, err := New(defaultConfig(tt.strategy), &fakeIDs{maxID: 41})
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			if code := generate(t, g); !regexp.MustCompile(tt.pattern).MatchString(code) {
+				t.Errorf("Expected a code matching %s, got %q", tt.pattern, code)
+			}
+		})
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *config.ShortCodeConfig)
+	}{
+		{"unknown strategy", func(cfg *config.ShortCodeConfig) { cfg.Strategy = "uuid" }},
+		{"unknown alphabet", func(cfg *config.ShortCodeConfig) { cfg.Alphabet = "hex" }},
+		{"zero length", func(cfg *config.ShortCodeConfig) { cfg.Length = 0 }},
+		{"max length below length", func(cfg *config.ShortCodeConfig) { cfg.MaxLength = 4 }},
+		{"max length above column size", func(cfg *config.ShortCodeConfig) { cfg.MaxLength = 33 }},
+		{"growth rate above 1", func(cfg *config.ShortCodeConfig) { cfg.GrowthCollisionRate = 2 }},
+		{"single word", func(cfg *config.ShortCodeConfig) { cfg.Strategy, cfg.Words = StrategyWords, 1 }},
+		{"too many words", func(cfg *config.ShortCodeConfig) { cfg.Strategy, cfg.MaxWords = StrategyWords, 6 }},
+		{"invalid separator", func(cfg *config.ShortCodeConfig) { cfg.Strategy, cfg.WordSeparator = StrategyWords, "/" }},
+		{"hashids length above 64 bits", func(cfg *config.ShortCodeConfig) { cfg.Strategy, cfg.Length = StrategyHashids, 11 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(StrategyRandom)
+			tt.modify(&cfg)
+			if _, err := New(cfg, &fakeIDs{}); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+
+	if _, err := New(defaultConfig(StrategySequential), nil); err == nil {
+		t.Error("Expected the sequential strategy to require an ID source")
+	}
+}
+
+func TestUnambiguousAlphabet(t *testing.T) {
+	alphabet := alphabets[AlphabetUnambiguous]
+	if strings.ContainsAny(alphabet, "0O1lI") {
+		t.Errorf("Expected no ambiguous characters in %q", alphabet)
+	}
+	if len(alphabet) != 57 {
+		t.Errorf("Expected 57 characters, got %d", len(alphabet))
+	}
+
+	cfg := defaultConfig(StrategyRandom)
+	cfg.Alphabet = AlphabetUnambiguous
+	g, err := New(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		if code := generate(t, g); strings.ContainsAny(code, "0O1lI") {
+			t.Fatalf("Expected no ambiguous characters, got %q", code)
+		}
+	}
+}
+
+func TestRandom_Growth(t *testing.T) {
+	g := NewRandom(alphabets[AlphabetBase62], 6, 8, 0.2)
+
+	// Des collisions rares n'allongent pas les codes.
+	for i := 0; i < 100; i++ {
+		g.Observe(i%10 == 0)
+	}
+	if g.Length() != 6 {
+		t.Fatalf("Expected a 10%% collision rate to keep the length, got %d", g.Length())
+	}
+
+	// Quand l'espace est saturé, les codes sont allongés d'un caractère à la fois, jusqu'au maximum.
+	for i := 0; i < growthMinSamples; i++ {
+		g.Observe(true)
+	}
+	if g.Length() != 7 || len(generate(t, g)) != 7 {
+		t.Fatalf("Expected the length to grow to 7, got %d", g.Length())
+	}
+	for i := 0; i < 10*growthWindow; i++ {
+		g.Observe(true)
+	}
+	if g.Length() != 8 {
+		t.Errorf("Expected the length to stop at 8, got %d", g.Length())
+	}
+
+	disabled := NewRandom(alphabets[AlphabetBase62], 6, 8, 0)
+	for i := 0; i < growthWindow; i++ {
+		disabled.Observe(true)
+	}
+	if disabled.Length() != 6 {
+		t.Errorf("Expected a zero rate to disable growth, got %d", disabled.Length())
+	}
+}
+
+func TestSequential(t *testing.T) {
+	ids := &fakeIDs{maxID: 0}
+	g := NewSequential("abc", 3, ids)
+
+	var codes []string
+	for i := 0; i < 4; i++ {
+		codes = append(codes, generate(t, g))
+	}
+	// Un candidat déjà attribué est suivi du numéro suivant, même si la base n'a pas changé.
+	if got := strings.Join(codes, ","); got != "aab,aac,aba,abb" {
+		t.Errorf("Unexpected sequence: %s", got)
+	}
+
+	// Les liens créés par un autre processus sont sautés.
+	ids.maxID = 26
+	if code := generate(t, g); code != "baaa" {
+		t.Errorf("Expected the code of number 27, got %q", code)
+	}
+
+	ids.err = errors.New("database unavailable")
+	if _, err := g.Generate(); err == nil {
+		t.Error("Expected the ID source error")
+	}
+}
+
+func TestHashids(t *testing.T) {
+	alphabet := alphabets[AlphabetBase62]
+	g := NewHashids(alphabet, 4, "my salt", &fakeIDs{})
+
+	// Les codes sont distincts, y compris au passage à une longueur supérieure.
+	seen := make(map[string]uint64)
+	for n := uint64(1); n < 20000; n++ {
+		code := g.encode(n)
+		if other, ok := seen[code]; ok {
+			t.Fatalf("Numbers %d and %d share code %q", other, n, code)
+		}
+		seen[code] = n
+	}
+	if code := g.encode(14776336); len(code) != 5 {
+		t.Errorf("Expected 62^4 to need 5 characters, got %q", code)
+	}
+
+	// Deux numéros consécutifs n'ont pas des codes voisins, et le sel change les codes.
+	first, second := g.encode(1), g.encode(2)
+	if first[:3] == second[:3] {
+		t.Errorf("Expected consecutive numbers to have unrelated codes, got %q and %q", first, second)
+	}
+	if other := NewHashids(alphabet, 4, "other salt", &fakeIDs{}); other.encode(1) == first {
+		t.Error("Expected the salt to change the codes")
+	}
+	if again := NewHashids(alphabet, 4, "my salt", &fakeIDs{}); again.encode(1) != first {
+		t.Error("Expected the codes to be stable for a given salt")
+	}
+
+	// La plus grande longueur acceptée par New permute encore les numéros sur toute sa longueur.
+	if maxLength := maxHashidsLength(alphabet); maxLength != 10 {
+		t.Errorf("Expected a maximum length of 10 for base62, got %d", maxLength)
+	}
+	long := NewHashids(alphabet, 10, "my salt", &fakeIDs{})
+	if code := long.encode(1); len(code) != 10 || code == encode(1, long.alphabet, 10) {
+		t.Errorf("Expected a permuted 10-character code, got %q", code)
+	}
+	cfg := defaultConfig(StrategyHashids)
+	cfg.Length = 10
+	if _, err := New(cfg, &fakeIDs{}); err != nil {
+		t.Errorf("Expected a length of 10 to be accepted, got %v", err)
+	}
+
+	// Au-delà de 64 bits, le numéro est encodé sans permutation.
+	if code := g.encode(^uint64(0)); len(code) != 11 {
+		t.Errorf("Expected the largest number to be encoded on 11 characters, got %q", code)
+	}
+}
+
+func TestWords(t *testing.T) {
+	if len(dictionary) < 256 {
+		t.Fatalf("Expected at least 256 words, got %d", len(dictionary))
+	}
+	seen := make(map[string]bool)
+	for _, word := range dictionary {
+		if seen[word] || !regexp.MustCompile(`^[a-z]{3,}$`).MatchString(word) {
+			t.Errorf("Invalid or duplicate word %q", word)
+		}
+		seen[word] = true
+	}
+
+	g := NewWords(2, 3, "", 0.2)
+	code := generate(t, g)
+	if len(code) < 6 || len(code) > 2*maxWordLength || strings.Contains(code, "-") {
+		t.Errorf("Expected two words without separator, got %q", code)
+	}
+	for i := 0; i < growthMinSamples; i++ {
+		g.Observe(true)
+	}
+	if g.Count() != 3 {
+		t.Errorf("Expected a third word after repeated collisions, got %d", g.Count())
+	}
+}
@@ -0,0 +1,59 @@
+package shortcode
+
+import (
+	_ "embed"
+	"strings"
+)
+
+// words.txt liste des mots anglais courts, en minuscules ASCII, un par ligne.
+//
+//go:embed words.txt
+var wordList string
+
+var (
+	dictionary    = strings.Fields(wordList)
+	maxWordLength = longestWord(dictionary)
+)
+
+// Words génère des codes lisibles, faits de mots tirés au hasard (ex: brave-otter-maple).
+type Words struct {
+	separator string
+	growth    *growth
+}
+
+// NewWords crée un générateur de codes de count mots séparés par separator, allongés d'un mot,
+// jusqu'à maxCount, chaque fois que la part de collisions atteint growthRate (0 : jamais).
+func NewWords(count, maxCount int, separator string, growthRate float64) *Words {
+	return &Words{separator: separator, growth: newGrowth(count, maxCount, growthRate)}
+}
+
+// Generate retourne un code du nombre de mots courant.
+func (g *Words) Generate() (string, error) {
+	words := make([]string, g.growth.current())
+	for i := range words {
+		n, err := randomIndex(len(dictionary))
+		if err != nil {
+			return "", err
+		}
+		words[i] = dictionary[n]
+	}
+	return strings.Join(words, g.separator), nil
+}
+
+// Observe mesure le taux de collisions.
+func (g *Words) Observe(taken bool) {
+	g.growth.observe(taken)
+}
+
+// Count retourne le nombre courant de mots par code.
+func (g *Words) Count() int {
+	return g.growth.current()
+}
+
+func longestWord(words []string) int {
+	longest := 0
+	for _, word := range words {
+		longest = max(longest, len(word))
+	}
+	return longest
+}
@@ -0,0 +1,380 @@
+package spool
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// ErrFull est retourné par Append quand le spool a atteint sa taille maximale.
+var ErrFull = errors.New("click spool is full")
+
+// ErrClosed est retourné par Append après la fermeture du spool.
+var ErrClosed = errors.New("click spool is closed")
+
+const (
+	segmentSuffix = ".seg"
+	tmpSuffix     = ".tmp"
+	// headerSize : longueur puis somme de contrôle CRC-32C du contenu, en little-endian.
+	headerSize = 8
+	// maxRecordSize borne la taille d'un enregistrement relu, pour qu'une longueur corrompue
+	// ne provoque pas une allocation démesurée.
+	maxRecordSize = 1 << 20
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Spool est un journal local, en ajout seul, des événements de clic qui n'ont pas pu être
+// transmis aux workers. Il est découpé en segments numérotés : les nouveaux événements sont
+// ajoutés au segment courant, et chaque relecture commence par le clore, de sorte que les
+// segments relus ne sont plus modifiés que pour retirer les événements déjà transmis.
+//
+// Chaque enregistrement porte une somme de contrôle : une fin de segment tronquée ou corrompue
+// (arrêt brutal pendant une écriture) est ignorée à la relecture. La transmission est garantie
+// au moins une fois : un événement relu ne quitte le spool qu'après l'appel de son Done par les
+// workers, et un arrêt pendant une relecture peut dupliquer des clics.
+type Spool struct {
+	dir          string
+	segmentBytes int64
+	maxBytes     int64
+	logger       *slog.Logger
+
+	mu          sync.Mutex
+	current     *os.File
+	currentSize int64
+	lastSeq     uint64
+	totalBytes  int64
+	closed      bool
+
+	// replayMu empêche deux relectures simultanées.
+	replayMu sync.Mutex
+}
+
+// Open ouvre (ou crée) le spool du répertoire dir. Un segment est clos dès qu'il dépasse
+// segmentBytes octets ; maxBytes borne la taille totale du spool (0 : pas de limite).
+// Les segments laissés par une exécution précédente sont conservés pour être relus.
+func Open(dir string, segmentBytes, maxBytes int64) (*Spool, error) {
+	if segmentBytes <= 0 {
+		return nil, fmt.Errorf("invalid spool segment size %d", segmentBytes)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	s := &Spool{dir: dir, segmentBytes: segmentBytes, maxBytes: maxBytes, logger: slog.Default()}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, tmpSuffix) {
+			// Réécriture interrompue : le segment d'origine est toujours en place.
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return nil, fmt.Errorf("failed to remove stale spool file: %w", err)
+			}
+			continue
+		}
+		seq, ok := parseSegmentName(name)
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat spool segment: %w", err)
+		}
+		s.totalBytes += info.Size()
+		if seq > s.lastSeq {
+			s.lastSeq = seq
+		}
+	}
+	return s, nil
+}
+
+// SetLogger remplace le logger du spool, par défaut slog.Default().
+func (s *Spool) SetLogger(logger *slog.Logger) {
+	s.logger = logger.With("component", "click_spool")
+}
+
+// Append ajoute durablement un événement au spool : il est écrit et synchronisé sur disque
+// avant le retour.
+func (s *Spool) Append(event models.ClickEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode click event: %w", err)
+	}
+	record := encodeRecord(payload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if s.maxBytes > 0 && s.totalBytes+int64(len(record)) > s.maxBytes {
+		return ErrFull
+	}
+	if s.current != nil && s.currentSize+int64(len(record)) > s.segmentBytes {
+		if err := s.sealLocked(); err != nil {
+			return err
+		}
+	}
+	if s.current == nil {
+		s.lastSeq++
+		file, err := os.OpenFile(s.segmentPath(s.lastSeq), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to create spool segment: %w", err)
+		}
+		s.current, s.currentSize = file, 0
+	}
+
+	n, err := s.current.Write(record)
+	if err == nil {
+		err = s.current.Sync()
+	}
+	s.currentSize += int64(n)
+	s.totalBytes += int64(n)
+	if err != nil {
+		// Un enregistrement partiel termine le segment : les suivants iront dans un nouveau segment.
+		_ = s.sealLocked()
+		return fmt.Errorf("failed to write click event to spool: %w", err)
+	}
+	return nil
+}
+
+// HasPending indique si le spool contient des événements à relire.
+func (s *Spool) HasPending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.totalBytes > 0
+}
+
+// Replay relit les événements du spool, du plus ancien au plus récent, et les passe à send avec
+// un Done renseigné, que le destinataire doit appeler une fois l'événement traité. Un segment n'est
+// supprimé qu'après l'appel de Done pour chacun de ses événements. Si send retourne false, la
+// relecture s'interrompt et les événements non transmis restent dans le spool. Si ctx expire avant
+// que les événements transmis d'un segment soient traités, le segment est conservé tel quel : ils
+// seront relus, au risque d'un doublon. Retourne le nombre d'événements transmis.
+func (s *Spool) Replay(ctx context.Context, send func(models.ClickEvent) bool) (int, error) {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	s.mu.Lock()
+	if err := s.sealLocked(); err != nil {
+		s.mu.Unlock()
+		return 0, err
+	}
+	segments, err := s.listSegments()
+	s.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, path := range segments {
+		records, size, err := s.readSegment(path)
+		if err != nil {
+			return replayed, err
+		}
+
+		var inFlight sync.WaitGroup
+		for i, record := range records {
+			var event models.ClickEvent
+			if err := json.Unmarshal(record, &event); err != nil {
+				s.logger.Warn("Événement illisible ignoré dans un segment du spool", "segment", filepath.Base(path), "error", err)
+				continue
+			}
+			inFlight.Add(1)
+			event.Done = sync.OnceFunc(inFlight.Done)
+			if !send(event) {
+				inFlight.Done()
+				if !waitDone(ctx, &inFlight) {
+					return replayed, nil
+				}
+				return replayed, s.rewriteSegment(path, size, records[i:])
+			}
+			replayed++
+		}
+
+		if !waitDone(ctx, &inFlight) {
+			return replayed, nil
+		}
+		if err := os.Remove(path); err != nil {
+			return replayed, fmt.Errorf("failed to remove replayed spool segment: %w", err)
+		}
+		s.mu.Lock()
+		s.totalBytes -= size
+		s.mu.Unlock()
+	}
+	return replayed, nil
+}
+
+// waitDone attend le traitement des événements transmis de wg, et retourne false si ctx expire avant.
+func waitDone(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Close ferme le segment courant. Les événements du spool seront relus à la prochaine ouverture.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return s.sealLocked()
+}
+
+// sealLocked clôt le segment courant ; s.mu doit être verrouillé.
+func (s *Spool) sealLocked() error {
+	if s.current == nil {
+		return nil
+	}
+	err := s.current.Close()
+	s.current, s.currentSize = nil, 0
+	if err != nil {
+		return fmt.Errorf("failed to close spool segment: %w", err)
+	}
+	return nil
+}
+
+// listSegments retourne les chemins des segments, du plus ancien au plus récent.
+func (s *Spool) listSegments() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+	var seqs []uint64
+	for _, entry := range entries {
+		if seq, ok := parseSegmentName(entry.Name()); ok {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	paths := make([]string, len(seqs))
+	for i, seq := range seqs {
+		paths[i] = s.segmentPath(seq)
+	}
+	return paths, nil
+}
+
+// rewriteSegment remplace atomiquement un segment de size octets par ses enregistrements restants.
+func (s *Spool) rewriteSegment(path string, size int64, remaining [][]byte) error {
+	tmpPath := path + tmpSuffix
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to rewrite spool segment: %w", err)
+	}
+	var written int64
+	for _, record := range remaining {
+		n, werr := file.Write(encodeRecord(record))
+		written += int64(n)
+		if werr != nil {
+			err = werr
+			break
+		}
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to rewrite spool segment: %w", err)
+	}
+
+	s.mu.Lock()
+	s.totalBytes += written - size
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Spool) segmentPath(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%016d%s", seq, segmentSuffix))
+}
+
+func parseSegmentName(name string) (uint64, bool) {
+	if !strings.HasSuffix(name, segmentSuffix) {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
+
+func encodeRecord(payload []byte) []byte {
+	record := make([]byte, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.Checksum(payload, crcTable))
+	copy(record[headerSize:], payload)
+	return record
+}
+
+// readSegment retourne le contenu des enregistrements valides d'un segment et sa taille sur disque.
+// La lecture s'arrête au premier enregistrement tronqué ou corrompu.
+func (s *Spool) readSegment(path string) ([][]byte, int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read spool segment: %w", err)
+	}
+
+	var records [][]byte
+	offset := 0
+	for offset < len(data) {
+		payload, err := decodeRecord(data[offset:])
+		if err != nil {
+			s.logger.Warn("Fin de segment du spool corrompue ignorée",
+				"segment", filepath.Base(path), "offset", offset, "bytes", len(data)-offset, "error", err)
+			break
+		}
+		records = append(records, payload)
+		offset += headerSize + len(payload)
+	}
+	return records, int64(len(data)), nil
+}
+
+func decodeRecord(data []byte) ([]byte, error) {
+	if len(data) < headerSize {
+		return nil, io.ErrUnexpectedEOF
+	}
+	length := binary.LittleEndian.Uint32(data[0:4])
+	if length > maxRecordSize {
+		return nil, fmt.Errorf("invalid record length %d", length)
+	}
+	if uint32(len(data)-headerSize) < length {
+		return nil, io.ErrUnexpectedEOF
+	}
+	payload := data[headerSize : headerSize+int(length)]
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(data[4:8]) {
+		return nil, errors.New("checksum mismatch")
+	}
+	return payload, nil
+}
@@ -0,0 +1,279 @@
+package spool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+func testEvent(linkID uint) models.ClickEvent {
+	return models.ClickEvent{
+		LinkID:    linkID,
+		Timestamp: time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC),
+		UserAgent: "Mozilla/5.0",
+		IPAddress: "203.0.113.7",
+		Method:    "GET",
+	}
+}
+
+func mustOpen(t *testing.T, dir string, segmentBytes, maxBytes int64) *Spool {
+	t.Helper()
+	s, err := Open(dir, segmentBytes, maxBytes)
+	if err != nil {
+		t.Fatalf("Failed to open spool: %v", err)
+	}
+	return s
+}
+
+func mustAppend(t *testing.T, s *Spool, linkIDs ...uint) {
+	t.Helper()
+	for _, id := range linkIDs {
+		if err := s.Append(testEvent(id)); err != nil {
+			t.Fatalf("Failed to append event %d: %v", id, err)
+		}
+	}
+}
+
+// replayAll relit le spool et retourne les LinkID transmis, en acceptant au plus limit événements
+// (limit négatif : pas de limite).
+func replayAll(t *testing.T, s *Spool, limit int) []uint {
+	t.Helper()
+	var ids []uint
+	_, err := s.Replay(context.Background(), func(event models.ClickEvent) bool {
+		if limit >= 0 && len(ids) == limit {
+			return false
+		}
+		ids = append(ids, event.LinkID)
+		event.Done()
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Failed to replay spool: %v", err)
+	}
+	return ids
+}
+
+func assertIDs(t *testing.T, got []uint, want ...uint) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("Expected events %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected events %v, got %v", want, got)
+		}
+	}
+}
+
+func segmentCount(t *testing.T, dir string) int {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(matches)
+}
+
+func TestSpool_AppendAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	// Des segments de 200 octets ne contiennent qu'un ou deux événements.
+	s := mustOpen(t, dir, 200, 0)
+	mustAppend(t, s, 1, 2, 3, 4, 5)
+
+	if segmentCount(t, dir) < 2 {
+		t.Errorf("Expected the spool to be split into several segments")
+	}
+	if !s.HasPending() {
+		t.Errorf("Expected pending events")
+	}
+
+	event := testEvent(1)
+	_, err := s.Replay(context.Background(), func(got models.ClickEvent) bool {
+		got.Done()
+		if got.LinkID == 1 && (!got.Timestamp.Equal(event.Timestamp) || got.IPAddress != event.IPAddress ||
+			got.UserAgent != event.UserAgent || got.Method != event.Method) {
+			t.Errorf("Expected replayed event %+v, got %+v", event, got)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Failed to replay spool: %v", err)
+	}
+
+	if s.HasPending() {
+		t.Errorf("Expected no pending events after a full replay")
+	}
+	if n := segmentCount(t, dir); n != 0 {
+		t.Errorf("Expected replayed segments to be removed, %d left", n)
+	}
+	assertIDs(t, replayAll(t, s, -1))
+}
+
+func TestSpool_InterruptedReplayKeepsRemainingEvents(t *testing.T) {
+	dir := t.TempDir()
+	s := mustOpen(t, dir, 1<<20, 0)
+	mustAppend(t, s, 1, 2, 3, 4)
+
+	assertIDs(t, replayAll(t, s, 2), 1, 2)
+	mustAppend(t, s, 5)
+	assertIDs(t, replayAll(t, s, -1), 3, 4, 5)
+}
+
+func TestSpool_KeepsReplayedEventsUntilDone(t *testing.T) {
+	dir := t.TempDir()
+	s := mustOpen(t, dir, 1<<20, 0)
+	mustAppend(t, s, 1, 2, 3)
+
+	// Des événements transmis mais jamais traités (arrêt avant leur enregistrement) restent dans
+	// le spool, y compris ceux qui précèdent l'interruption.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var sent []models.ClickEvent
+	replayed, err := s.Replay(ctx, func(event models.ClickEvent) bool {
+		if len(sent) == 2 {
+			return false
+		}
+		sent = append(sent, event)
+		return true
+	})
+	if err != nil || replayed != 2 {
+		t.Fatalf("Expected 2 replayed events, got %d (err: %v)", replayed, err)
+	}
+	if n := segmentCount(t, dir); n != 1 {
+		t.Fatalf("Expected the segment to be kept, %d left", n)
+	}
+
+	// Le segment est retiré une fois tous ses événements traités, même après le retour de send.
+	events := make(chan models.ClickEvent, 3)
+	done := make(chan error)
+	go func() {
+		_, err := s.Replay(context.Background(), func(event models.ClickEvent) bool {
+			events <- event
+			return true
+		})
+		done <- err
+	}()
+	received := make([]models.ClickEvent, 0, 3)
+	for len(received) < 3 {
+		received = append(received, <-events)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Expected the replay to wait for Done, returned %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	for _, event := range received {
+		event.Done()
+		event.Done()
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Failed to replay spool: %v", err)
+	}
+	if s.HasPending() || segmentCount(t, dir) != 0 {
+		t.Errorf("Expected the replayed events to leave the spool")
+	}
+}
+
+func TestSpool_SurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	s := mustOpen(t, dir, 1<<20, 0)
+	mustAppend(t, s, 1, 2)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Failed to close spool: %v", err)
+	}
+	if err := s.Append(testEvent(3)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+
+	reopened := mustOpen(t, dir, 1<<20, 0)
+	if !reopened.HasPending() {
+		t.Errorf("Expected pending events after reopening")
+	}
+	mustAppend(t, reopened, 3)
+	assertIDs(t, replayAll(t, reopened, -1), 1, 2, 3)
+}
+
+func TestSpool_SkipsCorruptedTail(t *testing.T) {
+	dir := t.TempDir()
+	s := mustOpen(t, dir, 1<<20, 0)
+	mustAppend(t, s, 1, 2)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Failed to close spool: %v", err)
+	}
+
+	// Simule un arrêt brutal au milieu de l'écriture d'un enregistrement.
+	path := filepath.Join(dir, "0000000000000001"+segmentSuffix)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := encodeRecord([]byte(`{"LinkID":3}`))
+	if _, err := file.Write(record[:len(record)-3]); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	reopened := mustOpen(t, dir, 1<<20, 0)
+	var logs bytes.Buffer
+	reopened.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	assertIDs(t, replayAll(t, reopened, -1), 1, 2)
+	if !strings.Contains(logs.String(), "component=click_spool") {
+		t.Errorf("Expected the corrupted tail to be logged through the spool logger, got %q", logs.String())
+	}
+}
+
+func TestSpool_DetectsChecksumMismatch(t *testing.T) {
+	dir := t.TempDir()
+	s := mustOpen(t, dir, 1<<20, 0)
+	mustAppend(t, s, 1, 2)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Failed to close spool: %v", err)
+	}
+
+	path := filepath.Join(dir, "0000000000000001"+segmentSuffix)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Altère le contenu du second enregistrement.
+	data[len(data)-2] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := mustOpen(t, dir, 1<<20, 0)
+	assertIDs(t, replayAll(t, reopened, -1), 1)
+}
+
+func TestSpool_MaxBytes(t *testing.T) {
+	dir := t.TempDir()
+	recordSize := int64(len(encodeRecord(mustMarshal(t, testEvent(1)))))
+	s := mustOpen(t, dir, 1<<20, 2*recordSize)
+	mustAppend(t, s, 1, 2)
+
+	if err := s.Append(testEvent(3)); !errors.Is(err, ErrFull) {
+		t.Errorf("Expected ErrFull, got %v", err)
+	}
+
+	// La relecture libère de la place.
+	assertIDs(t, replayAll(t, s, -1), 1, 2)
+	mustAppend(t, s, 3)
+}
+
+func mustMarshal(t *testing.T, event models.ClickEvent) []byte {
+	t.Helper()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
@@ -1,34 +1,235 @@
 package workers
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/analytics"
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/models"
-	"github.com/axellelanca/urlshortener/internal/repository" 
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/spool"
+)
+
+// BatchConfig règle le regroupement des clics avant leur enregistrement : un lot est enregistré
+// dès qu'il contient Size clics, ou MaxLatency après la réception de son premier clic.
+type BatchConfig struct {
+	Size       int
+	MaxLatency time.Duration
+}
+
+// defaultBatchMaxLatency s'applique quand BatchConfig.MaxLatency n'est pas positif.
+const defaultBatchMaxLatency = 500 * time.Millisecond
+
+// Un lot refusé parce que la base est verrouillée est retenté jusqu'à flushAttempts fois,
+// en doublant l'attente entre deux tentatives.
+const (
+	flushAttempts       = 5
+	flushInitialBackoff = 50 * time.Millisecond
 )
 
-func StartClickWorkers(workerCount int, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
-	log.Printf("Starting %d click worker(s)...", workerCount)
+// WorkerOptions regroupe les dépendances optionnelles des workers de clics.
+// Une dépendance nil désactive la fonctionnalité correspondante.
+type WorkerOptions struct {
+	Batch BatchConfig
+	// Visitors alimente l'estimation des visiteurs uniques avec les clics humains.
+	Visitors *analytics.VisitorCounter
+	// Spool reçoit les événements d'un lot qui n'a pas pu être enregistré faute d'accès à la base.
+	Spool *spool.Spool
+	// Metrics mesure les enregistrements de lots et compte les clics perdus ou mis en spool.
+	Metrics *metrics.Metrics
+	// Logger reçoit les logs des workers ; nil, le logger par défaut est utilisé.
+	Logger *slog.Logger
+}
+
+// PipelineStats compte les événements de clic traités par les workers depuis leur démarrage.
+type PipelineStats struct {
+	Saved   int64
+	Spooled int64
+	Dropped int64
+}
+
+// ClickWorkers est le pool de workers démarré par StartClickWorkers.
+type ClickWorkers struct {
+	clickRepo repository.ClickRepository
+	enricher  *analytics.ClickEnricher
+	opts      WorkerOptions
+
+	wg      sync.WaitGroup
+	saved   atomic.Int64
+	spooled atomic.Int64
+	dropped atomic.Int64
+}
+
+// StartClickWorkers démarre workerCount goroutines qui enregistrent par lots les clics reçus sur
+// clickEventsChan. Les workers s'arrêtent, après avoir enregistré leur dernier lot, à la fermeture du channel.
+func StartClickWorkers(workerCount int, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository,
+	enricher *analytics.ClickEnricher, opts WorkerOptions) *ClickWorkers {
+	if opts.Batch.Size < 1 {
+		opts.Batch.Size = 1
+	}
+	if opts.Batch.MaxLatency <= 0 {
+		opts.Batch.MaxLatency = defaultBatchMaxLatency
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	opts.Logger = opts.Logger.With("component", "click_workers")
+
+	w := &ClickWorkers{clickRepo: clickRepo, enricher: enricher, opts: opts}
+	opts.Logger.Info("Starting click workers",
+		"workers", workerCount, "batch_size", opts.Batch.Size, "batch_max_latency", opts.Batch.MaxLatency)
 	for i := 0; i < workerCount; i++ {
-		go clickWorker(clickEventsChan, clickRepo)
+		w.wg.Add(1)
+		go w.run(clickEventsChan)
 	}
+	return w
 }
 
-func clickWorker(clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
-	for event := range clickEventsChan {
-		click := &models.Click{
-			LinkID:    event.LinkID,
-			Timestamp: event.Timestamp,
-			UserAgent: event.UserAgent,
-			IPAddress: event.IPAddress,
-		}		
-		err := clickRepo.CreateClick(click)
+// Wait attend que les workers aient enregistré tous les événements du channel, qui doit avoir
+// été fermé. Retourne l'erreur de ctx si celui-ci expire avant.
+func (w *ClickWorkers) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats retourne les compteurs des workers.
+func (w *ClickWorkers) Stats() PipelineStats {
+	return PipelineStats{
+		Saved:   w.saved.Load(),
+		Spooled: w.spooled.Load(),
+		Dropped: w.dropped.Load(),
+	}
+}
 
-		if err != nil {
-			log.Printf("ERROR: Failed to save click for LinkID %d (UserAgent: %s, IP: %s): %v",
-				event.LinkID, event.UserAgent, event.IPAddress, err)
-		} else {
-			log.Printf("Click recorded successfully for LinkID %d", event.LinkID)
+func (w *ClickWorkers) run(clickEventsChan <-chan models.ClickEvent) {
+	defer w.wg.Done()
+
+	size := w.opts.Batch.Size
+	pendingClicks := make([]*models.Click, 0, size)
+	pendingEvents := make([]models.ClickEvent, 0, size)
+	// timeout n'est armé que lorsqu'un lot est en cours.
+	var timeout <-chan time.Time
+
+	flush := func() {
+		timeout = nil
+		if len(pendingClicks) == 0 {
+			return
+		}
+		w.saveClicks(pendingClicks, pendingEvents)
+		for _, event := range pendingEvents {
+			markDone(event)
+		}
+		pendingClicks = make([]*models.Click, 0, size)
+		pendingEvents = make([]models.ClickEvent, 0, size)
+	}
+
+	for {
+		select {
+		case event, ok := <-clickEventsChan:
+			if !ok {
+				flush()
+				return
+			}
+
+			click, err := w.enricher.NewClick(event)
+			if err != nil {
+				w.opts.Logger.Error("Failed to prepare click", "link_id", event.LinkID, "error", err)
+				w.dropped.Add(1)
+				w.opts.Metrics.ClickEventsDropped(metrics.DropInsertFailed, 1)
+				markDone(event)
+				continue
+			}
+			if w.opts.Visitors != nil && !click.IsBot {
+				if err := w.opts.Visitors.Record(event.LinkID, event.Timestamp, event.IPAddress, event.UserAgent); err != nil {
+					w.opts.Logger.Error("Failed to record visitor", "link_id", event.LinkID, "error", err)
+				}
+			}
+
+			pendingClicks = append(pendingClicks, click)
+			pendingEvents = append(pendingEvents, event)
+			if len(pendingClicks) == 1 {
+				timeout = time.After(w.opts.Batch.MaxLatency)
+			}
+			if len(pendingClicks) >= size {
+				flush()
+			}
+		case <-timeout:
+			flush()
 		}
 	}
 }
 
+// saveClicks enregistre un lot de clics en retentant les échecs dus au verrouillage de la base.
+// Si la base reste inaccessible, les événements d'origine sont confiés au spool. Un lot refusé pour
+// une autre raison est coupé en deux et chaque moitié retentée, jusqu'à isoler les clics refusés :
+// seuls ceux-ci sont perdus.
+func (w *ClickWorkers) saveClicks(clicks []*models.Click, events []models.ClickEvent) {
+	backoff := flushInitialBackoff
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := w.clickRepo.CreateClicks(clicks)
+		w.opts.Metrics.ObserveClickInsert(len(clicks), time.Since(start), err)
+		if err == nil {
+			w.saved.Add(int64(len(clicks)))
+			w.opts.Logger.Debug("Recorded click batch", "clicks", len(clicks))
+			return
+		}
+
+		transient := repository.IsTransientError(err)
+		if !transient && len(clicks) > 1 {
+			w.opts.Logger.Warn("Click batch rejected, splitting it to isolate failing clicks", "clicks", len(clicks), "error", err)
+			half := len(clicks) / 2
+			w.saveClicks(clicks[:half], events[:half])
+			w.saveClicks(clicks[half:], events[half:])
+			return
+		}
+		if !transient || attempt == flushAttempts {
+			w.opts.Logger.Error("Failed to save click batch", "clicks", len(clicks), "attempts", attempt, "error", err)
+			if transient && w.opts.Spool != nil {
+				w.spoolEvents(events)
+			} else {
+				w.dropped.Add(int64(len(clicks)))
+				w.opts.Metrics.ClickEventsDropped(metrics.DropInsertFailed, len(clicks))
+			}
+			return
+		}
+		w.opts.Logger.Warn("Database busy while saving click batch, retrying",
+			"clicks", len(clicks), "attempt", attempt, "max_attempts", flushAttempts, "backoff", backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (w *ClickWorkers) spoolEvents(events []models.ClickEvent) {
+	for _, event := range events {
+		if err := w.opts.Spool.Append(event); err != nil {
+			w.opts.Logger.Error("Failed to spool click event", "link_id", event.LinkID, "error", err)
+			w.dropped.Add(1)
+			w.opts.Metrics.ClickEventsDropped(metrics.DropInsertFailed, 1)
+			continue
+		}
+		w.spooled.Add(1)
+		w.opts.Metrics.ClickEventsSpooled(1)
+	}
+	w.opts.Logger.Warn("Spooled click batch for later replay", "clicks", len(events))
+}
+
+// markDone signale le traitement d'un événement à son émetteur (le spool pour un événement relu).
+func markDone(event models.ClickEvent) {
+	if event.Done != nil {
+		event.Done()
+	}
+}
@@ -0,0 +1,272 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/analytics"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services/mocks"
+	"github.com/axellelanca/urlshortener/internal/spool"
+)
+
+// batchRecorder transmet chaque lot enregistré sur batches et refuse les failures premiers lots
+// avec failErr.
+type batchRecorder struct {
+	*mocks.MockClickRepository
+	mu       sync.Mutex
+	failures int
+	failErr  error
+	attempts int
+	batches  chan []*models.Click
+}
+
+func newBatchRecorder(failures int, failErr error) *batchRecorder {
+	return &batchRecorder{
+		MockClickRepository: mocks.NewMockClickRepository(),
+		failures:            failures,
+		failErr:             failErr,
+		batches:             make(chan []*models.Click, 10),
+	}
+}
+
+func (r *batchRecorder) CreateClicks(clicks []*models.Click) error {
+	r.mu.Lock()
+	r.attempts++
+	if r.failures > 0 {
+		r.failures--
+		r.mu.Unlock()
+		return r.failErr
+	}
+	r.mu.Unlock()
+	r.batches <- clicks
+	return nil
+}
+
+func startWorker(t *testing.T, repo *batchRecorder, batch BatchConfig) chan models.ClickEvent {
+	t.Helper()
+	events := make(chan models.ClickEvent, 10)
+	enricher := analytics.NewClickEnricher(analytics.NewBotDetector(nil), nil)
+	StartClickWorkers(1, events, repo, enricher, WorkerOptions{Batch: batch})
+	t.Cleanup(func() { close(events) })
+	return events
+}
+
+func sendClicks(events chan<- models.ClickEvent, n int) {
+	for i := 0; i < n; i++ {
+		events <- models.ClickEvent{LinkID: 1, Timestamp: time.Now(), UserAgent: "Mozilla/5.0 Firefox/120.0", Method: "GET"}
+	}
+}
+
+func waitBatch(t *testing.T, repo *batchRecorder, timeout time.Duration) []*models.Click {
+	t.Helper()
+	select {
+	case batch := <-repo.batches:
+		return batch
+	case <-time.After(timeout):
+		t.Fatalf("No batch recorded within %s", timeout)
+		return nil
+	}
+}
+
+func TestClickWorker_FlushesFullBatches(t *testing.T) {
+	repo := newBatchRecorder(0, nil)
+	events := startWorker(t, repo, BatchConfig{Size: 3, MaxLatency: time.Hour})
+
+	sendClicks(events, 7)
+	for i := 0; i < 2; i++ {
+		if got := len(waitBatch(t, repo, time.Second)); got != 3 {
+			t.Errorf("Expected a batch of 3 clicks, got %d", got)
+		}
+	}
+
+	// Le dernier lot, incomplet, attend l'expiration du délai maximal.
+	select {
+	case batch := <-repo.batches:
+		t.Errorf("Unexpected early flush of %d click(s)", len(batch))
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestClickWorker_FlushesAfterMaxLatency(t *testing.T) {
+	repo := newBatchRecorder(0, nil)
+	events := startWorker(t, repo, BatchConfig{Size: 100, MaxLatency: 20 * time.Millisecond})
+
+	sendClicks(events, 2)
+	if got := len(waitBatch(t, repo, time.Second)); got != 2 {
+		t.Errorf("Expected a batch of 2 clicks, got %d", got)
+	}
+}
+
+func TestClickWorker_FlushesOnClose(t *testing.T) {
+	repo := newBatchRecorder(0, nil)
+	events := make(chan models.ClickEvent, 10)
+	pool := StartClickWorkers(2, events, repo, analytics.NewClickEnricher(analytics.NewBotDetector(nil), nil),
+		WorkerOptions{Batch: BatchConfig{Size: 100, MaxLatency: time.Hour}})
+
+	sendClicks(events, 4)
+	close(events)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := pool.Wait(ctx); err != nil {
+		t.Fatalf("Workers did not stop: %v", err)
+	}
+	if stats := pool.Stats(); stats != (PipelineStats{Saved: 4}) {
+		t.Errorf("Expected 4 saved clicks, got %+v", stats)
+	}
+	saved := 0
+	for len(repo.batches) > 0 {
+		saved += len(<-repo.batches)
+	}
+	if saved != 4 {
+		t.Errorf("Expected 4 recorded clicks, got %d", saved)
+	}
+}
+
+func TestClickWorker_SpoolsBatchWhenDatabaseStaysLocked(t *testing.T) {
+	repo := newBatchRecorder(flushAttempts, errors.New("failed to create clicks: database is locked"))
+	clickSpool, err := spool.Open(t.TempDir(), 1<<20, 0)
+	if err != nil {
+		t.Fatalf("Failed to open spool: %v", err)
+	}
+	events := make(chan models.ClickEvent, 10)
+	pool := StartClickWorkers(1, events, repo, analytics.NewClickEnricher(analytics.NewBotDetector(nil), nil),
+		WorkerOptions{Batch: BatchConfig{Size: 3, MaxLatency: time.Hour}, Spool: clickSpool})
+
+	sendClicks(events, 3)
+	close(events)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := pool.Wait(ctx); err != nil {
+		t.Fatalf("Workers did not stop: %v", err)
+	}
+
+	if stats := pool.Stats(); stats != (PipelineStats{Spooled: 3}) {
+		t.Errorf("Expected 3 spooled clicks, got %+v", stats)
+	}
+	replayed, err := clickSpool.Replay(context.Background(), func(event models.ClickEvent) bool {
+		event.Done()
+		return true
+	})
+	if err != nil || replayed != 3 {
+		t.Errorf("Expected 3 events in spool, got %d (err: %v)", replayed, err)
+	}
+}
+
+func TestClickWorker_RetriesLockedDatabase(t *testing.T) {
+	repo := newBatchRecorder(2, errors.New("failed to create clicks: database is locked"))
+	events := startWorker(t, repo, BatchConfig{Size: 2, MaxLatency: time.Hour})
+
+	sendClicks(events, 2)
+	if got := len(waitBatch(t, repo, 2*time.Second)); got != 2 {
+		t.Errorf("Expected a batch of 2 clicks, got %d", got)
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", repo.attempts)
+	}
+}
+
+func TestClickWorker_DoesNotRetryOtherErrors(t *testing.T) {
+	repo := newBatchRecorder(1, errors.New("failed to create clicks: constraint failed"))
+	events := startWorker(t, repo, BatchConfig{Size: 1, MaxLatency: time.Hour})
+
+	// Le premier lot est abandonné, le suivant est enregistré.
+	sendClicks(events, 2)
+	if got := len(waitBatch(t, repo, time.Second)); got != 1 {
+		t.Errorf("Expected a batch of 1 click, got %d", got)
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", repo.attempts)
+	}
+}
+
+// rejectingRecorder refuse tout lot contenant un clic de rejectedLink, comme une base qui refuse une ligne.
+type rejectingRecorder struct {
+	*mocks.MockClickRepository
+	mu           sync.Mutex
+	rejectedLink uint
+	saved        []*models.Click
+}
+
+func (r *rejectingRecorder) CreateClicks(clicks []*models.Click) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, click := range clicks {
+		if click.LinkID == r.rejectedLink {
+			return errors.New("failed to create clicks: value too long for type character varying(255)")
+		}
+	}
+	r.saved = append(r.saved, clicks...)
+	return nil
+}
+
+func TestClickWorker_IsolatesRejectedClicks(t *testing.T) {
+	repo := &rejectingRecorder{MockClickRepository: mocks.NewMockClickRepository(), rejectedLink: 4}
+	events := make(chan models.ClickEvent, 10)
+	pool := StartClickWorkers(1, events, repo, analytics.NewClickEnricher(analytics.NewBotDetector(nil), nil),
+		WorkerOptions{Batch: BatchConfig{Size: 7, MaxLatency: time.Hour}})
+
+	for linkID := uint(1); linkID <= 7; linkID++ {
+		events <- models.ClickEvent{LinkID: linkID, Timestamp: time.Now(), UserAgent: "Mozilla/5.0 Firefox/120.0", Method: "GET"}
+	}
+	close(events)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := pool.Wait(ctx); err != nil {
+		t.Fatalf("Workers did not stop: %v", err)
+	}
+
+	if stats := pool.Stats(); stats != (PipelineStats{Saved: 6, Dropped: 1}) {
+		t.Errorf("Expected only the rejected click to be dropped, got %+v", stats)
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	var linkIDs []uint
+	for _, click := range repo.saved {
+		linkIDs = append(linkIDs, click.LinkID)
+	}
+	if !slices.Equal(linkIDs, []uint{1, 2, 3, 5, 6, 7}) {
+		t.Errorf("Expected clicks of links 1-3 and 5-7 to be saved, got %v", linkIDs)
+	}
+}
+
+func TestSpoolReplayer_RemovesEventsOnceSaved(t *testing.T) {
+	clickSpool, err := spool.Open(t.TempDir(), 1<<20, 0)
+	if err != nil {
+		t.Fatalf("Failed to open spool: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := clickSpool.Append(models.ClickEvent{LinkID: 1, Timestamp: time.Now(), Method: "GET"}); err != nil {
+			t.Fatalf("Failed to spool event: %v", err)
+		}
+	}
+
+	// Tant que le lot attend son délai maximal, les événements relus restent dans le spool.
+	repo := newBatchRecorder(0, nil)
+	events := startWorker(t, repo, BatchConfig{Size: 10, MaxLatency: 300 * time.Millisecond})
+	replayer := StartSpoolReplayer(clickSpool, events, time.Hour, slog.New(slog.DiscardHandler))
+
+	time.Sleep(50 * time.Millisecond)
+	if !clickSpool.HasPending() {
+		t.Fatal("Expected the replayed events to stay in the spool until saved")
+	}
+
+	// Stop attend l'enregistrement des événements déjà relus.
+	replayer.Stop(context.Background())
+	if got := len(waitBatch(t, repo, time.Second)); got != 3 {
+		t.Errorf("Expected a batch of 3 clicks, got %d", got)
+	}
+	if clickSpool.HasPending() {
+		t.Error("Expected the saved events to leave the spool")
+	}
+}
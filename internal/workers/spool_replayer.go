@@ -0,0 +1,119 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/spool"
+)
+
+// SpoolReplayer réinjecte dans le channel des workers les événements de clic mis en attente
+// dans le spool : au démarrage, puis dès que le channel est redescendu à moitié de sa capacité.
+// Les événements relus ne quittent le spool qu'une fois traités par les workers.
+type SpoolReplayer struct {
+	spool    *spool.Spool
+	events   chan<- models.ClickEvent
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+	logger   *slog.Logger
+	// acks borne l'attente du traitement des événements relus ; annulé par Stop.
+	acks       context.Context
+	cancelAcks context.CancelFunc
+}
+
+// StartSpoolReplayer démarre la relecture du spool, vérifiée toutes les interval.
+func StartSpoolReplayer(clickSpool *spool.Spool, events chan<- models.ClickEvent, interval time.Duration,
+	logger *slog.Logger) *SpoolReplayer {
+	acks, cancelAcks := context.WithCancel(context.Background())
+	r := &SpoolReplayer{
+		spool:    clickSpool,
+		events:   events,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		logger:   logger.With("component", "spool_replayer"),
+
+		acks:       acks,
+		cancelAcks: cancelAcks,
+	}
+	go r.run()
+	return r
+}
+
+// Stop arrête la relecture et attend, au plus jusqu'à l'expiration de ctx, que les workers aient
+// traité les événements déjà relus : le channel doit rester ouvert jusque-là. Les événements non
+// transmis restent dans le spool, de même que ceux d'un segment encore en cours de traitement à
+// l'expiration de ctx.
+func (r *SpoolReplayer) Stop(ctx context.Context) {
+	close(r.stop)
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		r.cancelAcks()
+		<-r.done
+	}
+	r.cancelAcks()
+}
+
+func (r *SpoolReplayer) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.replay()
+	for {
+		select {
+		case <-ticker.C:
+			if r.spool.HasPending() && len(r.events) <= cap(r.events)/2 {
+				r.replay()
+			}
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// replay transmet les événements du spool au rythme des workers, jusqu'à ce que le spool
+// soit vide ou que Stop soit appelé.
+func (r *SpoolReplayer) replay() {
+	replayed, err := r.spool.Replay(r.acks, func(event models.ClickEvent) bool {
+		select {
+		case r.events <- event:
+			return true
+		case <-r.stop:
+			return false
+		}
+	})
+	if err != nil {
+		r.logger.Error("Failed to replay click spool", "error", err)
+	}
+	if replayed > 0 {
+		r.logger.Info("Replayed click events from spool", "events", replayed)
+	}
+}
+
+// SpoolPendingEvents transfère dans le spool les événements encore présents dans le channel,
+// sans attendre, et retourne le nombre d'événements conservés.
+func SpoolPendingEvents(events <-chan models.ClickEvent, clickSpool *spool.Spool, logger *slog.Logger) int {
+	spooled := 0
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return spooled
+			}
+			if err := clickSpool.Append(event); err != nil {
+				logger.Error("Failed to spool pending click event", "link_id", event.LinkID, "error", err)
+				continue
+			}
+			markDone(event)
+			spooled++
+		default:
+			return spooled
+		}
+	}
+}
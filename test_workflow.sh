@@ -46,6 +46,15 @@ else
     exit 1
 fi
 
+# Issue an API key for the /api/v1 routes
+API_KEY=$(./url-shortener apikey create --name="integration-test" 2>/dev/null | grep "^Clé:" | awk '{print $2}')
+if [ -n "$API_KEY" ]; then
+    echo -e "${GREEN}✅ API key issued${NC}"
+else
+    echo -e "${RED}❌ Failed to issue API key${NC}"
+    exit 1
+fi
+
 # Step 3: Start Server
 echo -e "\n${BLUE}Step 3: Start Server${NC}"
 ./url-shortener run-server > server.log 2>&1 &
@@ -84,6 +93,7 @@ fi
 echo -e "\n${BLUE}Step 5: Create Link via API${NC}"
 response=$(curl -s -X POST "$SERVER_URL/api/v1/links" \
     -H "Content-Type: application/json" \
+    -H "Authorization: Bearer $API_KEY" \
     -d '{"long_url":"https://www.google.com"}')
 
 echo "API response: $response"
@@ -128,7 +138,7 @@ sleep 3
 
 # Test API stats (no timeout needed on macOS)
 echo "Testing API stats..."
-stats_response=$(curl -s "$SERVER_URL/api/v1/links/$short_code/stats")
+stats_response=$(curl -s -H "Authorization: Bearer $API_KEY" "$SERVER_URL/api/v1/links/$short_code/stats")
 echo "Stats response: $stats_response"
 
 if echo "$stats_response" | jq -e '.total_clicks' > /dev/null 2>&1; then